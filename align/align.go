@@ -0,0 +1,180 @@
+package align
+
+import (
+	"math"
+	"sort"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+/*
+	Orthogonal Procrustes alignment of embedding spaces
+
+	Retraining word2vec gives a space that is rotated (and reflected) relative to the previous
+	one, even on the same corpus. Procrustes finds the orthogonal matrix W minimizing
+	|X·W - Y| over the words both versions share, where X holds the new vectors and Y the
+	reference ones: with the SVD XᵀY = UΣVᵀ, W = UVᵀ (Schönemann, 1966). Being orthogonal, W
+	keeps all distances and similarities within the new space intact.
+
+	After alignment a shared word whose vectors are still far apart changed its usage between
+	the corpora (Hamilton et al., 2016, "Diachronic Word Embeddings").
+*/
+
+type Options struct {
+	// Normalize fits the rotation on unit length vectors, so frequent words with long vectors
+	// don't dominate it. The rotation is applied to the vectors as they are either way.
+	Normalize bool
+	// Anchors limits the fit to the first Anchors shared words, in the order of the new file
+	// (by frequency for word2vec output). 0 uses every shared word.
+	Anchors int
+	// Top is the number of most drifted words to report, 20 by default
+	Top int
+}
+
+// Alignment maps vectors of a new model into the space of a reference model
+type Alignment struct {
+	Rotation [][]float64 `json:"-"` // dim × dim, applied as v·Rotation
+	Shared   int         `json:"shared_words"`
+	Anchors  int         `json:"anchors"`
+	// ErrorBefore and ErrorAfter are the root mean square distance between the new and the
+	// reference vectors of the anchors, before and after rotating (normalized if Options.Normalize)
+	ErrorBefore float64 `json:"error_before"`
+	ErrorAfter  float64 `json:"error_after"`
+	// MeanSimilarity is the mean cosine similarity of the aligned and reference vectors of all
+	// shared words
+	MeanSimilarity float64 `json:"mean_similarity"`
+	Drifted        []Drift `json:"drifted"`
+}
+
+// Drift is a shared word and the cosine similarity of its aligned and reference vectors
+type Drift struct {
+	Word       string  `json:"word"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Procrustes fits the rotation taking embs into the space of reference
+func Procrustes(reference []embeddings.Embedding, embs []embeddings.Embedding, opts Options) (*Alignment, error) {
+	if opts.Top <= 0 {
+		opts.Top = 20
+	}
+	if len(reference) == 0 || len(embs) == 0 {
+		return nil, errors.Invalid("embeddings", "both models need vectors")
+	}
+	dim := len(reference[0].Vector)
+	if len(embs[0].Vector) != dim {
+		return nil, errors.DimensionMismatch("new vectors", len(embs[0].Vector), dim)
+	}
+	referenceVectors := make(map[string][]float32, len(reference))
+	for _, e := range reference {
+		if len(e.Vector) != dim {
+			return nil, errors.DimensionMismatch("reference vector of "+e.Word, len(e.Vector), dim)
+		}
+		referenceVectors[e.Word] = e.Vector
+	}
+
+	var x, y [][]float64
+	var shared []string
+	for _, e := range embs {
+		ref, ok := referenceVectors[e.Word]
+		if !ok {
+			continue
+		}
+		if len(e.Vector) != dim {
+			return nil, errors.DimensionMismatch("new vector of "+e.Word, len(e.Vector), dim)
+		}
+		shared = append(shared, e.Word)
+		x = append(x, prepare(e.Vector, opts.Normalize))
+		y = append(y, prepare(ref, opts.Normalize))
+	}
+	if len(shared) == 0 {
+		return nil, errors.Invalid("embeddings", "the models share no words")
+	}
+	anchors := len(shared)
+	if opts.Anchors > 0 && opts.Anchors < anchors {
+		anchors = opts.Anchors
+	}
+
+	// M = XᵀY over the anchors
+	m := make([][]float64, dim)
+	for i := range m {
+		m[i] = make([]float64, dim)
+	}
+	for a := 0; a < anchors; a++ {
+		for i, xi := range x[a] {
+			for j, yj := range y[a] {
+				m[i][j] += xi * yj
+			}
+		}
+	}
+	u, v := svd(m)
+	rotation := make([][]float64, dim)
+	for i := range rotation {
+		rotation[i] = make([]float64, dim)
+		for k := range rotation[i] {
+			for j := 0; j < dim; j++ {
+				rotation[i][k] += u[i][j] * v[k][j]
+			}
+		}
+	}
+
+	alignment := &Alignment{Rotation: rotation, Shared: len(shared), Anchors: anchors}
+	var before, after float64
+	for a := 0; a < anchors; a++ {
+		rotated := rotate(x[a], rotation)
+		for j := range rotated {
+			before += (x[a][j] - y[a][j]) * (x[a][j] - y[a][j])
+			after += (rotated[j] - y[a][j]) * (rotated[j] - y[a][j])
+		}
+	}
+	alignment.ErrorBefore = math.Sqrt(before / float64(anchors))
+	alignment.ErrorAfter = math.Sqrt(after / float64(anchors))
+
+	drifts := make([]Drift, len(shared))
+	for i, word := range shared {
+		drifts[i] = Drift{Word: word, Similarity: vecmath.Cosine64(rotate(x[i], rotation), y[i])}
+		alignment.MeanSimilarity += drifts[i].Similarity
+	}
+	alignment.MeanSimilarity /= float64(len(shared))
+	sort.SliceStable(drifts, func(i, j int) bool { return drifts[i].Similarity < drifts[j].Similarity })
+	alignment.Drifted = drifts[:min(opts.Top, len(drifts))]
+	return alignment, nil
+}
+
+// Apply returns embs rotated into the reference space
+func (a *Alignment) Apply(embs []embeddings.Embedding) ([]embeddings.Embedding, error) {
+	aligned := make([]embeddings.Embedding, len(embs))
+	for i, e := range embs {
+		if len(e.Vector) != len(a.Rotation) {
+			return nil, errors.DimensionMismatch("vector of "+e.Word, len(e.Vector), len(a.Rotation))
+		}
+		rotated := rotate(prepare(e.Vector, false), a.Rotation)
+		aligned[i] = embeddings.Embedding{Word: e.Word, Vector: make([]float32, len(rotated))}
+		for j, x := range rotated {
+			aligned[i].Vector[j] = float32(x)
+		}
+	}
+	return aligned, nil
+}
+
+func prepare(v []float32, normalize bool) []float64 {
+	out := make([]float64, len(v))
+	for j, x := range v {
+		out[j] = float64(x)
+	}
+	if normalize {
+		vecmath.Normalize64(out)
+	}
+	return out
+}
+
+func rotate(v []float64, rotation [][]float64) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		for k, r := range rotation[i] {
+			out[k] += x * r
+		}
+	}
+	return out
+}
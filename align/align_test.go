@@ -0,0 +1,159 @@
+package align
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+// randomRotation returns a random orthogonal matrix, by Gram-Schmidt on a Gaussian one
+func randomRotation(dim int, rng *rand.Rand) [][]float64 {
+	rows := make([][]float64, dim)
+	for i := range rows {
+		rows[i] = make([]float64, dim)
+		for j := range rows[i] {
+			rows[i][j] = rng.NormFloat64()
+		}
+		for k := 0; k < i; k++ {
+			var dot float64
+			for j := range rows[i] {
+				dot += rows[i][j] * rows[k][j]
+			}
+			for j := range rows[i] {
+				rows[i][j] -= dot * rows[k][j]
+			}
+		}
+		var norm float64
+		for _, x := range rows[i] {
+			norm += x * x
+		}
+		for j := range rows[i] {
+			rows[i][j] /= math.Sqrt(norm)
+		}
+	}
+	return rows
+}
+
+// rotatedModels returns a reference model and a rotated, slightly noisy copy of it in which
+// "drifter" moved somewhere else
+func rotatedModels(n int, dim int, seed int64) ([]embeddings.Embedding, []embeddings.Embedding, [][]float64) {
+	rng := rand.New(rand.NewSource(seed))
+	rotation := randomRotation(dim, rng)
+	reference := make([]embeddings.Embedding, n)
+	rotated := make([]embeddings.Embedding, n)
+	for i := range reference {
+		word := fmt.Sprintf("w%d", i)
+		if i == n/2 {
+			word = "drifter"
+		}
+		reference[i] = embeddings.Embedding{Word: word, Vector: make([]float32, dim)}
+		for j := range reference[i].Vector {
+			reference[i].Vector[j] = float32(rng.NormFloat64())
+		}
+		source := reference[i].Vector
+		if word == "drifter" {
+			source = make([]float32, dim)
+			for j := range source {
+				source[j] = float32(rng.NormFloat64())
+			}
+		}
+		// rotated = source · rotationᵀ, so rotation takes it back
+		rotated[i] = embeddings.Embedding{Word: word, Vector: make([]float32, dim)}
+		for k := range rotated[i].Vector {
+			var sum float64
+			for j, x := range source {
+				sum += float64(x) * rotation[k][j]
+			}
+			rotated[i].Vector[k] = float32(sum + rng.NormFloat64()*0.01)
+		}
+	}
+	return reference, rotated, rotation
+}
+
+func TestProcrustes(t *testing.T) {
+	reference, rotated, rotation := rotatedModels(200, 16, 1)
+	// the new model has a word the reference doesn't and lacks one it has
+	rotated = append(rotated[1:], embeddings.Embedding{Word: "newcomer", Vector: make([]float32, 16)})
+
+	alignment, err := Procrustes(reference, rotated, Options{Top: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alignment.Shared != 199 || alignment.Anchors != 199 {
+		t.Errorf("shared %d, anchors %d, want 199", alignment.Shared, alignment.Anchors)
+	}
+	for i := range rotation {
+		for k := range rotation {
+			if math.Abs(alignment.Rotation[i][k]-rotation[i][k]) > 0.02 {
+				t.Fatalf("rotation[%d][%d] = %f, want %f", i, k, alignment.Rotation[i][k], rotation[i][k])
+			}
+		}
+	}
+	if alignment.ErrorAfter > 0.4 || alignment.ErrorBefore < 4 || alignment.MeanSimilarity < 0.98 {
+		t.Errorf("error before %f, after %f, mean similarity %f", alignment.ErrorBefore, alignment.ErrorAfter, alignment.MeanSimilarity)
+	}
+	if len(alignment.Drifted) != 3 || alignment.Drifted[0].Word != "drifter" || alignment.Drifted[0].Similarity > 0.7 {
+		t.Errorf("drifted = %+v", alignment.Drifted)
+	}
+
+	aligned, err := alignment.Apply(rotated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aligned) != len(rotated) || aligned[len(aligned)-1].Word != "newcomer" {
+		t.Fatalf("aligned %d vectors", len(aligned))
+	}
+	for j, x := range aligned[0].Vector {
+		if math.Abs(float64(x-reference[1].Vector[j])) > 0.05 {
+			t.Errorf("aligned %s = %v, want %v", aligned[0].Word, aligned[0].Vector, reference[1].Vector)
+			break
+		}
+	}
+}
+
+func TestProcrustesOptions(t *testing.T) {
+	reference, rotated, _ := rotatedModels(100, 8, 2)
+	alignment, err := Procrustes(reference, rotated, Options{Normalize: true, Anchors: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alignment.Anchors != 30 || alignment.ErrorAfter > 0.1 || len(alignment.Drifted) != 20 {
+		t.Errorf("anchors %d, error after %f, %d drifted", alignment.Anchors, alignment.ErrorAfter, len(alignment.Drifted))
+	}
+
+	// Fewer anchors than dimensions leaves a rank deficient fit, the rotation must stay orthogonal
+	alignment, err = Procrustes(reference, rotated, Options{Anchors: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range alignment.Rotation {
+		for k := range alignment.Rotation {
+			var dot float64
+			for j := range alignment.Rotation {
+				dot += alignment.Rotation[i][j] * alignment.Rotation[k][j]
+			}
+			expected := 0.0
+			if i == k {
+				expected = 1
+			}
+			if math.Abs(dot-expected) > 1e-9 {
+				t.Fatalf("rotation is not orthogonal: row %d · row %d = %f", i, k, dot)
+			}
+		}
+	}
+}
+
+func TestProcrustesErrors(t *testing.T) {
+	a := []embeddings.Embedding{{Word: "cat", Vector: []float32{1, 0}}}
+	if _, err := Procrustes(a, []embeddings.Embedding{{Word: "cat", Vector: []float32{1, 0, 0}}}, Options{}); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+	if _, err := Procrustes(a, []embeddings.Embedding{{Word: "dog", Vector: []float32{0, 1}}}, Options{}); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error without shared words, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package align
+
+import "math"
+
+// svd factors the square matrix m as U·diag(σ)·Vᵀ with one-sided Jacobi rotations (Hestenes),
+// returning U and V. Columns of U for zero singular values are completed to an orthonormal
+// basis, so U is always orthogonal.
+func svd(m [][]float64) ([][]float64, [][]float64) {
+	n := len(m)
+	a := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), m[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 60; sweep++ {
+		rotated := false
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				var alpha, beta, gamma float64
+				for i := 0; i < n; i++ {
+					alpha += a[i][p] * a[i][p]
+					beta += a[i][q] * a[i][q]
+					gamma += a[i][p] * a[i][q]
+				}
+				if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				for i := 0; i < n; i++ {
+					ap, aq := a[i][p], a[i][q]
+					a[i][p], a[i][q] = c*ap-s*aq, s*ap+c*aq
+					vp, vq := v[i][p], v[i][q]
+					v[i][p], v[i][q] = c*vp-s*vq, s*vp+c*vq
+				}
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	// the columns of a are now orthogonal, their norms the singular values
+	var largest float64
+	norms := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			norms[j] += a[i][j] * a[i][j]
+		}
+		norms[j] = math.Sqrt(norms[j])
+		largest = math.Max(largest, norms[j])
+	}
+	u := make([][]float64, n)
+	for i := range u {
+		u[i] = make([]float64, n)
+	}
+	var missing []int
+	for j := 0; j < n; j++ {
+		if norms[j] <= 1e-12*largest || norms[j] == 0 {
+			missing = append(missing, j)
+			continue
+		}
+		for i := 0; i < n; i++ {
+			u[i][j] = a[i][j] / norms[j]
+		}
+	}
+	for _, j := range missing {
+		completeColumn(u, j)
+	}
+	return u, v
+}
+
+// completeColumn fills column j of u with a unit vector orthogonal to its other non-zero columns
+func completeColumn(u [][]float64, j int) {
+	n := len(u)
+	for e := 0; e < n; e++ {
+		candidate := make([]float64, n)
+		candidate[e] = 1
+		for k := 0; k < n; k++ {
+			if k == j {
+				continue
+			}
+			var dot float64
+			for i := 0; i < n; i++ {
+				dot += candidate[i] * u[i][k]
+			}
+			for i := 0; i < n; i++ {
+				candidate[i] -= dot * u[i][k]
+			}
+		}
+		var norm float64
+		for _, x := range candidate {
+			norm += x * x
+		}
+		if norm = math.Sqrt(norm); norm > 1e-6 {
+			for i := 0; i < n; i++ {
+				u[i][j] = candidate[i] / norm
+			}
+			return
+		}
+	}
+}
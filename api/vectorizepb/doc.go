@@ -0,0 +1,6 @@
+// Package vectorizepb holds the protobuf messages and the generated gRPC client and server
+// interfaces of the Vectorize service defined in vectorize.proto. Regenerate with go generate
+// (needs protoc, protoc-gen-go and protoc-gen-go-grpc on the PATH).
+package vectorizepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative vectorize.proto
@@ -0,0 +1,429 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: vectorize.proto
+
+package vectorizepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Vectorize_Embed_FullMethodName        = "/vectorize.v1.Vectorize/Embed"
+	Vectorize_SimilarWords_FullMethodName = "/vectorize.v1.Vectorize/SimilarWords"
+	Vectorize_Search_FullMethodName       = "/vectorize.v1.Vectorize/Search"
+	Vectorize_Query_FullMethodName        = "/vectorize.v1.Vectorize/Query"
+	Vectorize_Insert_FullMethodName       = "/vectorize.v1.Vectorize/Insert"
+	Vectorize_Upsert_FullMethodName       = "/vectorize.v1.Vectorize/Upsert"
+)
+
+// VectorizeClient is the client API for Vectorize service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VectorizeClient interface {
+	// Embed averages the word vectors of each text
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	// SimilarWords finds the words closest to a word, a text or a raw vector
+	SimilarWords(ctx context.Context, in *SimilarWordsRequest, opts ...grpc.CallOption) (*SimilarWordsResponse, error)
+	// Search runs a vector search against a collection, streaming one hit per message
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Vectorize_SearchClient, error)
+	// Query runs a filter expression, paging through Milvus and streaming one row per message
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Vectorize_QueryClient, error)
+	// Insert bulk loads rows. The first message names the collection, every message is inserted as one batch.
+	Insert(ctx context.Context, opts ...grpc.CallOption) (Vectorize_InsertClient, error)
+	// Upsert is Insert, replacing entities with the same primary key
+	Upsert(ctx context.Context, opts ...grpc.CallOption) (Vectorize_UpsertClient, error)
+}
+
+type vectorizeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVectorizeClient(cc grpc.ClientConnInterface) VectorizeClient {
+	return &vectorizeClient{cc}
+}
+
+func (c *vectorizeClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, Vectorize_Embed_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorizeClient) SimilarWords(ctx context.Context, in *SimilarWordsRequest, opts ...grpc.CallOption) (*SimilarWordsResponse, error) {
+	out := new(SimilarWordsResponse)
+	err := c.cc.Invoke(ctx, Vectorize_SimilarWords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorizeClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Vectorize_SearchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Vectorize_ServiceDesc.Streams[0], Vectorize_Search_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vectorizeSearchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Vectorize_SearchClient interface {
+	Recv() (*SearchHit, error)
+	grpc.ClientStream
+}
+
+type vectorizeSearchClient struct {
+	grpc.ClientStream
+}
+
+func (x *vectorizeSearchClient) Recv() (*SearchHit, error) {
+	m := new(SearchHit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *vectorizeClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Vectorize_QueryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Vectorize_ServiceDesc.Streams[1], Vectorize_Query_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vectorizeQueryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Vectorize_QueryClient interface {
+	Recv() (*Row, error)
+	grpc.ClientStream
+}
+
+type vectorizeQueryClient struct {
+	grpc.ClientStream
+}
+
+func (x *vectorizeQueryClient) Recv() (*Row, error) {
+	m := new(Row)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *vectorizeClient) Insert(ctx context.Context, opts ...grpc.CallOption) (Vectorize_InsertClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Vectorize_ServiceDesc.Streams[2], Vectorize_Insert_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vectorizeInsertClient{stream}
+	return x, nil
+}
+
+type Vectorize_InsertClient interface {
+	Send(*WriteRequest) error
+	CloseAndRecv() (*WriteResponse, error)
+	grpc.ClientStream
+}
+
+type vectorizeInsertClient struct {
+	grpc.ClientStream
+}
+
+func (x *vectorizeInsertClient) Send(m *WriteRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *vectorizeInsertClient) CloseAndRecv() (*WriteResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(WriteResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *vectorizeClient) Upsert(ctx context.Context, opts ...grpc.CallOption) (Vectorize_UpsertClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Vectorize_ServiceDesc.Streams[3], Vectorize_Upsert_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vectorizeUpsertClient{stream}
+	return x, nil
+}
+
+type Vectorize_UpsertClient interface {
+	Send(*WriteRequest) error
+	CloseAndRecv() (*WriteResponse, error)
+	grpc.ClientStream
+}
+
+type vectorizeUpsertClient struct {
+	grpc.ClientStream
+}
+
+func (x *vectorizeUpsertClient) Send(m *WriteRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *vectorizeUpsertClient) CloseAndRecv() (*WriteResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(WriteResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VectorizeServer is the server API for Vectorize service.
+// All implementations must embed UnimplementedVectorizeServer
+// for forward compatibility
+type VectorizeServer interface {
+	// Embed averages the word vectors of each text
+	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	// SimilarWords finds the words closest to a word, a text or a raw vector
+	SimilarWords(context.Context, *SimilarWordsRequest) (*SimilarWordsResponse, error)
+	// Search runs a vector search against a collection, streaming one hit per message
+	Search(*SearchRequest, Vectorize_SearchServer) error
+	// Query runs a filter expression, paging through Milvus and streaming one row per message
+	Query(*QueryRequest, Vectorize_QueryServer) error
+	// Insert bulk loads rows. The first message names the collection, every message is inserted as one batch.
+	Insert(Vectorize_InsertServer) error
+	// Upsert is Insert, replacing entities with the same primary key
+	Upsert(Vectorize_UpsertServer) error
+	mustEmbedUnimplementedVectorizeServer()
+}
+
+// UnimplementedVectorizeServer must be embedded to have forward compatible implementations.
+type UnimplementedVectorizeServer struct {
+}
+
+func (UnimplementedVectorizeServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
+}
+func (UnimplementedVectorizeServer) SimilarWords(context.Context, *SimilarWordsRequest) (*SimilarWordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimilarWords not implemented")
+}
+func (UnimplementedVectorizeServer) Search(*SearchRequest, Vectorize_SearchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedVectorizeServer) Query(*QueryRequest, Vectorize_QueryServer) error {
+	return status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedVectorizeServer) Insert(Vectorize_InsertServer) error {
+	return status.Errorf(codes.Unimplemented, "method Insert not implemented")
+}
+func (UnimplementedVectorizeServer) Upsert(Vectorize_UpsertServer) error {
+	return status.Errorf(codes.Unimplemented, "method Upsert not implemented")
+}
+func (UnimplementedVectorizeServer) mustEmbedUnimplementedVectorizeServer() {}
+
+// UnsafeVectorizeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VectorizeServer will
+// result in compilation errors.
+type UnsafeVectorizeServer interface {
+	mustEmbedUnimplementedVectorizeServer()
+}
+
+func RegisterVectorizeServer(s grpc.ServiceRegistrar, srv VectorizeServer) {
+	s.RegisterService(&Vectorize_ServiceDesc, srv)
+}
+
+func _Vectorize_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorizeServer).Embed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vectorize_Embed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorizeServer).Embed(ctx, req.(*EmbedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vectorize_SimilarWords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimilarWordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorizeServer).SimilarWords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Vectorize_SimilarWords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorizeServer).SimilarWords(ctx, req.(*SimilarWordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Vectorize_Search_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VectorizeServer).Search(m, &vectorizeSearchServer{stream})
+}
+
+type Vectorize_SearchServer interface {
+	Send(*SearchHit) error
+	grpc.ServerStream
+}
+
+type vectorizeSearchServer struct {
+	grpc.ServerStream
+}
+
+func (x *vectorizeSearchServer) Send(m *SearchHit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Vectorize_Query_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VectorizeServer).Query(m, &vectorizeQueryServer{stream})
+}
+
+type Vectorize_QueryServer interface {
+	Send(*Row) error
+	grpc.ServerStream
+}
+
+type vectorizeQueryServer struct {
+	grpc.ServerStream
+}
+
+func (x *vectorizeQueryServer) Send(m *Row) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Vectorize_Insert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VectorizeServer).Insert(&vectorizeInsertServer{stream})
+}
+
+type Vectorize_InsertServer interface {
+	SendAndClose(*WriteResponse) error
+	Recv() (*WriteRequest, error)
+	grpc.ServerStream
+}
+
+type vectorizeInsertServer struct {
+	grpc.ServerStream
+}
+
+func (x *vectorizeInsertServer) SendAndClose(m *WriteResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *vectorizeInsertServer) Recv() (*WriteRequest, error) {
+	m := new(WriteRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Vectorize_Upsert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VectorizeServer).Upsert(&vectorizeUpsertServer{stream})
+}
+
+type Vectorize_UpsertServer interface {
+	SendAndClose(*WriteResponse) error
+	Recv() (*WriteRequest, error)
+	grpc.ServerStream
+}
+
+type vectorizeUpsertServer struct {
+	grpc.ServerStream
+}
+
+func (x *vectorizeUpsertServer) SendAndClose(m *WriteResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *vectorizeUpsertServer) Recv() (*WriteRequest, error) {
+	m := new(WriteRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Vectorize_ServiceDesc is the grpc.ServiceDesc for Vectorize service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Vectorize_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vectorize.v1.Vectorize",
+	HandlerType: (*VectorizeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Embed",
+			Handler:    _Vectorize_Embed_Handler,
+		},
+		{
+			MethodName: "SimilarWords",
+			Handler:    _Vectorize_SimilarWords_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Search",
+			Handler:       _Vectorize_Search_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Query",
+			Handler:       _Vectorize_Query_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Insert",
+			Handler:       _Vectorize_Insert_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Upsert",
+			Handler:       _Vectorize_Upsert_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "vectorize.proto",
+}
@@ -0,0 +1,63 @@
+// Package atomicfile replaces files through a temporary file, so readers and crashes never leave
+// half of a new file behind.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"milvus/errors"
+)
+
+// Write writes path through a temporary file in the same directory that is synced and renamed
+// over path only if write succeeds. On failure path is left untouched and the error is an
+// errors.FileCreationErr.
+func Write(path string, write func(w io.Writer) error) (err error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			err = errors.FileCreationErr(path, err)
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	// CreateTemp makes the file readable by its owner only, os.Create would have used 0666 minus the umask
+	if err = tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Persist the rename itself; not every platform can sync a directory, so this is best effort
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// WriteBytes is Write of data
+func WriteBytes(path string, data []byte) error {
+	return Write(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
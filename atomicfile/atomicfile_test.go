@@ -0,0 +1,41 @@
+package atomicfile
+
+import (
+	stdErrors "errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"milvus/errors"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := WriteBytes(path, []byte("first\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failed := stdErrors.New("write failed")
+	err := Write(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return failed
+	})
+	if !stdErrors.Is(err, failed) || !errors.IsFileError(err, "FileCreationError") {
+		t.Errorf("expected a file creation error wrapping the write error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first\n" {
+		t.Errorf("a failed write changed the file: %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files were left behind: %v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("file mode = %v, %v", info.Mode(), err)
+	}
+
+	if err := WriteBytes(filepath.Join(dir, "missing", "file.txt"), nil); !errors.IsFileError(err, "FileCreationError") {
+		t.Errorf("expected a file creation error, got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"milvus/vecmath"
+)
+
+/*
+	Recall / latency benchmark for picking index types and parameters with data instead of guesswork.
+
+	1. Exact ground truth for every query is computed by brute force
+	2. Each VectorStore is built from the same vectors
+	3. Every query is run against each store, timing each call
+	4. Recall@k, QPS and p50/p99 latency are reported per store
+*/
+
+// VectorStore is any backend that can index vectors and answer top-k queries. IDs are row numbers in the build set.
+type VectorStore interface {
+	Name() string
+	Build(vectors [][]float32, ctx context.Context) error
+	Search(query []float32, k int, ctx context.Context) ([]int64, error)
+	Close(ctx context.Context) error
+}
+
+type Metric string
+
+const (
+	L2     Metric = "L2"
+	IP     Metric = "IP"
+	Cosine Metric = "COSINE"
+)
+
+type Dataset struct {
+	Name        string
+	Train       [][]float32
+	Queries     [][]float32
+	GroundTruth [][]int64 // optional, computed by Run if empty
+}
+
+type Result struct {
+	Store     string        `json:"store"`
+	K         int           `json:"k"`
+	Queries   int           `json:"queries"`
+	Recall    float64       `json:"recall"`
+	QPS       float64       `json:"qps"`
+	P50       time.Duration `json:"p50_ns"`
+	P99       time.Duration `json:"p99_ns"`
+	BuildTime time.Duration `json:"build_ns"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Distance is "smaller is closer" for every metric
+func Distance(metric Metric, a []float32, b []float32) float32 {
+	switch metric {
+	case IP:
+		return -vecmath.Dot(a, b)
+	case Cosine:
+		na, nb := vecmath.Dot(a, a), vecmath.Dot(b, b)
+		if na == 0 || nb == 0 {
+			return 1
+		}
+		return 1 - vecmath.Dot(a, b)/float32(math.Sqrt(float64(na)*float64(nb)))
+	}
+	return vecmath.SquaredL2(a, b)
+}
+
+// GroundTruth returns the exact k nearest train rows for every query, computed in parallel
+func GroundTruth(train [][]float32, queries [][]float32, k int, metric Metric) [][]int64 {
+	truth := make([][]int64, len(queries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for q := range jobs {
+				truth[q] = exactSearch(train, queries[q], k, metric)
+			}
+		}()
+	}
+	for q := range queries {
+		jobs <- q
+	}
+	close(jobs)
+	wg.Wait()
+	return truth
+}
+
+func exactSearch(train [][]float32, query []float32, k int, metric Metric) []int64 {
+	type scored struct {
+		id   int64
+		dist float32
+	}
+	scores := make([]scored, len(train))
+	for i, v := range train {
+		scores[i] = scored{id: int64(i), dist: Distance(metric, query, v)}
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].dist < scores[j].dist })
+	if k > len(scores) {
+		k = len(scores)
+	}
+	ids := make([]int64, k)
+	for i := range ids {
+		ids[i] = scores[i].id
+	}
+	return ids
+}
+
+// Recall is the fraction of the true top-k found in results, averaged over queries
+func Recall(truth [][]int64, results [][]int64, k int) float64 {
+	if len(truth) == 0 {
+		return 0
+	}
+	total := 0.0
+	for q := range truth {
+		expected := truth[q]
+		if len(expected) > k {
+			expected = expected[:k]
+		}
+		want := make(map[int64]struct{}, len(expected))
+		for _, id := range expected {
+			want[id] = struct{}{}
+		}
+		hits := 0
+		for i, id := range results[q] {
+			if i == k {
+				break
+			}
+			if _, ok := want[id]; ok {
+				hits++
+			}
+		}
+		if len(expected) > 0 {
+			total += float64(hits) / float64(len(expected))
+		}
+	}
+	return total / float64(len(truth))
+}
+
+// Run builds and queries every store against the dataset. A failing store is reported in its Result and skipped.
+func Run(stores []VectorStore, dataset Dataset, k int, metric Metric, ctx context.Context) ([]Result, error) {
+	if len(dataset.Train) == 0 || len(dataset.Queries) == 0 {
+		return nil, fmt.Errorf("dataset %q needs train vectors and queries", dataset.Name)
+	}
+	if len(dataset.GroundTruth) == 0 {
+		dataset.GroundTruth = GroundTruth(dataset.Train, dataset.Queries, k, metric)
+	}
+	if len(dataset.GroundTruth) != len(dataset.Queries) {
+		return nil, fmt.Errorf("dataset %q has %d queries but %d ground truth rows", dataset.Name, len(dataset.Queries), len(dataset.GroundTruth))
+	}
+
+	results := make([]Result, 0, len(stores))
+	for _, store := range stores {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := runStore(store, dataset, k, ctx)
+		if err := store.Close(ctx); err != nil && result.Error == "" {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func runStore(store VectorStore, dataset Dataset, k int, ctx context.Context) Result {
+	result := Result{Store: store.Name(), K: k, Queries: len(dataset.Queries)}
+
+	start := time.Now()
+	if err := store.Build(dataset.Train, ctx); err != nil {
+		result.Error = fmt.Sprintf("build: %s", err)
+		return result
+	}
+	result.BuildTime = time.Since(start)
+
+	found := make([][]int64, len(dataset.Queries))
+	latencies := make([]time.Duration, len(dataset.Queries))
+	var total time.Duration
+	for q, query := range dataset.Queries {
+		start := time.Now()
+		ids, err := store.Search(query, k, ctx)
+		latencies[q] = time.Since(start)
+		if err != nil {
+			result.Error = fmt.Sprintf("search: %s", err)
+			return result
+		}
+		found[q] = ids
+		total += latencies[q]
+	}
+
+	result.Recall = Recall(dataset.GroundTruth, found, k)
+	if total > 0 {
+		result.QPS = float64(len(dataset.Queries)) / total.Seconds()
+	}
+	result.P50 = Percentile(latencies, 50)
+	result.P99 = Percentile(latencies, 99)
+	return result
+}
+
+// Percentile returns the p-th percentile (nearest rank) of latencies
+func Percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,121 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"milvus/hnsw"
+)
+
+func randomVectors(n int, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()
+		}
+	}
+	return vectors
+}
+
+func TestRun(t *testing.T) {
+	dataset := Dataset{
+		Name:    "random",
+		Train:   randomVectors(500, 8, 1),
+		Queries: randomVectors(20, 8, 2),
+	}
+	stores := []VectorStore{
+		&BruteForce{Metric: L2},
+		&HNSW{Config: hnsw.Config{M: 8, EfConstruction: 64, EfSearch: 64, Metric: hnsw.L2}},
+	}
+
+	results, err := Run(stores, dataset, 5, L2, context.Background())
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Recall != 1 {
+		t.Errorf("brute force recall = %f, expected 1", results[0].Recall)
+	}
+	if results[1].Recall < 0.9 {
+		t.Errorf("hnsw recall = %f, expected at least 0.9", results[1].Recall)
+	}
+	for _, r := range results {
+		if r.Error != "" || r.QPS <= 0 || r.P99 < r.P50 {
+			t.Errorf("unexpected result %+v", r)
+		}
+	}
+
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{format: "csv", expect: "store,k,queries,recall"},
+		{format: "json", expect: `"recall": 1`},
+		{format: "md", expect: "| flat(metric=L2) | 5 | 20 | 1.0000 |"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, results, tt.format); err != nil {
+			t.Fatalf("%s report failed: %v", tt.format, err)
+		}
+		if !strings.Contains(buf.String(), tt.expect) {
+			t.Errorf("%s report missing %q:\n%s", tt.format, tt.expect, buf.String())
+		}
+	}
+}
+
+func TestRecall(t *testing.T) {
+	truth := [][]int64{{1, 2, 3, 4}, {5, 6, 7, 8}}
+	found := [][]int64{{4, 3, 9, 10}, {5, 6, 7, 8}}
+	if recall := Recall(truth, found, 4); recall != 0.75 {
+		t.Errorf("recall = %f, expected 0.75", recall)
+	}
+	if recall := Recall(truth, found, 2); recall != 0.5 {
+		t.Errorf("recall@2 = %f, expected 0.5", recall)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(100-i) * time.Millisecond
+	}
+	if p50 := Percentile(latencies, 50); p50 != 50*time.Millisecond {
+		t.Errorf("p50 = %s", p50)
+	}
+	if p99 := Percentile(latencies, 99); p99 != 99*time.Millisecond {
+		t.Errorf("p99 = %s", p99)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "flat", want: "flat"},
+		{input: "hnsw:M=16,efSearch=64", want: "hnsw:M=16,efSearch=64"},
+		{input: "milvus:ivf_flat:nlist=128,nprobe=16", want: "milvus:IVF_FLAT:nlist=128,nprobe=16"},
+		{input: "milvus:HNSW:M=abc", wantErr: true},
+		{input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		spec, err := ParseSpec(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSpec(%q) error = %v", tt.input, err)
+			continue
+		}
+		if err == nil && spec.String() != tt.want {
+			t.Errorf("ParseSpec(%q) = %s, expected %s", tt.input, spec, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package benchmark
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"milvus/errors"
+)
+
+// WriteReport writes results as "csv", "json" or "md" (Markdown)
+func WriteReport(w io.Writer, results []Result, format string) error {
+	switch strings.ToLower(format) {
+	case "csv":
+		return WriteCSV(w, results)
+	case "json":
+		return WriteJSON(w, results)
+	case "md", "markdown":
+		return WriteMarkdown(w, results)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// SaveReport writes results to path, picking the format from the extension
+func SaveReport(path string, results []Result) error {
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer output.Close()
+	if err := WriteReport(output, results, format); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return output.Close()
+}
+
+var columns = []string{"store", "k", "queries", "recall", "qps", "p50_ms", "p99_ms", "build_s", "error"}
+
+func row(r Result) []string {
+	return []string{
+		r.Store,
+		fmt.Sprint(r.K),
+		fmt.Sprint(r.Queries),
+		fmt.Sprintf("%.4f", r.Recall),
+		fmt.Sprintf("%.1f", r.QPS),
+		fmt.Sprintf("%.3f", milliseconds(r.P50)),
+		fmt.Sprintf("%.3f", milliseconds(r.P99)),
+		fmt.Sprintf("%.2f", r.BuildTime.Seconds()),
+		r.Error,
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+	for _, r := range results {
+		writer.Write(row(r))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func WriteJSON(w io.Writer, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func WriteMarkdown(w io.Writer, results []Result) error {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(columns, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(columns)))
+	for _, r := range results {
+		cells := row(r)
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
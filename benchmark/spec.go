@@ -0,0 +1,94 @@
+package benchmark
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Spec describes one store to benchmark, written on the command line as
+//
+//	backend[:INDEX_TYPE][:key=value,key=value]
+//
+// e.g. "flat", "hnsw:M=16,efConstruction=200,efSearch=64", "milvus:IVF_FLAT:nlist=128,nprobe=16"
+type Spec struct {
+	Backend   string
+	IndexType string
+	Params    map[string]int
+}
+
+func ParseSpec(s string) (Spec, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	spec := Spec{Backend: strings.ToLower(parts[0]), Params: map[string]int{}}
+	if spec.Backend == "" {
+		return spec, fmt.Errorf("empty benchmark spec")
+	}
+	if len(parts) > 3 {
+		return spec, fmt.Errorf("invalid benchmark spec %q", s)
+	}
+
+	for _, part := range parts[1:] {
+		if !strings.Contains(part, "=") {
+			spec.IndexType = strings.ToUpper(part)
+			continue
+		}
+		for _, kv := range strings.Split(part, ",") {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok {
+				return spec, fmt.Errorf("invalid parameter %q in spec %q", kv, s)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return spec, fmt.Errorf("parameter %q in spec %q must be an integer", key, s)
+			}
+			spec.Params[strings.TrimSpace(key)] = n
+		}
+	}
+	return spec, nil
+}
+
+// ParseSpecs parses a ';' separated list of specs
+func ParseSpecs(s string) ([]Spec, error) {
+	var specs []Spec
+	for _, part := range strings.Split(s, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		spec, err := ParseSpec(part)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Param returns the named parameter or fallback if it wasn't given
+func (s Spec) Param(name string, fallback int) int {
+	if v, ok := s.Params[name]; ok {
+		return v
+	}
+	return fallback
+}
+
+func (s Spec) String() string {
+	keys := make([]string, 0, len(s.Params))
+	for k := range s.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = fmt.Sprintf("%s=%d", k, s.Params[k])
+	}
+
+	parts := []string{s.Backend}
+	if s.IndexType != "" {
+		parts = append(parts, s.IndexType)
+	}
+	if len(params) > 0 {
+		parts = append(parts, strings.Join(params, ","))
+	}
+	return strings.Join(parts, ":")
+}
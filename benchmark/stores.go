@@ -0,0 +1,82 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"milvus/hnsw"
+)
+
+// BruteForce is an exact in-memory store, useful as a latency baseline
+type BruteForce struct {
+	Metric  Metric
+	vectors [][]float32
+}
+
+func (bf *BruteForce) Name() string {
+	return fmt.Sprintf("flat(metric=%s)", bf.Metric)
+}
+
+func (bf *BruteForce) Build(vectors [][]float32, ctx context.Context) error {
+	bf.vectors = vectors
+	return nil
+}
+
+func (bf *BruteForce) Search(query []float32, k int, ctx context.Context) ([]int64, error) {
+	return exactSearch(bf.vectors, query, k, bf.Metric), nil
+}
+
+func (bf *BruteForce) Close(ctx context.Context) error {
+	bf.vectors = nil
+	return nil
+}
+
+// HNSW benchmarks the local hnsw package
+type HNSW struct {
+	Config hnsw.Config
+	index  *hnsw.Index
+}
+
+func (h *HNSW) Name() string {
+	return fmt.Sprintf("hnsw(M=%d,efConstruction=%d,efSearch=%d,metric=%s)", h.Config.M, h.Config.EfConstruction, h.Config.EfSearch, h.Config.Metric)
+}
+
+func (h *HNSW) Build(vectors [][]float32, ctx context.Context) error {
+	if len(vectors) == 0 {
+		return fmt.Errorf("no vectors to index")
+	}
+	index, err := hnsw.New(len(vectors[0]), h.Config)
+	if err != nil {
+		return err
+	}
+	for i, v := range vectors {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := index.Add(strconv.Itoa(i), v); err != nil {
+			return err
+		}
+	}
+	h.index = index
+	return nil
+}
+
+func (h *HNSW) Search(query []float32, k int, ctx context.Context) ([]int64, error) {
+	results, err := h.index.Search(query, k)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(results))
+	for i, r := range results {
+		ids[i] = int64(r.ID)
+	}
+	return ids, nil
+}
+
+func (h *HNSW) Close(ctx context.Context) error {
+	h.index = nil
+	return nil
+}
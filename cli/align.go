@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+
+	"milvus/align"
+	"milvus/embeddings"
+)
+
+func runAlign(a *app, args []string) error {
+	fs := a.flags("align")
+	reference := fs.String("reference", "", "vector file whose space the new vectors are mapped into")
+	referenceModel := fs.String("reference-model", "", "registered reference model instead of -reference, e.g. words@production")
+	vectors := fs.String("vectors", "", "new vector file to align")
+	model := fs.String("model", "", "registered new model instead of -vectors, e.g. words@3")
+	output := fs.String("output", "", "write the aligned vectors to this file")
+	format := fs.String("format", "", "output format (default: the format of the new vectors, see export)")
+	normalize := fs.Bool("normalize", true, "fit the rotation on unit length vectors")
+	anchors := fs.Int("anchors", 0, "fit on the first n shared words of the new file (0 = all)")
+	top := fs.Int("top", 20, "number of most drifted words to list")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if (*reference == "") == (*referenceModel == "") {
+		return usagef("give one of -reference or -reference-model")
+	}
+	if (*vectors == "") == (*model == "") {
+		return usagef("give one of -vectors or -model")
+	}
+
+	referencePath, err := a.vectorsPath(*reference, *referenceModel)
+	if err != nil {
+		return err
+	}
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	referenceEmbs, err := embeddings.Load(referencePath)
+	if err != nil {
+		return err
+	}
+	embs, err := embeddings.Load(path)
+	if err != nil {
+		return err
+	}
+
+	alignment, err := align.Procrustes(referenceEmbs, embs, align.Options{Normalize: *normalize, Anchors: *anchors, Top: *top})
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		outputFormat, err := embeddings.DetectFormat(path)
+		if err != nil {
+			return err
+		}
+		if *format != "" {
+			if outputFormat, err = embeddings.ParseFormat(*format); err != nil {
+				return usagef("%s", err)
+			}
+		}
+		aligned, err := alignment.Apply(embs)
+		if err != nil {
+			return err
+		}
+		if err := embeddings.Save(*output, aligned, outputFormat); err != nil {
+			return err
+		}
+		a.logger.Info("saved aligned vectors", "output", *output, "vectors", len(aligned), "format", outputFormat.String())
+	}
+
+	if a.output == "json" {
+		return a.print(alignment, nil, nil)
+	}
+	fmt.Fprintf(a.stdout, "shared words %d  anchors %d  rms error %.4f -> %.4f  mean similarity %.4f\n",
+		alignment.Shared, alignment.Anchors, alignment.ErrorBefore, alignment.ErrorAfter, alignment.MeanSimilarity)
+	rows := make([][]string, len(alignment.Drifted))
+	for i, d := range alignment.Drifted {
+		rows[i] = []string{fmt.Sprint(i + 1), d.Word, fmt.Sprintf("%.4f", d.Similarity)}
+	}
+	return a.print(nil, []string{"rank", "drifted word", "similarity"}, rows)
+}
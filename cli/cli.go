@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"context"
+	stdErrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"milvus/logging"
+	"milvus/tools"
+	"milvus/tracing"
+	"milvus/vectordb"
+	"milvus/vectorize"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // the command ran and failed
+	ExitUsage      = 2 // bad flags or arguments
+	ExitConnection = 3 // Milvus could not be reached
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"train":       {usage: "train [-input path] [-output path | -register name] [-checkpoint path [-resume]] [-from model] [-progress=false]", summary: "train word vectors on a UTF-8 corpus", run: runTrain},
+	"query":       {usage: "query [-vectors path | -model name@version | -index path | -quantized path] [-k n] <word>  |  query -collection name -expr expr [-fields f1,f2]", summary: "similar words from a vector file, or a Milvus filter query", run: runQuery},
+	"evaluate":    {usage: "evaluate -data path [-specs specs] [-k n] [-out files]", summary: "benchmark recall, QPS and latency of index types", run: runEvaluate},
+	"collections": {usage: "collections create|list|describe ... | collections drop [-dry-run] [-force] (-all | name...)", summary: "manage Milvus collections", run: runCollections},
+	"insert":      {usage: "insert -collection name [-file rows.jsonl]", summary: "insert JSON rows (one object per line) into a collection", run: runInsert},
+	"import":      {usage: "import -collection name (-file vectors | -model name@version) [-create [-dynamic]]", summary: "bulk load a vector file into a collection", run: runImport},
+	"export":      {usage: "export -collection name -file vectors [-format name]", summary: "write a collection's words and vectors to a vector file", run: runExport},
+	"index":       {usage: "index create|drop -collection name -field name ...", summary: "manage vector indexes", run: runIndex},
+	"load":        {usage: "load <collection>", summary: "load a collection into memory for search", run: runLoad},
+	"release":     {usage: "release <collection>", summary: "release a collection from memory", run: runRelease},
+	"search":      {usage: "search -collection name (-vector v1,v2,... | -word w (-vectors path | -model name@version)) [-topk n] [-expr expr]", summary: "vector similarity search", run: runSearch},
+	"delete":      {usage: "delete -collection name -expr expr [-dry-run] [-force]", summary: "delete the entities matching a filter expression", run: runDelete},
+	"serve":       {usage: "serve [-listen addr] [-grpc addr] [-vectors path | -model name@version] [-milvus=false] [-request-timeout d]", summary: "run the REST (and gRPC) API server", run: runServe},
+	"models":      {usage: "models list|show|compare|promote|delete|score|register ...", summary: "manage the local model registry", run: runModels},
+	"stats":       {usage: "stats [-input path] [-vectors path | -model name@version] [-top n] [-min-counts 1,5,10]", summary: "corpus statistics: tokens, vocabulary, Zipf curve, OOV rate", run: runStats},
+	"align":       {usage: "align (-reference path | -reference-model name@version) (-vectors path | -model name@version) [-output file] [-anchors n] [-top n]", summary: "rotate a retrained model into the space of a reference model and list drifted words", run: runAlign},
+	"cluster":     {usage: "cluster [-vectors path | -model name@version | -collection name] -k n [-metric L2|COSINE] [-batch n] [-assignments file] [-centroids file] [-write-field name]", summary: "k-means clustering of word vectors", run: runCluster},
+	"dedupe":      {usage: "dedupe [-vectors path | -model name@version | -collection name] -threshold t [-metric L2|IP|COSINE] [-topk n] [-batch n] [-groups file] [-output file | -delete [-force]]", summary: "find near-duplicate vectors with range search and remove all but one of each group", run: runDedupe},
+	"docs":        {usage: "docs ingest -collection name [-vectors path | -model name@version] [-chunk sentence|paragraph|token] [-size n] [-overlap n] [-dry-run] path...  |  docs search -collection name [-topk n] [-context bytes] <query>", summary: "chunk, embed and store documents, then search them semantically", run: runDocs},
+	"project":     {usage: "project [-vectors path | -model name@version] [-words w1,w2 | -limit n] [-method pca|tsne] [-dims n] [-projector dir] [-svg file] [-html file]", summary: "reduce vectors to 2D/3D, plot them or export them for the Embedding Projector", run: runProject},
+	"quantize":    {usage: "quantize [-vectors path | -model name@version] [-method sq8|pq] [-metric L2|IP|COSINE] [-subspaces m] [-bits b] [-output file] [-report=false]", summary: "compress vectors with scalar or product quantization and report the error and recall", run: runQuantize},
+	"repl":        {usage: "repl [-vectors path | -model name@version] [-collection name] [-history file]", summary: "interactive shell for exploring vectors and collections", run: runRepl},
+	"demo":        {usage: "demo [-force]", summary: "run the original end to end walkthrough", run: runDemo},
+}
+
+// usageError marks errors caused by how the command was called
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.err
+}
+
+type app struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	addr    string
+	output  string
+	timeout time.Duration
+	ctx     context.Context
+	logger  *slog.Logger
+
+	registryDir  string
+	milvusClient client.Client
+}
+
+// Run executes the command line args (without the program name) and returns the process exit code.
+// Destructive commands read their confirmation from stdin.
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	global := flag.NewFlagSet("vectorize", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.StringVar(&a.addr, "addr", envOr("MILVUS_ADDR", "localhost:19530"), "Milvus address")
+	global.StringVar(&a.output, "output", "table", "output format: table or json")
+	global.DurationVar(&a.timeout, "timeout", 0, "overall timeout for the command, e.g. 30s (0 = none)")
+	global.StringVar(&a.registryDir, "registry", envOr("VECTORIZE_REGISTRY", "models"), "model registry directory")
+	logLevel := global.String("log-level", envOr("VECTORIZE_LOG_LEVEL", "info"), "log level: debug, info, warn or error")
+	logFormat := global.String("log-format", envOr("VECTORIZE_LOG_FORMAT", "text"), "log format on stderr: text or json")
+	perf := global.String("perf", "", "serve pprof on this address, e.g. localhost:6060")
+	otlp := global.String("otlp", os.Getenv("VECTORIZE_OTLP_ENDPOINT"), "send traces to this OTLP gRPC collector, e.g. localhost:4317")
+	otlpInsecure := global.Bool("otlp-insecure", true, "connect to the OTLP collector without TLS")
+	protect := global.String("protect", os.Getenv("MILVUS_PROTECTED_COLLECTIONS"), "comma separated collections (or patterns like prod_*) that can never be dropped or deleted from")
+	global.Usage = func() { a.printUsage() }
+
+	if err := global.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if a.output != "table" && a.output != "json" {
+		fmt.Fprintf(stderr, "invalid -output %q, expected table or json\n", a.output)
+		return ExitUsage
+	}
+	logger, err := logging.New(stderr, *logFormat, *logLevel)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	a.logger = logger
+	vectordb.SetLogger(logger)
+	vectorize.SetLogger(logger)
+	tools.SetLogger(logger)
+	if err := vectordb.Protect(splitList(*protect)...); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	if *perf != "" {
+		tools.StartPerformanceServer(*perf)
+	}
+
+	rest := global.Args()
+	if len(rest) == 0 {
+		a.printUsage()
+		return ExitUsage
+	}
+	cmd, ok := commands[rest[0]]
+	if !ok {
+		if rest[0] == "help" {
+			a.printUsage()
+			return ExitOK
+		}
+		fmt.Fprintf(stderr, "unknown command %q\n\n", rest[0])
+		a.printUsage()
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	if *otlp != "" {
+		shutdown, err := tracing.Setup(tracing.Config{Endpoint: *otlp, Insecure: *otlpInsecure}, ctx)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		defer func() {
+			// flush what's buffered even if ctx timed out
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdown(flushCtx)
+		}()
+	}
+	ctx, span := tracing.Start(ctx, "vectorize "+rest[0])
+	defer span.End()
+	a.ctx = ctx
+	defer a.close()
+
+	err = cmd.run(a, rest[1:])
+	if err == nil {
+		return ExitOK
+	}
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+
+	var usageErr *usageError
+	var connErr *connectionError
+	switch {
+	case stdErrors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%s\nusage: vectorize %s\n", err, cmd.usage)
+		return ExitUsage
+	case stdErrors.As(err, &connErr):
+		fmt.Fprintf(stderr, "failed to connect to Milvus at %s: %s\nMake sure to run docker-compose up\n", a.addr, err)
+		return ExitConnection
+	}
+	fmt.Fprintf(stderr, "error: %s\n", err)
+	return ExitFailure
+}
+
+func (a *app) printUsage() {
+	fmt.Fprintln(a.stderr, "usage: vectorize [-addr host:port] [-output table|json] [-timeout d] [-registry dir] [-protect names] [-perf addr] <command> [flags]")
+	fmt.Fprintln(a.stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(a.stderr, "\nRun 'vectorize <command> -h' for the flags of a command.")
+}
+
+// client connects to Milvus on first use
+func (a *app) client() (client.Client, error) {
+	if a.milvusClient != nil {
+		return a.milvusClient, nil
+	}
+	ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer cancel()
+	milvusClient, err := tools.NewVectorDBClient(a.addr, ctx)
+	if err != nil {
+		return nil, &connectionError{err: err}
+	}
+	a.milvusClient = milvusClient
+	return milvusClient, nil
+}
+
+func (a *app) close() {
+	if a.milvusClient != nil {
+		a.milvusClient.Close()
+	}
+}
+
+// flags returns a flag set for a subcommand that reports parse errors as usage errors
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return &usageError{message: err.Error()}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envOr(name string, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
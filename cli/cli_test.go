@@ -0,0 +1,474 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"milvus/embeddings"
+	"milvus/vectorize"
+)
+
+const (
+	validModelPath = "../tests/mockdata/word_vector.txt"
+	emptyInputPath = "../tests/mockdata/empty"
+	unknownPath    = "imaginary/path/to/invalid/input.txt"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		exitCode int
+		stdout   string // substring expected on stdout
+		stderr   string // substring expected on stderr
+	}{
+		{
+			name:     "No command",
+			args:     []string{},
+			exitCode: ExitUsage,
+			stderr:   "commands:",
+		},
+		{
+			name:     "Help",
+			args:     []string{"help"},
+			exitCode: ExitOK,
+			stderr:   "collections",
+		},
+		{
+			name:     "Unknown command",
+			args:     []string{"vectorise"},
+			exitCode: ExitUsage,
+			stderr:   `unknown command "vectorise"`,
+		},
+		{
+			name:     "Invalid output format",
+			args:     []string{"-output", "xml", "query", "cat"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Unknown subcommand flag",
+			args:     []string{"query", "-nope", "cat"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Query without a word",
+			args:     []string{"query", "-vectors", validModelPath},
+			exitCode: ExitUsage,
+			stderr:   "usage: vectorize query",
+		},
+		{
+			name:     "Query",
+			args:     []string{"query", "-vectors", validModelPath, "-k", "3", "cat"},
+			exitCode: ExitOK,
+			stdout:   "SIMILARITY",
+		},
+		{
+			name:     "Query unknown vector file",
+			args:     []string{"query", "-vectors", unknownPath, "cat"},
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "Stats",
+			args:     []string{"stats", "-input", "../tests/mockdata/testdata", "-vectors", validModelPath, "-top", "3"},
+			exitCode: ExitOK,
+			stdout:   "MIN COUNT",
+		},
+		{
+			name:     "Stats invalid min count",
+			args:     []string{"stats", "-min-counts", "1,zero"},
+			exitCode: ExitUsage,
+			stderr:   "usage: vectorize stats",
+		},
+		{
+			name:     "Project",
+			args:     []string{"project", "-vectors", validModelPath, "-words", "cat,dog,tiger"},
+			exitCode: ExitOK,
+			stdout:   "tiger",
+		},
+		{
+			name:     "Project 3D plot",
+			args:     []string{"project", "-vectors", validModelPath, "-dims", "3", "-svg", "plot.svg"},
+			exitCode: ExitUsage,
+			stderr:   "usage: vectorize project",
+		},
+		{
+			name:     "Cluster",
+			args:     []string{"cluster", "-vectors", validModelPath, "-k", "3", "-metric", "cosine"},
+			exitCode: ExitOK,
+			stdout:   "silhouette",
+		},
+		{
+			name:     "Cluster write field without collection",
+			args:     []string{"cluster", "-write-field", "cluster"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Cluster too many clusters",
+			args:     []string{"cluster", "-vectors", validModelPath, "-k", "50"},
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "Quantize report",
+			args:     []string{"quantize", "-vectors", validModelPath, "-method", "pq", "-subspaces", "5", "-bits", "3"},
+			exitCode: ExitOK,
+			stdout:   "recall@10",
+		},
+		{
+			name:     "Quantize bad subspaces",
+			args:     []string{"quantize", "-vectors", validModelPath, "-method", "pq", "-subspaces", "3"},
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "Align a model with itself",
+			args:     []string{"align", "-reference", validModelPath, "-vectors", validModelPath, "-top", "3"},
+			exitCode: ExitOK,
+			stdout:   "shared words 20",
+		},
+		{
+			name:     "Align without reference",
+			args:     []string{"align", "-vectors", validModelPath},
+			exitCode: ExitUsage,
+			stderr:   "usage: vectorize align",
+		},
+		{
+			name:     "Dedupe everything into one group",
+			args:     []string{"dedupe", "-vectors", validModelPath, "-metric", "cosine", "-threshold", "-1"},
+			exitCode: ExitOK,
+			stdout:   "20 entities, 1 duplicate groups, 19 duplicates",
+		},
+		{
+			name:     "Dedupe without threshold",
+			args:     []string{"dedupe", "-vectors", validModelPath},
+			exitCode: ExitUsage,
+			stderr:   "-threshold is required",
+		},
+		{
+			name:     "Dedupe delete without collection",
+			args:     []string{"dedupe", "-vectors", validModelPath, "-threshold", "0.5", "-delete"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Docs without subcommand",
+			args:     []string{"docs"},
+			exitCode: ExitUsage,
+			stderr:   "expected ingest or search",
+		},
+		{
+			name:     "Docs ingest without files",
+			args:     []string{"docs", "ingest", "-collection", "docs"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Docs ingest missing file",
+			args:     []string{"docs", "ingest", "-dry-run", "-vectors", validModelPath, unknownPath},
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "Docs search without query",
+			args:     []string{"docs", "search", "-collection", "docs"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Train empty corpus",
+			args:     []string{"train", "-input", emptyInputPath, "-output", unknownPath},
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "Resume without checkpoint",
+			args:     []string{"train", "-resume"},
+			exitCode: ExitUsage,
+			stderr:   "-resume needs -checkpoint",
+		},
+		{
+			name:     "Collections without action",
+			args:     []string{"collections"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Index params must be integers",
+			args:     []string{"index", "create", "-collection", "words", "-params", "nlist=many"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Search needs a query vector",
+			args:     []string{"search", "-collection", "words"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Drop needs names or -all",
+			args:     []string{"collections", "drop", "-force"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Drop protected collection",
+			args:     []string{"-protect", "prod_*", "collections", "drop", "-force", "prod_words"},
+			exitCode: ExitFailure,
+			stderr:   "collection is protected: prod_words",
+		},
+		{
+			name:     "Delete protected collection",
+			args:     []string{"-protect", "words", "delete", "-collection", "words", "-expr", "word == 'cat'"},
+			exitCode: ExitFailure,
+			stderr:   "collection is protected: words",
+		},
+		{
+			name:     "Delete needs an expression",
+			args:     []string{"delete", "-collection", "scratch", "-force"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "Repl script",
+			args:     []string{"repl", "-vectors", validModelPath, "-history", ""},
+			stdin:    "# neighbours of cat\ncat\nk 2\nsim cat dog\nanalogy english hindi cat\nexit\nnever run\n",
+			exitCode: ExitOK,
+			stdout:   "SIMILARITY",
+		},
+		{
+			name:     "Repl stops at the first error",
+			args:     []string{"repl", "-history", ""},
+			stdin:    "k 3\nnn cat\n",
+			exitCode: ExitFailure,
+			stderr:   "line 2: no vectors loaded",
+		},
+		{
+			name:     "Repl unknown command",
+			args:     []string{"repl", "-vectors", validModelPath, "-history", ""},
+			stdin:    "neighbours cat\n",
+			exitCode: ExitFailure,
+			stderr:   `unknown command "neighbours"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("Run(%q) = %d, want %d\nstderr: %s", tt.args, code, tt.exitCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout %q does not contain %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr %q does not contain %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-output", "json", "query", "-vectors", validModelPath, "-k", "2", "cat"}, nil, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var neighbors []struct {
+		Word       string  `json:"word"`
+		Similarity float64 `json:"similarity"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &neighbors); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(neighbors) != 2 {
+		t.Fatalf("got %d neighbours, want 2", len(neighbors))
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		force   bool
+		stdin   string
+		wantErr bool
+	}{
+		{name: "Force skips the prompt", force: true},
+		{name: "Matching answer", stdin: "words\n"},
+		{name: "Wrong answer", stdin: "y\n", wantErr: true},
+		{name: "No input", stdin: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			a := &app{stdin: strings.NewReader(tt.stdin), stderr: &stderr}
+			err := a.confirm(tt.force, "This permanently drops words.", "words")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("confirm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.force && stderr.Len() > 0 {
+				t.Errorf("prompted despite force: %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	sh := &shell{a: &app{stdout: &bytes.Buffer{}}, k: 10, collectionNames: []string{"words", "words_v2"}}
+	if err := sh.load([]string{validModelPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "analo", want: []string{"gy "}},
+		{line: "nn ca", want: []string{"t "}},
+		{line: "use wo", want: []string{"rds ", "rds_v2 "}},
+		{line: "k ", want: nil},
+	}
+	for _, tt := range tests {
+		got, _ := sh.Do([]rune(tt.line), len(tt.line))
+		var completions []string
+		for _, c := range got {
+			completions = append(completions, string(c))
+		}
+		if strings.Join(completions, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Do(%q) = %q, want %q", tt.line, completions, tt.want)
+		}
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &progressBar{w: &buf}
+	bar.update(vectorize.Progress{Epoch: 2, Epochs: 4, Words: 150, TotalWords: 400, WordsPerSec: 12345, LearningRate: 0.0156, ETA: 1500 * time.Millisecond})
+	bar.update(vectorize.Progress{Epoch: 4, Epochs: 4, Words: 400, TotalWords: 400, WordsPerSec: 900, LearningRate: 0.0000025})
+	bar.finish()
+
+	lines := strings.Split(buf.String(), "\r")
+	if len(lines) != 3 {
+		t.Fatalf("expected two redraws, got %q", buf.String())
+	}
+	first := "epoch 2/4 [==========>                   ]  38%  12.3k words/s  lr 0.01560  ETA 2s"
+	if lines[1] != first {
+		t.Errorf("got  %q\nwant %q", lines[1], first)
+	}
+	if !strings.HasPrefix(lines[2], "epoch 4/4 [==============================] 100%  900 words/s") || !strings.HasSuffix(lines[2], "  \n") {
+		t.Errorf("last line %q doesn't overwrite the previous one", lines[2])
+	}
+}
+
+func TestModels(t *testing.T) {
+	dir := t.TempDir()
+	run := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := Run(append([]string{"-registry", dir}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	for i := 0; i < 2; i++ {
+		if code, _, stderr := run("", "models", "register", "-name", "animals", "-file", validModelPath); code != ExitOK {
+			t.Fatalf("register exited with %d: %s", code, stderr)
+		}
+	}
+	tests := []struct {
+		name     string
+		stdin    string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{name: "List", args: []string{"models", "list"}, exitCode: ExitOK, stdout: "animals  2"},
+		{name: "Promote", args: []string{"models", "promote", "animals@1"}, exitCode: ExitOK, stdout: "animals@production is now version 1"},
+		{name: "Show production", args: []string{"models", "show", "animals"}, exitCode: ExitOK, stdout: "animals@1"},
+		{name: "Score", args: []string{"models", "score", "animals@2", "analogy=0.42"}, exitCode: ExitOK, stdout: "analogy=0.42"},
+		{name: "Compare", args: []string{"models", "compare", "animals@1", "animals@2"}, exitCode: ExitOK, stdout: "scores.analogy"},
+		{name: "Query a model", args: []string{"query", "-model", "animals@2", "-k", "3", "cat"}, exitCode: ExitOK, stdout: "SIMILARITY"},
+		{name: "Query an unknown version", args: []string{"query", "-model", "animals@9", "cat"}, exitCode: ExitFailure},
+		{name: "Delete production", args: []string{"models", "delete", "animals@1"}, stdin: "animals@1\n", exitCode: ExitFailure},
+		{name: "Delete needs a version", args: []string{"models", "delete", "animals"}, exitCode: ExitUsage},
+		{name: "Delete", args: []string{"models", "delete", "animals@2"}, stdin: "animals@2\n", exitCode: ExitOK, stdout: "deleted animals@2"},
+		{name: "Bad score", args: []string{"models", "score", "animals", "analogy"}, exitCode: ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(tt.stdin, tt.args...)
+			if code != tt.exitCode {
+				t.Errorf("exit code = %d, want %d\nstderr: %s", code, tt.exitCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout %q does not contain %q", stdout, tt.stdout)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	dir := t.TempDir()
+	svg, page := filepath.Join(dir, "animals.svg"), filepath.Join(dir, "animals.html")
+	var stdout, stderr bytes.Buffer
+	args := []string{"project", "-vectors", validModelPath, "-method", "tsne", "-iterations", "100",
+		"-projector", filepath.Join(dir, "projector"), "-svg", svg, "-html", page}
+	if code := Run(args, strings.NewReader(""), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("project exited with %d: %s", code, stderr.String())
+	}
+	for _, file := range []string{filepath.Join(dir, "projector", "metadata.tsv"), svg, page} {
+		if content, err := os.ReadFile(file); err != nil || !strings.Contains(string(content), "lion") {
+			t.Errorf("%s does not list lion: %v", file, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "plotted 20 words") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	dir := t.TempDir()
+	groups, output := filepath.Join(dir, "groups.tsv"), filepath.Join(dir, "deduped.txt")
+	var stdout, stderr bytes.Buffer
+	args := []string{"dedupe", "-vectors", validModelPath, "-metric", "COSINE", "-threshold", "-1", "-groups", groups, "-output", output}
+	if code := Run(args, strings.NewReader(""), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("dedupe exited with %d: %s", code, stderr.String())
+	}
+	// everything is a duplicate of the first word
+	content, err := os.ReadFile(groups)
+	if err != nil || strings.Count(string(content), "\tenglish\n") != 19 {
+		t.Errorf("unexpected groups %q: %v", content, err)
+	}
+	embs, err := embeddings.Load(output)
+	if err != nil || len(embs) != 1 || embs[0].Word != "english" {
+		t.Errorf("deduplicated vectors %v: %v", embs, err)
+	}
+}
+
+func TestDocsIngestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	text := "The cat sat on the mat. A dog barked!\n\nNothing here.\n\nThe lion and the tiger."
+	if err := os.WriteFile(filepath.Join(dir, "animals.txt"), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "skipped.csv"), []byte("cat,dog"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	args := []string{"-output", "json", "docs", "ingest", "-dry-run", "-vectors", validModelPath, "-chunk", "sentence", "-size", "1", dir}
+	if code := Run(args, strings.NewReader(""), &stdout, &stderr); code != ExitOK {
+		t.Fatalf("docs ingest exited with %d: %s", code, stderr.String())
+	}
+	var chunks []struct {
+		Source string `json:"source"`
+		Index  int    `json:"chunk"`
+		Start  int    `json:"start"`
+		Text   string `json:"text"`
+		Terms  int    `json:"terms"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &chunks); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout.String(), err)
+	}
+	// "Nothing here." has no known words and is skipped
+	if len(chunks) != 3 || chunks[2].Index != 3 || chunks[2].Text != "The lion and the tiger." || chunks[2].Terms != 2 {
+		t.Fatalf("unexpected chunks %+v", chunks)
+	}
+	if chunks[1].Start != strings.Index(text, "A dog") || filepath.Base(chunks[1].Source) != "animals.txt" || !filepath.IsAbs(chunks[1].Source) {
+		t.Errorf("unexpected chunk %+v", chunks[1])
+	}
+	if !strings.Contains(stderr.String(), "skipped chunks without known words") {
+		t.Errorf("missing warning: %s", stderr.String())
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"milvus/cluster"
+	"milvus/embeddings"
+	"milvus/vectordb"
+)
+
+func runCluster(a *app, args []string) error {
+	fs := a.flags("cluster")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file to cluster")
+	model := fs.String("model", "", "cluster a registered model instead of -vectors, e.g. words@2")
+	collection := fs.String("collection", "", "cluster the words of this (loaded) Milvus collection instead")
+	k := fs.Int("k", 10, "number of clusters")
+	metric := fs.String("metric", "L2", "L2 or COSINE")
+	batch := fs.Int("batch", 0, "mini-batch size (0 = full batch k-means)")
+	iterations := fs.Int("iterations", 100, "maximum iterations (or mini-batches)")
+	seed := fs.Int64("seed", 1, "random seed")
+	sample := fs.Int("silhouette-sample", 1000, "vectors to compute the silhouette on (0 = all, -1 = skip)")
+	show := fs.Int("show", 5, "words closest to each centroid to list")
+	assignments := fs.String("assignments", "", "write word<TAB>cluster lines to this file")
+	centroids := fs.String("centroids", "", "write the centroids to this vector file, as words cluster_0, cluster_1, ...")
+	field := fs.String("write-field", "", "with -collection, store each word's cluster id in this Int64 or dynamic field")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *field != "" && *collection == "" {
+		return usagef("-write-field needs -collection")
+	}
+
+	var words []string
+	var data [][]float32
+	source := *collection
+	var wordField, vectorField string
+	if *collection != "" {
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		if wordField, vectorField, err = wordFields(a, *collection); err != nil {
+			return err
+		}
+		if words, data, err = vectordb.ExportWords(milvusClient, *collection, wordField, vectorField, 0, a.ctx); err != nil {
+			return err
+		}
+	} else {
+		path, err := a.vectorsPath(*vectors, *model)
+		if err != nil {
+			return err
+		}
+		embs, err := embeddings.Load(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range embs {
+			words = append(words, e.Word)
+			data = append(data, e.Vector)
+		}
+		source = path
+	}
+
+	result, err := cluster.KMeans(data, cluster.Options{
+		K:             *k,
+		Metric:        cluster.Metric(strings.ToUpper(*metric)),
+		MaxIterations: *iterations,
+		BatchSize:     *batch,
+		Seed:          *seed,
+	}, a.ctx)
+	if err != nil {
+		return err
+	}
+	silhouette := 0.0
+	if *sample >= 0 {
+		if silhouette, err = cluster.Silhouette(data, result, *sample, *seed, a.ctx); err != nil {
+			return err
+		}
+	}
+	a.logger.Info("clustered", "source", source, "k", result.K, "iterations", result.Iterations, "converged", result.Converged)
+
+	if *assignments != "" {
+		err := writeOutput(*assignments, func(w io.Writer) error {
+			writer := bufio.NewWriter(w)
+			for i, word := range words {
+				fmt.Fprintf(writer, "%s\t%d\n", word, result.Assignments[i])
+			}
+			return writer.Flush()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if *centroids != "" {
+		embs := make([]embeddings.Embedding, result.K)
+		for c, centroid := range result.Centroids {
+			embs[c] = embeddings.Embedding{Word: fmt.Sprintf("cluster_%d", c), Vector: centroid}
+		}
+		if err := embeddings.Save(*centroids, embs, embeddings.FormatWego); err != nil {
+			return err
+		}
+	}
+	if *field != "" {
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, len(result.Assignments))
+		for i, c := range result.Assignments {
+			ids[i] = int64(c)
+		}
+		if err := vectordb.UpsertWordField(milvusClient, *collection, wordField, vectorField, *field, words, data, ids, 0, a.ctx); err != nil {
+			return err
+		}
+		a.logger.Info("stored cluster ids", "collection", *collection, "field", *field, "rows", len(ids))
+	}
+
+	type clusterInfo struct {
+		ID    int      `json:"id"`
+		Size  int      `json:"size"`
+		Words []string `json:"words"`
+	}
+	summary := struct {
+		Source     string         `json:"source"`
+		K          int            `json:"k"`
+		Metric     cluster.Metric `json:"metric"`
+		Inertia    float64        `json:"inertia"`
+		Silhouette *float64       `json:"silhouette,omitempty"`
+		Iterations int            `json:"iterations"`
+		Converged  bool           `json:"converged"`
+		Clusters   []clusterInfo  `json:"clusters"`
+	}{Source: source, K: result.K, Metric: result.Metric, Inertia: result.Inertia, Iterations: result.Iterations, Converged: result.Converged}
+	if *sample >= 0 {
+		summary.Silhouette = &silhouette
+	}
+	rows := make([][]string, result.K)
+	for c, members := range result.Representatives(data, *show) {
+		info := clusterInfo{ID: c, Size: result.Sizes[c], Words: []string{}}
+		for _, i := range members {
+			info.Words = append(info.Words, words[i])
+		}
+		summary.Clusters = append(summary.Clusters, info)
+		rows[c] = []string{strconv.Itoa(c), strconv.Itoa(info.Size), strings.Join(info.Words, " ")}
+	}
+	if a.output == "json" {
+		return a.print(summary, nil, nil)
+	}
+	totals := fmt.Sprintf("k %d  inertia %.4f  iterations %d  converged %t", result.K, result.Inertia, result.Iterations, result.Converged)
+	if summary.Silhouette != nil {
+		totals += fmt.Sprintf("  silhouette %.4f", silhouette)
+	}
+	fmt.Fprintln(a.stdout, totals)
+	return a.print(nil, []string{"cluster", "size", "closest words"}, rows)
+}
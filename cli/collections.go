@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+func runCollections(a *app, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected create, list, describe or drop")
+	}
+	switch args[0] {
+	case "create":
+		return collectionsCreate(a, args[1:])
+	case "list":
+		return collectionsList(a, args[1:])
+	case "describe":
+		return collectionsDescribe(a, args[1:])
+	case "drop":
+		return collectionsDrop(a, args[1:])
+	}
+	return usagef("unknown collections command %q", args[0])
+}
+
+func collectionsCreate(a *app, args []string) error {
+	fs := a.flags("collections create")
+	name := fs.String("name", "", "collection name")
+	description := fs.String("description", "", "collection description")
+	dim := fs.Int("dim", 0, "dimension of the vector field")
+	idField := fs.String("id-field", "word", "primary key field")
+	idType := fs.String("id-type", "varchar", "primary key type: varchar or int64")
+	maxLength := fs.Int("max-length", 100, "max length of a varchar primary key")
+	autoID := fs.Bool("auto-id", false, "let Milvus generate int64 primary keys")
+	vectorField := fs.String("vector-field", "embedding", "float vector field")
+	shards := fs.Int("shards", 2, "number of shards")
+	dynamic := fs.Bool("dynamic", false, "enable the dynamic field for extra scalar values")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *name == "" || *dim <= 0 {
+		return usagef("-name and a positive -dim are required")
+	}
+
+	var pk *entity.Field
+	switch strings.ToLower(*idType) {
+	case "varchar":
+		pk = vectordb.NewFieldVarChar(*idField, *maxLength, true, false)
+	case "int64":
+		pk = vectordb.NewFieldInt64(*idField, true, *autoID)
+	default:
+		return usagef("invalid -id-type %q", *idType)
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	err = vectordb.NewCollectionBuilder().
+		WithName(*name).
+		WithDescription(*description).
+		WithShardNum(int32(*shards)).
+		WithDynamicField(*dynamic).
+		WithFields(pk, vectordb.NewFieldFloatVector(*vectorField, *dim)).
+		Create(milvusClient, a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.message("created collection %s", *name)
+}
+
+func collectionsList(a *app, args []string) error {
+	fs := a.flags("collections list")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	collections, err := vectordb.ListCollections(milvusClient, a.ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+
+	type listed struct {
+		Name   string `json:"name"`
+		ID     int64  `json:"id"`
+		Loaded bool   `json:"loaded"`
+	}
+	values := make([]listed, len(collections))
+	rows := make([][]string, len(collections))
+	for i, c := range collections {
+		values[i] = listed{Name: c.Name, ID: c.ID, Loaded: c.Loaded}
+		rows[i] = []string{c.Name, strconv.FormatInt(c.ID, 10), strconv.FormatBool(c.Loaded)}
+	}
+	return a.print(values, []string{"name", "id", "loaded"}, rows)
+}
+
+func collectionsDescribe(a *app, args []string) error {
+	fs := a.flags("collections describe")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected a collection name")
+	}
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	info, err := vectordb.DescribeCollection(milvusClient, fs.Arg(0), a.ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(info.Fields))
+	for _, f := range info.Fields {
+		params := make([]string, 0, len(f.TypeParams))
+		for k, v := range f.TypeParams {
+			params = append(params, k+"="+v)
+		}
+		sort.Strings(params)
+		var flags []string
+		if f.PrimaryKey {
+			flags = append(flags, "primary")
+		}
+		if f.AutoID {
+			flags = append(flags, "auto_id")
+		}
+		if idx, ok := info.Indexes[f.Name]; ok {
+			flags = append(flags, "index="+idx)
+		}
+		rows = append(rows, []string{f.Name, f.DataType, strings.Join(params, ","), strings.Join(flags, ",")})
+	}
+	if a.output == "table" {
+		fmt.Fprintf(a.stdout, "%s (%s) rows=%s loaded=%t shards=%d\n\n", info.Name, info.Description, info.RowCount, info.Loaded, info.ShardNum)
+	}
+	return a.print(info, []string{"field", "type", "params", "flags"}, rows)
+}
+
+func runIndex(a *app, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected create or drop")
+	}
+	fs := a.flags("index " + args[0])
+	collection := fs.String("collection", "", "collection name")
+	field := fs.String("field", "embedding", "vector field to index")
+
+	switch args[0] {
+	case "create":
+		indexType := fs.String("type", "IVF_FLAT", "FLAT, IVF_FLAT, IVF_SQ8, IVF_PQ, HNSW or AUTOINDEX")
+		metric := fs.String("metric", "L2", "L2, IP or COSINE")
+		params := fs.String("params", "", "index parameters, e.g. nlist=1024 or M=16,efConstruction=200")
+		if err := parse(fs, args[1:]); err != nil {
+			return err
+		}
+		if *collection == "" {
+			return usagef("-collection is required")
+		}
+		parsed, err := parseParams(*params)
+		if err != nil {
+			return err
+		}
+		idx, _, err := vectordb.NewIndex(*indexType, entity.MetricType(strings.ToUpper(*metric)), parsed)
+		if err != nil {
+			return usagef("%s", err)
+		}
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		if err := vectordb.CreateIndexWith(milvusClient, *collection, *field, idx, a.ctx); err != nil {
+			return err
+		}
+		return a.message("created %s index on %s.%s", strings.ToUpper(*indexType), *collection, *field)
+
+	case "drop":
+		if err := parse(fs, args[1:]); err != nil {
+			return err
+		}
+		if *collection == "" {
+			return usagef("-collection is required")
+		}
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		if err := vectordb.DropIndex(milvusClient, *collection, *field, a.ctx); err != nil {
+			return err
+		}
+		return a.message("dropped index on %s.%s", *collection, *field)
+	}
+	return usagef("unknown index command %q", args[0])
+}
+
+func runLoad(a *app, args []string) error {
+	return collectionCommand(a, "load", args, vectordb.LoadCollection, "loaded")
+}
+
+func runRelease(a *app, args []string) error {
+	return collectionCommand(a, "release", args, vectordb.ReleaseCollection, "released")
+}
+
+func collectionCommand(a *app, name string, args []string, do func(client.Client, string, context.Context) error, done string) error {
+	fs := a.flags(name)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected a collection name")
+	}
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err := do(milvusClient, fs.Arg(0), a.ctx); err != nil {
+		return err
+	}
+	return a.message("%s collection %s", done, fs.Arg(0))
+}
+
+// parseParams parses "key=value,key=value" integer parameters
+func parseParams(s string) (map[string]int, error) {
+	params := map[string]int{}
+	for _, kv := range splitList(s) {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, usagef("invalid parameter %q, expected key=value", kv)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, usagef("parameter %s must be an integer", key)
+		}
+		params[key] = n
+	}
+	return params, nil
+}
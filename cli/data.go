@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"milvus/embeddings"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+func runInsert(a *app, args []string) error {
+	fs := a.flags("insert")
+	collection := fs.String("collection", "", "collection name")
+	file := fs.String("file", "-", "JSON lines file with one row object per line, - for stdin")
+	batch := fs.Int("batch", 1000, "rows per insert call")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" {
+		return usagef("-collection is required")
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	info, err := vectordb.DescribeCollection(milvusClient, *collection, a.ctx)
+	if err != nil {
+		return err
+	}
+
+	inserted := 0
+	flush := func(rows []map[string]interface{}) error {
+		columns, err := vectordb.ColumnsFromRows(info, rows)
+		if err != nil {
+			return fmt.Errorf("rows %d-%d: %w", inserted+1, inserted+len(rows), err)
+		}
+		if _, err := milvusClient.Insert(a.ctx, *collection, "", columns...); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", *collection, err)
+		}
+		inserted += len(rows)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var rows []map[string]interface{}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var row map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber() // keep integers exact for Int64 fields
+		err := decoder.Decode(&row)
+		if err == nil && decoder.More() {
+			err = fmt.Errorf("unexpected data after the object")
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+		if len(rows) == *batch {
+			if err := flush(rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		if err := flush(rows); err != nil {
+			return err
+		}
+	}
+	return a.message("inserted %d rows into %s", inserted, *collection)
+}
+
+func runImport(a *app, args []string) error {
+	fs := a.flags("import")
+	collection := fs.String("collection", "", "collection name")
+	file := fs.String("file", "", "vector file in any supported format")
+	model := fs.String("model", "", "import a registered model instead of -file, e.g. words@production")
+	format := fs.String("format", "", "vector file format, detected when empty")
+	create := fs.Bool("create", false, "create the collection (word varchar key + embedding vector) if it doesn't exist")
+	dynamic := fs.Bool("dynamic", false, "with -create, enable the dynamic field, e.g. for cluster -write-field")
+	batch := fs.Int("batch", 10000, "rows per insert call")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" || (*file == "") == (*model == "") {
+		return usagef("-collection and one of -file or -model are required")
+	}
+	path, err := a.vectorsPath(*file, *model)
+	if err != nil {
+		return err
+	}
+	if *model != "" {
+		*file = *model
+	}
+
+	embs, err := loadEmbeddings(path, *format)
+	if err != nil {
+		return err
+	}
+	dim, err := embeddings.Dim(embs)
+	if err != nil {
+		return err
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	if *create {
+		err = vectordb.NewCollectionBuilder().
+			WithName(*collection).
+			WithDescription(fmt.Sprintf("word vectors imported from %s", *file)).
+			WithDynamicField(*dynamic).
+			WithFields(
+				vectordb.NewFieldVarChar("word", 512, true, false),
+				vectordb.NewFieldFloatVector("embedding", dim),
+			).
+			Create(milvusClient, a.ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	wordField, vectorField, err := wordFields(a, *collection)
+	if err != nil {
+		return err
+	}
+	words := make([]string, len(embs))
+	vectors := make([][]float32, len(embs))
+	for i, e := range embs {
+		words[i], vectors[i] = e.Word, e.Vector
+	}
+	if err := vectordb.InsertWords(milvusClient, *collection, wordField, vectorField, words, vectors, *batch, a.ctx); err != nil {
+		return err
+	}
+	return a.message("imported %d vectors from %s into %s", len(embs), *file, *collection)
+}
+
+func runExport(a *app, args []string) error {
+	fs := a.flags("export")
+	collection := fs.String("collection", "", "collection name, must be loaded")
+	file := fs.String("file", "", "output vector file")
+	format := fs.String("format", "wego", "wego, glove, fasttext, word2vec, npy or f16")
+	batch := fs.Int("batch", 10000, "rows per query page")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" || *file == "" {
+		return usagef("-collection and -file are required")
+	}
+	outputFormat, err := embeddings.ParseFormat(*format)
+	if err != nil {
+		return usagef("%s", err)
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	wordField, vectorField, err := wordFields(a, *collection)
+	if err != nil {
+		return err
+	}
+	words, vectors, err := vectordb.ExportWords(milvusClient, *collection, wordField, vectorField, *batch, a.ctx)
+	if err != nil {
+		return err
+	}
+	embs := make([]embeddings.Embedding, len(words))
+	for i := range words {
+		embs[i] = embeddings.Embedding{Word: words[i], Vector: vectors[i]}
+	}
+	if err := embeddings.Save(*file, embs, outputFormat); err != nil {
+		return err
+	}
+	return a.message("exported %d vectors from %s to %s", len(embs), *collection, *file)
+}
+
+func runSearch(a *app, args []string) error {
+	fs := a.flags("search")
+	collection := fs.String("collection", "", "collection name, must be loaded")
+	field := fs.String("field", "", "vector field, defaults to the collection's first vector field")
+	vector := fs.String("vector", "", "query vector as comma separated floats")
+	word := fs.String("word", "", "use this word's vector from -vectors as the query")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file used with -word")
+	model := fs.String("model", "", "registered model used with -word instead of -vectors")
+	topK := fs.Int("topk", 10, "number of results")
+	expr := fs.String("expr", "", "boolean filter applied before the search")
+	fields := fs.String("fields", "", "comma separated output fields")
+	metric := fs.String("metric", "L2", "metric the index was built with")
+	indexType := fs.String("index-type", "FLAT", "index type, selects the search parameters")
+	params := fs.String("params", "", "search parameters, e.g. nprobe=16 or ef=64")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" {
+		return usagef("-collection is required")
+	}
+
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	query, err := queryVector(*vector, *word, path)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseParams(*params)
+	if err != nil {
+		return err
+	}
+	_, sp, err := vectordb.NewIndex(*indexType, entity.MetricType(strings.ToUpper(*metric)), parsed)
+	if err != nil {
+		return usagef("%s", err)
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	if *field == "" {
+		info, err := vectordb.DescribeCollection(milvusClient, *collection, a.ctx)
+		if err != nil {
+			return err
+		}
+		vf, ok := info.VectorField()
+		if !ok {
+			return fmt.Errorf("collection %s has no float vector field", *collection)
+		}
+		*field = vf.Name
+	}
+
+	results, err := vectordb.Search(milvusClient, vectordb.SearchParams{
+		CollectionName: *collection,
+		Expr:           *expr,
+		OutputFields:   splitList(*fields),
+		VectorField:    *field,
+		Vectors:        []entity.Vector{entity.FloatVector(query)},
+		Metric:         entity.MetricType(strings.ToUpper(*metric)),
+		TopK:           *topK,
+		SearchParam:    sp,
+	}, a.ctx)
+	if err != nil {
+		return err
+	}
+
+	hits := []vectordb.Hit{}
+	if len(results) > 0 {
+		hits = vectordb.Hits(results[0])
+	}
+	outputFields := splitList(*fields)
+	rows := make([][]string, len(hits))
+	for i, h := range hits {
+		rows[i] = []string{strconv.Itoa(i + 1), fmt.Sprint(h.ID), fmt.Sprintf("%.6f", h.Score)}
+		for _, f := range outputFields {
+			rows[i] = append(rows[i], formatValue(h.Fields[f]))
+		}
+	}
+	return a.print(hits, append([]string{"rank", "id", "score"}, outputFields...), rows)
+}
+
+// queryVector parses -vector or looks up -word in a vector file
+func queryVector(vector string, word string, vectorsPath string) ([]float32, error) {
+	switch {
+	case vector != "" && word != "":
+		return nil, usagef("use either -vector or -word")
+	case vector != "":
+		var query []float32
+		for _, value := range splitList(vector) {
+			v, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return nil, usagef("invalid vector value %q", value)
+			}
+			query = append(query, float32(v))
+		}
+		return query, nil
+	case word != "":
+		embs, err := embeddings.Load(vectorsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range embs {
+			if e.Word == word {
+				return e.Vector, nil
+			}
+		}
+		return nil, fmt.Errorf("word %q is not in %s", word, vectorsPath)
+	}
+	return nil, usagef("-vector or -word is required")
+}
+
+// wordFields finds the VarChar primary key and vector field of a word collection
+func wordFields(a *app, collection string) (string, string, error) {
+	milvusClient, err := a.client()
+	if err != nil {
+		return "", "", err
+	}
+	info, err := vectordb.DescribeCollection(milvusClient, collection, a.ctx)
+	if err != nil {
+		return "", "", err
+	}
+	pk, ok := info.PrimaryField()
+	if !ok || pk.DataType != entity.FieldTypeVarChar.String() {
+		return "", "", fmt.Errorf("collection %s needs a VarChar primary key holding the words", collection)
+	}
+	vf, ok := info.VectorField()
+	if !ok {
+		return "", "", fmt.Errorf("collection %s has no float vector field", collection)
+	}
+	return pk.Name, vf.Name, nil
+}
+
+func loadEmbeddings(path string, format string) ([]embeddings.Embedding, error) {
+	if format == "" {
+		return embeddings.Load(path)
+	}
+	f, err := embeddings.ParseFormat(format)
+	if err != nil {
+		return nil, usagef("%s", err)
+	}
+	return embeddings.LoadFormat(path, f)
+}
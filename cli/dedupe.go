@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"milvus/dedupe"
+	"milvus/embeddings"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+func runDedupe(a *app, args []string) error {
+	fs := a.flags("dedupe")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file to deduplicate")
+	model := fs.String("model", "", "deduplicate a registered model instead of -vectors, e.g. words@2")
+	collection := fs.String("collection", "", "deduplicate the words of this (loaded) Milvus collection instead")
+	threshold := fs.String("threshold", "", "duplicates are below this squared distance (L2) or above this similarity (IP, COSINE)")
+	metric := fs.String("metric", "L2", "L2, IP or COSINE; for -collection the metric the index was built with")
+	topK := fs.Int("topk", 100, "neighbours range searched per entity")
+	batch := fs.Int("batch", 1000, "entities per batch of range searches")
+	indexType := fs.String("index-type", "FLAT", "with -collection, index type, selects the search parameters")
+	params := fs.String("params", "", "with -collection, search parameters, e.g. nprobe=16 or ef=64")
+	show := fs.Int("show", 20, "groups to list (0 = all)")
+	groupsPath := fs.String("groups", "", "write duplicate<TAB>canonical lines to this file")
+	output := fs.String("output", "", "write the vectors without the duplicates to this file")
+	format := fs.String("format", "", "-output format (default: the format of the input, see export)")
+	remove := fs.Bool("delete", false, "with -collection, delete every duplicate but the canonical member of its group")
+	force := fs.Bool("force", false, "don't ask for confirmation before -delete")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *threshold == "" {
+		return usagef("-threshold is required")
+	}
+	limit, err := strconv.ParseFloat(*threshold, 64)
+	if err != nil {
+		return usagef("invalid -threshold %q", *threshold)
+	}
+	switch {
+	case *remove && *collection == "":
+		return usagef("-delete needs -collection, use -output to deduplicate a vector file")
+	case *output != "" && *collection != "":
+		return usagef("-output only works with vector files, use export to write a collection to one")
+	}
+	opts := dedupe.Options{
+		Metric:        dedupe.Metric(strings.ToUpper(*metric)),
+		Threshold:     limit,
+		MaxNeighbours: *topK,
+		BatchSize:     *batch,
+	}
+
+	var result *dedupe.Result
+	var embs []embeddings.Embedding
+	source := *collection
+	if *collection != "" {
+		parsed, err := parseParams(*params)
+		if err != nil {
+			return err
+		}
+		_, sp, err := vectordb.NewIndex(*indexType, entity.MetricType(opts.Metric), parsed)
+		if err != nil {
+			return usagef("%s", err)
+		}
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		wordField, vectorField, err := wordFields(a, *collection)
+		if err != nil {
+			return err
+		}
+		if result, err = dedupe.Collection(milvusClient, *collection, wordField, vectorField, sp, opts, a.ctx); err != nil {
+			return err
+		}
+		if *remove && result.Duplicates > 0 {
+			if err := a.confirm(*force, fmt.Sprintf("This permanently deletes %d duplicates from %s.", result.Duplicates, *collection), *collection); err != nil {
+				return err
+			}
+			skipped, err := vectordb.DeleteWords(milvusClient, *collection, wordField, duplicates(result.Groups), *batch, a.ctx)
+			if err != nil {
+				return err
+			}
+			if len(skipped) > 0 {
+				a.logger.Warn("kept duplicates that can't be written in a Milvus expression", "collection", *collection, "words", skipped)
+			}
+			a.logger.Info("deleted duplicates", "collection", *collection, "rows", result.Duplicates-len(skipped))
+		}
+	} else {
+		path, err := a.vectorsPath(*vectors, *model)
+		if err != nil {
+			return err
+		}
+		if embs, err = embeddings.Load(path); err != nil {
+			return err
+		}
+		if result, err = dedupe.Embeddings(embs, opts, a.ctx); err != nil {
+			return err
+		}
+		source = path
+		if *output != "" {
+			outputFormat, err := embeddings.DetectFormat(path)
+			if err != nil {
+				return err
+			}
+			if *format != "" {
+				if outputFormat, err = embeddings.ParseFormat(*format); err != nil {
+					return usagef("%s", err)
+				}
+			}
+			drop := make(map[string]bool, result.Duplicates)
+			for _, word := range duplicates(result.Groups) {
+				drop[word] = true
+			}
+			kept := make([]embeddings.Embedding, 0, len(embs)-len(drop))
+			for _, e := range embs {
+				if !drop[e.Word] {
+					kept = append(kept, e)
+				}
+			}
+			if err := embeddings.Save(*output, kept, outputFormat); err != nil {
+				return err
+			}
+			a.logger.Info("saved deduplicated vectors", "output", *output, "vectors", len(kept), "format", outputFormat.String())
+		}
+	}
+	a.logger.Info("deduplicated", "source", source, "entities", result.Entities, "groups", len(result.Groups), "duplicates", result.Duplicates)
+	if result.Saturated > 0 {
+		a.logger.Warn("some entities had -topk neighbours within the threshold, raise -topk to find all their duplicates", "entities", result.Saturated, "topk", *topK)
+	}
+
+	if *groupsPath != "" {
+		err := writeOutput(*groupsPath, func(w io.Writer) error {
+			writer := bufio.NewWriter(w)
+			for _, g := range result.Groups {
+				for _, word := range g.Duplicates {
+					fmt.Fprintf(writer, "%s\t%s\n", word, g.Canonical)
+				}
+			}
+			return writer.Flush()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.output == "json" {
+		return a.print(result, nil, nil)
+	}
+	fmt.Fprintf(a.stdout, "%d entities, %d duplicate groups, %d duplicates\n", result.Entities, len(result.Groups), result.Duplicates)
+	groups := result.Groups
+	if *show > 0 && *show < len(groups) {
+		groups = groups[:*show]
+	}
+	rows := make([][]string, len(groups))
+	for i, g := range groups {
+		rows[i] = []string{strconv.Itoa(i + 1), strconv.Itoa(len(g.Duplicates) + 1), g.Canonical, strings.Join(g.Duplicates, " ")}
+	}
+	return a.print(nil, []string{"group", "size", "canonical", "duplicates"}, rows)
+}
+
+// duplicates lists the members of groups that aren't canonical
+func duplicates(groups []dedupe.Group) []string {
+	var words []string
+	for _, g := range groups {
+		words = append(words, g.Duplicates...)
+	}
+	return words
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"milvus/vectordb"
+)
+
+// confirm asks for answer on stdin before a destructive operation, unless force is set
+func (a *app) confirm(force bool, prompt string, answer string) error {
+	if force {
+		return nil
+	}
+	fmt.Fprintf(a.stderr, "%s\nType %q to confirm: ", prompt, answer)
+	var line string
+	if a.stdin != nil {
+		line, _ = bufio.NewReader(a.stdin).ReadString('\n')
+	}
+	if strings.TrimSpace(line) != answer {
+		fmt.Fprintln(a.stderr)
+		return fmt.Errorf("aborted, nothing was removed (pass -force to skip the confirmation)")
+	}
+	return nil
+}
+
+func collectionsDrop(a *app, args []string) error {
+	fs := a.flags("collections drop")
+	all := fs.Bool("all", false, "drop every collection that isn't protected")
+	dryRun := fs.Bool("dry-run", false, "list what would be dropped without dropping it")
+	force := fs.Bool("force", false, "don't ask for confirmation")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *all == (fs.NArg() > 0) {
+		return usagef("expected collection names or -all")
+	}
+
+	for _, name := range fs.Args() {
+		if vectordb.IsProtected(name) {
+			return fmt.Errorf("%w: %s", vectordb.ErrProtectedCollection, name)
+		}
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if *all {
+		if names, err = vectordb.DeletableCollections(milvusClient, a.ctx); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		exists, err := milvusClient.HasCollection(a.ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check collection %s: %w", name, err)
+		}
+		if !exists {
+			return fmt.Errorf("collection %s does not exist", name)
+		}
+	}
+	if len(names) == 0 {
+		return a.message("no collections to drop")
+	}
+
+	if *dryRun {
+		rows := make([][]string, len(names))
+		for i, name := range names {
+			rows[i] = []string{name, rowCount(a, name)}
+		}
+		return a.print(map[string][]string{"would_drop": names}, []string{"would drop", "rows"}, rows)
+	}
+
+	answer := names[0]
+	if len(names) > 1 {
+		answer = fmt.Sprintf("drop %d collections", len(names))
+	}
+	if err := a.confirm(*force, fmt.Sprintf("This permanently drops %s on %s.", strings.Join(names, ", "), a.addr), answer); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := vectordb.DeleteCollection(milvusClient, name, a.ctx); err != nil {
+			return err
+		}
+	}
+	return a.message("dropped %s", strings.Join(names, ", "))
+}
+
+func runDelete(a *app, args []string) error {
+	fs := a.flags("delete")
+	collection := fs.String("collection", "", "collection name, must be loaded")
+	partition := fs.String("partition", "", "only delete from this partition")
+	expr := fs.String("expr", "", "filter expression selecting the entities to delete, e.g. \"word in ['cat', 'dog']\"")
+	dryRun := fs.Bool("dry-run", false, "list the primary keys that would be deleted without deleting them")
+	force := fs.Bool("force", false, "don't ask for confirmation")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" || strings.TrimSpace(*expr) == "" {
+		return usagef("-collection and -expr are required")
+	}
+	if vectordb.IsProtected(*collection) {
+		return fmt.Errorf("%w: %s", vectordb.ErrProtectedCollection, *collection)
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	info, err := vectordb.DescribeCollection(milvusClient, *collection, a.ctx)
+	if err != nil {
+		return err
+	}
+	pk, ok := info.PrimaryField()
+	if !ok {
+		return fmt.Errorf("collection %s has no primary key", *collection)
+	}
+	var partitions []string
+	if *partition != "" {
+		partitions = []string{*partition}
+	}
+	columns, err := vectordb.Query(milvusClient, vectordb.QueryParams{
+		CollectionName: *collection,
+		PartitionNames: partitions,
+		Expr:           *expr,
+		OutputFields:   []string{pk.Name},
+	}, a.ctx)
+	if err != nil {
+		return err
+	}
+	matches := vectordb.Rows(columns)
+	if len(matches) == 0 {
+		return a.message("no entities in %s match %s", *collection, *expr)
+	}
+
+	if *dryRun {
+		return a.printRows(matches)
+	}
+	if err := a.confirm(*force, fmt.Sprintf("This permanently deletes %d entities from %s.", len(matches), *collection), *collection); err != nil {
+		return err
+	}
+	if err := vectordb.DeleteByExpr(milvusClient, *collection, *partition, *expr, a.ctx); err != nil {
+		return err
+	}
+	return a.message("deleted %d entities from %s", len(matches), *collection)
+}
+
+func rowCount(a *app, collection string) string {
+	info, err := vectordb.DescribeCollection(a.milvusClient, collection, a.ctx)
+	if err != nil || info.RowCount == "" {
+		return "?"
+	}
+	return info.RowCount
+}
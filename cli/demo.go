@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+
+	"milvus/vectordb"
+	"milvus/vectorize"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+/*
+runDemo is the walkthrough main.go used to run on every start, minus dropping every collection first:
+
+- Train() word vectors from string-vectors/input and QueryVector() them
+- Create a "words" collection (-force recreates it), insert a few raw embeddings into it
+- Index, load, query and search the collection
+*/
+func runDemo(a *app, args []string) error {
+	fs := a.flags("demo")
+	force := fs.Bool("force", false, "drop the demo's \"words\" collection first if it already exists")
+	input := fs.String("input", "string-vectors/input", "UTF-8 corpus to train on")
+	output := fs.String("output", "string-vectors/word_vector.txt", "where to write the word vectors")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if err := vectorize.Train(*input, *output); err != nil {
+		return err
+	}
+	if err := vectorize.QueryVector("cat", *output); err != nil {
+		return err
+	}
+
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	// Only ever touch the demo's own collection - other collections on the server are left alone
+	exists, err := milvusClient.HasCollection(a.ctx, "words")
+	if err != nil {
+		return fmt.Errorf("failed to check collection words: %w", err)
+	}
+	if exists {
+		if !*force {
+			return fmt.Errorf("collection words already exists, rerun with -force to drop and recreate it")
+		}
+		if err := vectordb.DeleteCollection(milvusClient, "words", a.ctx); err != nil {
+			return err
+		}
+	}
+
+	// ------------>  CREATING COLLECTIONS  <------------
+
+	err = vectordb.NewCollectionBuilder().
+		WithName("words").
+		WithDescription("collection of words").
+		WithFields(
+			vectordb.NewFieldVarChar("word", 100, true, false),
+			vectordb.NewFieldFloatVector("embedding", 3),
+		).
+		Create(milvusClient, a.ctx)
+	if err != nil {
+		return err
+	}
+
+	// ------------>  INSERTING INTO COLLECTIONS  <------------
+
+	words := []string{"word1", "word2", "word3", "cat", "dog"}
+	embeddings := [][]float32{
+		{0.1, 0.2, 0.3}, // embedding for word1
+		{0.4, 0.5, 0.6}, // embedding for word2
+		{0.7, 0.8, 0.9}, // embedding for word3
+		{0.2, 0.2, 0.7}, // embedding for cat
+		{0.2, 0.2, 0.8}, // embedding for dog
+	}
+
+	insertParams := vectordb.InsertParams{
+		CollectionName: "words",
+		Columns: map[string]entity.Column{
+			"word":      entity.NewColumnVarChar("word", words),
+			"embedding": entity.NewColumnFloatVector("embedding", 3, embeddings), // 3 is the dimensionality of the embeddings
+		},
+	}
+	if err := vectordb.InsertData(milvusClient, insertParams, a.ctx); err != nil {
+		return err
+	}
+
+	// ------------>  Searching from a Collection  <------------
+
+	if err := vectordb.CreateIndex(milvusClient, "words", "embedding", entity.L2, 1024, a.ctx); err != nil {
+		return err
+	}
+	if err := vectordb.LoadCollection(milvusClient, "words", a.ctx); err != nil {
+		return err
+	}
+	if err := vectordb.QueryCollection(milvusClient, "words", "word not in ['cat', 'dog']", []string{"word"}, a.ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprint(a.stdout, "\n\nSearching Collection\n\n")
+	queryVectors := []entity.Vector{entity.FloatVector([]float32{0.2, 0.2, 0.8})}
+	if err := vectordb.SearchIndexFromCollection(milvusClient, "words", "embedding", queryVectors, []string{"word"}, 3, a.ctx); err != nil {
+		return err
+	}
+	fmt.Fprint(a.stdout, "\n\nSearching Done\n\n")
+	return nil
+}
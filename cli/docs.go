@@ -0,0 +1,282 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"milvus/documents"
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+)
+
+func runDocs(a *app, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected ingest or search")
+	}
+	switch args[0] {
+	case "ingest":
+		return docsIngest(a, args[1:])
+	case "search":
+		return docsSearch(a, args[1:])
+	}
+	return usagef("unknown docs command %q", args[0])
+}
+
+func docsIngest(a *app, args []string) error {
+	fs := a.flags("docs ingest")
+	collection := fs.String("collection", "", "chunk collection, created (indexed and loaded) if it doesn't exist")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "word vectors the chunks are embedded with")
+	model := fs.String("model", "", "registered model instead of -vectors, e.g. words@production")
+	mode := fs.String("chunk", "sentence", "chunk by sentence, paragraph or token")
+	size := fs.Int("size", 0, "units per chunk (default: 5 sentences, 1 paragraph or 200 tokens)")
+	overlap := fs.Int("overlap", -1, "units shared by consecutive chunks (default: 1 sentence, 0 paragraphs or 50 tokens)")
+	extensions := fs.String("ext", ".txt,.md", "file extensions read from directories")
+	indexType := fs.String("index-type", "FLAT", "index created on a new collection")
+	params := fs.String("params", "", "index parameters, e.g. nlist=1024 or M=16,efConstruction=200")
+	batch := fs.Int("batch", 1000, "chunks per insert call")
+	replace := fs.Bool("replace", true, "delete the chunks already stored for a file once its new chunks are inserted")
+	dryRun := fs.Bool("dry-run", false, "list the chunks without storing them")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *collection == "" && !*dryRun {
+		return usagef("-collection is required")
+	}
+	if fs.NArg() == 0 {
+		return usagef("expected files or directories to ingest")
+	}
+	opts := documents.DefaultChunkOptions(documents.Mode(strings.ToLower(*mode)))
+	opts.Mode = documents.Mode(strings.ToLower(*mode))
+	if *size > 0 {
+		opts.Size = *size
+		opts.Overlap = min(opts.Overlap, opts.Size-1)
+	}
+	if *overlap >= 0 {
+		opts.Overlap = *overlap
+	}
+
+	files, err := documentFiles(fs.Args(), splitList(*extensions))
+	if err != nil {
+		return err
+	}
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	table, err := embeddings.LoadTable(path)
+	if err != nil {
+		return err
+	}
+
+	type chunkRow struct {
+		documents.Chunk
+		Terms int `json:"terms"`
+	}
+	rows := []chunkRow{}
+	var milvusClient client.Client
+	if !*dryRun {
+		parsed, err := parseParams(*params)
+		if err != nil {
+			return err
+		}
+		idx, _, err := vectordb.NewIndex(*indexType, documents.Metric, parsed)
+		if err != nil {
+			return usagef("%s", err)
+		}
+		if milvusClient, err = a.client(); err != nil {
+			return err
+		}
+		if err := documents.CreateCollection(milvusClient, *collection, table.Dim(), idx, a.ctx); err != nil {
+			return err
+		}
+	}
+
+	stored, skipped := 0, 0
+	for _, file := range files {
+		// search reads the source again for context, which has to work from any directory
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		chunks, err := documents.SplitFile(file, opts)
+		if err != nil {
+			return err
+		}
+		var kept []documents.Chunk
+		var vectors [][]float32
+		for _, c := range chunks {
+			vector, err := documents.Embed(table, c.Text)
+			if err != nil {
+				// no word of the chunk has a vector, it can't be found anyway
+				skipped++
+				continue
+			}
+			kept = append(kept, c)
+			vectors = append(vectors, vector)
+			if *dryRun {
+				rows = append(rows, chunkRow{Chunk: c, Terms: len(documents.Terms(table, c.Text))})
+			}
+		}
+		if *dryRun {
+			continue
+		}
+		// The old chunks are deleted only once the new ones are stored, so a failed insert keeps
+		// them; a search in between can see both
+		var old []int64
+		if *replace {
+			if old, err = documents.SourceIDs(milvusClient, *collection, file, a.ctx); err != nil {
+				return err
+			}
+		}
+		if err := documents.Insert(milvusClient, *collection, kept, vectors, *batch, a.ctx); err != nil {
+			return err
+		}
+		if err := documents.DeleteIDs(milvusClient, *collection, old, *batch, a.ctx); err != nil {
+			return err
+		}
+		stored += len(kept)
+		a.logger.Info("ingested document", "source", file, "chunks", len(kept))
+	}
+	if skipped > 0 {
+		a.logger.Warn("skipped chunks without known words", "chunks", skipped)
+	}
+
+	if *dryRun {
+		lines := make([][]string, len(rows))
+		for i, r := range rows {
+			lines[i] = []string{r.Source, strconv.Itoa(r.Index), fmt.Sprintf("%d-%d", r.Start, r.End), strconv.Itoa(r.Terms), preview(r.Text, 60)}
+		}
+		return a.print(rows, []string{"source", "chunk", "bytes", "terms", "text"}, lines)
+	}
+	return a.message("stored %d chunks of %d files in %s", stored, len(files), *collection)
+}
+
+func docsSearch(a *app, args []string) error {
+	fs := a.flags("docs search")
+	collection := fs.String("collection", "", "chunk collection, must be loaded")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "word vectors the chunks were embedded with")
+	model := fs.String("model", "", "registered model instead of -vectors, e.g. words@production")
+	topK := fs.Int("topk", 5, "number of chunks")
+	expr := fs.String("expr", "", "filter applied before the search, e.g. 'source like \"%/docs/%\"'")
+	contextBytes := fs.Int("context", 200, "bytes of the source shown around each chunk")
+	threshold := fs.Float64("highlight-similarity", 0.7, "also highlight words at least this similar to a query word (above 1: only the query words)")
+	color := fs.Bool("color", false, "highlight with ANSI bold instead of **")
+	indexType := fs.String("index-type", "FLAT", "index type of the collection, selects the search parameters")
+	params := fs.String("params", "", "search parameters, e.g. nprobe=16 or ef=64")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	query := strings.Join(fs.Args(), " ")
+	if *collection == "" || strings.TrimSpace(query) == "" {
+		return usagef("-collection and a query are required")
+	}
+
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	table, err := embeddings.LoadTable(path)
+	if err != nil {
+		return err
+	}
+	vector, err := documents.Embed(table, query)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseParams(*params)
+	if err != nil {
+		return err
+	}
+	_, sp, err := vectordb.NewIndex(*indexType, documents.Metric, parsed)
+	if err != nil {
+		return usagef("%s", err)
+	}
+	milvusClient, err := a.client()
+	if err != nil {
+		return err
+	}
+	matches, err := documents.Search(milvusClient, *collection, vector, *topK, *expr, sp, a.ctx)
+	if err != nil {
+		return err
+	}
+
+	markStart, markEnd := "**", "**"
+	if *color {
+		markStart, markEnd = "\x1b[1m", "\x1b[0m"
+	}
+	match := documents.Matcher(table, query, *threshold)
+	type result struct {
+		documents.Match
+		Snippet documents.Snippet `json:"snippet"`
+	}
+	results := make([]result, len(matches))
+	for i, m := range matches {
+		snippet := documents.Context(m.Chunk, *contextBytes)
+		snippet.Text = documents.Highlight(snippet.Text, match, markStart, markEnd)
+		results[i] = result{Match: m, Snippet: snippet}
+	}
+	if a.output == "json" {
+		return a.print(results, nil, nil)
+	}
+	if len(results) == 0 {
+		return a.message("no chunks in %s", *collection)
+	}
+	for i, r := range results {
+		fmt.Fprintf(a.stdout, "%d. %.4f  %s  chunk %d, bytes %d-%d\n", i+1, r.Score, r.Source, r.Index, r.Start, r.End)
+		text := oneLine(strings.Join([]string{r.Snippet.Before, r.Snippet.Text, r.Snippet.After}, " "))
+		fmt.Fprintf(a.stdout, "   %s\n\n", text)
+	}
+	return nil
+}
+
+// documentFiles expands directories into the files below them with one of extensions
+func documentFiles(paths []string, extensions []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if file == path {
+				files = append(files, file)
+				return nil
+			}
+			for _, ext := range extensions {
+				if strings.EqualFold(filepath.Ext(file), ext) {
+					files = append(files, file)
+					break
+				}
+			}
+			return nil
+		})
+		if os.IsNotExist(err) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		if err != nil {
+			return nil, errors.FileLoadingError(path, err)
+		}
+	}
+	return files, nil
+}
+
+// oneLine collapses all whitespace of s into single spaces
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// preview shortens s to one line of at most n runes
+func preview(s string, n int) string {
+	s = oneLine(s)
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
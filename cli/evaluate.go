@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"milvus/benchmark"
+	"milvus/datasets"
+	"milvus/embeddings"
+	"milvus/hnsw"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+func runEvaluate(a *app, args []string) error {
+	fs := a.flags("evaluate")
+	dataPath := fs.String("data", "string-vectors/word_vector.txt", "vectors to index, any format the embeddings package reads, or a .fvecs/.bvecs/.hdf5 dataset")
+	queriesPath := fs.String("queries", "", "query vectors, defaults to holding out -nq vectors from -data")
+	numQueries := fs.Int("nq", 100, "number of held out queries when -queries is not set")
+	k := fs.Int("k", 10, "number of neighbours to retrieve")
+	metric := fs.String("metric", "", "distance metric: L2, IP or COSINE, defaults to the dataset's metric or L2")
+	specs := fs.String("specs", "flat;hnsw", "';' separated stores to benchmark, e.g. hnsw:M=16,efSearch=64;milvus:IVF_FLAT:nlist=128,nprobe=16")
+	collection := fs.String("collection", "benchmark_scratch", "scratch collection created and dropped for milvus specs")
+	report := fs.String("out", "", "comma separated report files, format picked from the extension (.csv, .json, .md)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	parsed, err := benchmark.ParseSpecs(*specs)
+	if err != nil {
+		return usagef("%s", err)
+	}
+	dataset, datasetMetric, err := loadDataset(*dataPath, *queriesPath, *numQueries)
+	if err != nil {
+		return err
+	}
+	if *metric != "" {
+		datasetMetric = benchmark.Metric(strings.ToUpper(*metric))
+	}
+
+	stores, err := newStores(a, parsed, datasetMetric, *collection)
+	if err != nil {
+		return err
+	}
+	results, err := benchmark.Run(stores, dataset, *k, datasetMetric, a.ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range splitList(*report) {
+		if err := benchmark.SaveReport(path, results); err != nil {
+			return err
+		}
+	}
+	if a.output == "json" {
+		err = benchmark.WriteJSON(a.stdout, results)
+	} else {
+		err = benchmark.WriteMarkdown(a.stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+	// a store that failed is in the output, but the run still fails
+	for _, r := range results {
+		if r.Error != "" {
+			return fmt.Errorf("%s failed: %s", r.Store, r.Error)
+		}
+	}
+	return nil
+}
+
+func loadDataset(dataPath string, queriesPath string, numQueries int) (benchmark.Dataset, benchmark.Metric, error) {
+	if datasets.IsDataset(dataPath) {
+		loaded, err := datasets.Load(dataPath)
+		if err != nil {
+			return benchmark.Dataset{}, "", err
+		}
+		return loaded.Benchmark(), loaded.Metric, nil
+	}
+
+	dataset := benchmark.Dataset{Name: dataPath}
+	embs, err := embeddings.Load(dataPath)
+	if err != nil {
+		return dataset, "", err
+	}
+	vectors := make([][]float32, len(embs))
+	for i, e := range embs {
+		vectors[i] = e.Vector
+	}
+
+	if queriesPath == "" {
+		if numQueries <= 0 || numQueries >= len(vectors) {
+			return dataset, "", usagef("can not hold out %d queries from %d vectors, lower -nq", numQueries, len(vectors))
+		}
+		dataset.Train, dataset.Queries = vectors[:len(vectors)-numQueries], vectors[len(vectors)-numQueries:]
+		return dataset, benchmark.L2, nil
+	}
+
+	queries, err := embeddings.Load(queriesPath)
+	if err != nil {
+		return dataset, "", err
+	}
+	dataset.Train = vectors
+	for _, q := range queries {
+		dataset.Queries = append(dataset.Queries, q.Vector)
+	}
+	return dataset, benchmark.L2, nil
+}
+
+func newStores(a *app, specs []benchmark.Spec, metric benchmark.Metric, collection string) ([]benchmark.VectorStore, error) {
+	var stores []benchmark.VectorStore
+	for i, spec := range specs {
+		switch spec.Backend {
+		case "flat", "bruteforce":
+			stores = append(stores, &benchmark.BruteForce{Metric: metric})
+		case "hnsw":
+			defaults := hnsw.DefaultConfig()
+			stores = append(stores, &benchmark.HNSW{Config: hnsw.Config{
+				M:              spec.Param("M", defaults.M),
+				EfConstruction: spec.Param("efConstruction", defaults.EfConstruction),
+				EfSearch:       spec.Param("efSearch", defaults.EfSearch),
+				Metric:         hnsw.Metric(metric),
+				Seed:           defaults.Seed,
+			}})
+		case "milvus":
+			milvusClient, err := a.client()
+			if err != nil {
+				return nil, err
+			}
+			stores = append(stores, &vectordb.MilvusStore{
+				Client:     milvusClient,
+				Collection: fmt.Sprintf("%s_%d", collection, i),
+				IndexType:  spec.IndexType,
+				Params:     spec.Params,
+				Metric:     entity.MetricType(metric),
+			})
+		default:
+			return nil, usagef("unknown benchmark backend %q in %s", spec.Backend, spec)
+		}
+	}
+	return stores, nil
+}
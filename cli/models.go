@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"milvus/embeddings"
+	"milvus/registry"
+)
+
+func runModels(a *app, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected list, show, compare, promote, delete, score or register")
+	}
+	switch args[0] {
+	case "list":
+		return modelsList(a, args[1:])
+	case "show":
+		return modelsShow(a, args[1:])
+	case "compare":
+		return modelsCompare(a, args[1:])
+	case "promote":
+		return modelsPromote(a, args[1:])
+	case "delete":
+		return modelsDelete(a, args[1:])
+	case "score":
+		return modelsScore(a, args[1:])
+	case "register":
+		return modelsRegister(a, args[1:])
+	}
+	return usagef("unknown models command %q", args[0])
+}
+
+// registry opens the -registry directory
+func (a *app) registry() (*registry.Registry, error) {
+	return registry.Open(a.registryDir)
+}
+
+// vectorsPath is path, or the vector file of the registered model ref if one is given
+func (a *app) vectorsPath(path string, ref string) (string, error) {
+	if ref == "" {
+		return path, nil
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return "", err
+	}
+	return reg.Path(ref)
+}
+
+func modelsList(a *app, args []string) error {
+	fs := a.flags("models list")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		if names, err = reg.Models(); err != nil {
+			return err
+		}
+	}
+
+	manifests := []registry.Manifest{}
+	var rows [][]string
+	for _, name := range names {
+		versions, err := reg.Versions(name)
+		if err != nil {
+			return err
+		}
+		aliases, err := reg.Aliases(name)
+		if err != nil {
+			return err
+		}
+		for _, m := range versions {
+			manifests = append(manifests, m)
+			rows = append(rows, []string{m.Name, strconv.Itoa(m.Version), aliasesOf(aliases, m.Version),
+				strconv.Itoa(m.VocabSize), strconv.Itoa(m.Dimension), m.TrainedAt.Local().Format(time.DateTime), formatScores(m.Scores)})
+		}
+	}
+	return a.print(manifests, []string{"model", "version", "aliases", "words", "dim", "trained", "scores"}, rows)
+}
+
+func modelsShow(a *app, args []string) error {
+	fs := a.flags("models show")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one model, e.g. words@2")
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	m, err := reg.Get(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"model", m.Ref()},
+		{"file", reg.FilePath(m)},
+		{"algorithm", m.Algorithm},
+		{"corpus", m.Corpus},
+		{"corpus_sha256", m.CorpusSHA256},
+		{"parent", m.Parent},
+		{"vocab_size", strconv.Itoa(m.VocabSize)},
+		{"dimension", strconv.Itoa(m.Dimension)},
+		{"trained_at", m.TrainedAt.Format(time.RFC3339)},
+		{"training_seconds", fmt.Sprintf("%.1f", m.TrainingSeconds)},
+	}
+	for _, k := range sortedKeys(m.Hyperparameters) {
+		rows = append(rows, []string{"hyperparameters." + k, m.Hyperparameters[k]})
+	}
+	for _, k := range sortedScoreKeys(m.Scores) {
+		rows = append(rows, []string{"scores." + k, strconv.FormatFloat(m.Scores[k], 'f', -1, 64)})
+	}
+	return a.print(m, []string{"field", "value"}, rows)
+}
+
+func modelsCompare(a *app, args []string) error {
+	fs := a.flags("models compare")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usagef("expected two models, e.g. words@1 words@2")
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	ma, err := reg.Get(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	mb, err := reg.Get(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diffs := registry.Compare(ma, mb)
+	rows := make([][]string, len(diffs))
+	for i, d := range diffs {
+		rows[i] = []string{d.Field, d.A, d.B}
+	}
+	if diffs == nil {
+		diffs = []registry.Difference{}
+	}
+	return a.print(diffs, []string{"field", ma.Ref(), mb.Ref()}, rows)
+}
+
+func modelsPromote(a *app, args []string) error {
+	fs := a.flags("models promote")
+	alias := fs.String("alias", registry.Production, "alias to point at the version")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one model, e.g. words@2")
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	m, err := reg.Promote(fs.Arg(0), *alias)
+	if err != nil {
+		return err
+	}
+	return a.message("%s@%s is now version %d", m.Name, *alias, m.Version)
+}
+
+func modelsDelete(a *app, args []string) error {
+	fs := a.flags("models delete")
+	force := fs.Bool("force", false, "delete even if an alias points at the version, and don't ask")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one model version, e.g. words@2")
+	}
+	if !strings.Contains(fs.Arg(0), "@") {
+		return usagef("name the version to delete, e.g. %s@2", fs.Arg(0))
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	m, err := reg.Get(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := a.confirm(*force, fmt.Sprintf("delete model %s", m.Ref()), m.Ref()); err != nil {
+		return err
+	}
+	if _, err := reg.Delete(m.Ref(), *force); err != nil {
+		return err
+	}
+	return a.message("deleted %s", m.Ref())
+}
+
+func modelsScore(a *app, args []string) error {
+	fs := a.flags("models score")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return usagef("expected a model and name=value scores, e.g. words@2 analogy=0.61")
+	}
+	scores := map[string]float64{}
+	for _, arg := range fs.Args()[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		v, err := strconv.ParseFloat(value, 64)
+		if !ok || key == "" || err != nil {
+			return usagef("invalid score %q, expected name=number", arg)
+		}
+		scores[key] = v
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	m, err := reg.Score(fs.Arg(0), scores)
+	if err != nil {
+		return err
+	}
+	return a.message("scored %s: %s", m.Ref(), formatScores(m.Scores))
+}
+
+// modelsRegister adds vectors trained elsewhere to the registry
+func modelsRegister(a *app, args []string) error {
+	fs := a.flags("models register")
+	name := fs.String("name", "", "model name")
+	file := fs.String("file", "", "vector file in any supported format")
+	corpus := fs.String("corpus", "", "corpus the vectors were trained on, to record its hash")
+	algorithm := fs.String("algorithm", "unknown", "how the vectors were trained")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *name == "" || *file == "" {
+		return usagef("-name and -file are required")
+	}
+
+	embs, err := embeddings.Load(*file)
+	if err != nil {
+		return err
+	}
+	dim, err := embeddings.Dim(embs)
+	if err != nil {
+		return err
+	}
+	manifest := registry.Manifest{Name: *name, Algorithm: *algorithm, VocabSize: len(embs), Dimension: dim}
+	if *corpus != "" {
+		manifest.Corpus = *corpus
+		if manifest.CorpusSHA256, err = registry.HashFile(*corpus); err != nil {
+			return err
+		}
+	}
+	reg, err := a.registry()
+	if err != nil {
+		return err
+	}
+	if manifest, err = reg.Register(manifest, *file); err != nil {
+		return err
+	}
+	return a.message("registered %s as %s", *file, manifest.Ref())
+}
+
+func aliasesOf(aliases map[string]int, version int) string {
+	var names []string
+	for alias, v := range aliases {
+		if v == version {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func formatScores(scores map[string]float64) string {
+	parts := make([]string, 0, len(scores))
+	for _, k := range sortedScoreKeys(scores) {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, strconv.FormatFloat(scores[k], 'f', -1, 64)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedScoreKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes value as JSON, or headers and rows as an aligned table
+func (a *app) print(value interface{}, headers []string, rows [][]string) error {
+	if a.output == "json" {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	if len(headers) > 0 {
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(headers, "\t")))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message prints a one line status, or {"status": ...} in JSON mode
+func (a *app) message(format string, args ...interface{}) error {
+	text := fmt.Sprintf(format, args...)
+	return a.print(map[string]string{"status": text}, nil, [][]string{{text}})
+}
+
+// printRows prints query style rows, with columns in a stable order
+func (a *app) printRows(rows []map[string]interface{}) error {
+	keySet := map[string]bool{}
+	for _, row := range rows {
+		for k := range row {
+			keySet[k] = true
+		}
+	}
+	headers := make([]string, 0, len(keySet))
+	for k := range keySet {
+		headers = append(headers, k)
+	}
+	sort.Strings(headers)
+
+	table := make([][]string, len(rows))
+	for i, row := range rows {
+		table[i] = make([]string, len(headers))
+		for j, h := range headers {
+			table[i][j] = formatValue(row[h])
+		}
+	}
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	return a.print(rows, headers, table)
+}
+
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case []float32:
+		if len(value) > 4 {
+			return fmt.Sprintf("[%.4f %.4f %.4f ... (%d)]", value[0], value[1], value[2], len(value))
+		}
+		return fmt.Sprintf("%.4f", value)
+	case float32, float64:
+		return fmt.Sprintf("%.6f", value)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"milvus/vectorize"
+)
+
+const progressBarWidth = 30
+
+// progressBar redraws a single line of training progress on w, usually stderr
+type progressBar struct {
+	w       io.Writer
+	lastLen int
+}
+
+func (pb *progressBar) update(p vectorize.Progress) {
+	done := int(p.Fraction() * progressBarWidth)
+	if done > progressBarWidth {
+		done = progressBarWidth
+	}
+	bar := strings.Repeat("=", done) + strings.Repeat(" ", progressBarWidth-done)
+	if done > 0 && done < progressBarWidth {
+		bar = bar[:done-1] + ">" + bar[done:]
+	}
+
+	line := fmt.Sprintf("epoch %d/%d [%s] %3.0f%%  %s words/s  lr %.5f  ETA %s",
+		p.Epoch, p.Epochs, bar, p.Fraction()*100, humanCount(p.WordsPerSec), p.LearningRate, p.ETA.Round(time.Second))
+	// pad with spaces to overwrite the rest of a longer previous line
+	padding := ""
+	if len(line) < pb.lastLen {
+		padding = strings.Repeat(" ", pb.lastLen-len(line))
+	}
+	pb.lastLen = len(line)
+	fmt.Fprintf(pb.w, "\r%s%s", line, padding)
+}
+
+// finish moves past the progress line so later output starts on a line of its own
+func (pb *progressBar) finish() {
+	if pb.lastLen > 0 {
+		fmt.Fprintln(pb.w)
+	}
+}
+
+func humanCount(n float64) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", n/1e3)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
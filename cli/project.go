@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/projection"
+)
+
+func runProject(a *app, args []string) error {
+	fs := a.flags("project")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file to project")
+	model := fs.String("model", "", "project a registered model instead of -vectors, e.g. words@2")
+	words := fs.String("words", "", "comma separated words to project (default: the first -limit words of the file)")
+	limit := fs.Int("limit", 1000, "number of words to project when -words is not given (0 = all)")
+	method := fs.String("method", "pca", "pca or tsne")
+	dims := fs.Int("dims", 2, "output dimensions, 2 or 3")
+	perplexity := fs.Float64("perplexity", 30, "t-SNE perplexity")
+	iterations := fs.Int("iterations", 1000, "t-SNE iterations")
+	projector := fs.String("projector", "", "write vectors.tsv, metadata.tsv and projector_config.pbtxt for the TensorBoard Embedding Projector to this directory")
+	svg := fs.String("svg", "", "write a 2D scatter plot to this SVG file")
+	page := fs.String("html", "", "write a 2D scatter plot with word search to this HTML file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *method != "pca" && *method != "tsne" {
+		return usagef("invalid -method %q, expected pca or tsne", *method)
+	}
+	if *dims < 1 {
+		return usagef("-dims must be positive")
+	}
+	if (*svg != "" || *page != "") && *dims != 2 {
+		return usagef("-svg and -html plot 2 dimensions, got -dims %d", *dims)
+	}
+
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	embs, err := embeddings.Load(path)
+	if err != nil {
+		return err
+	}
+	embs, err = selectWords(a, embs, splitList(*words), *limit)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if *model != "" {
+		name = *model
+	}
+
+	if *projector != "" {
+		if err := projection.WriteProjector(*projector, name, embs, nil); err != nil {
+			return err
+		}
+		if err := a.message("wrote %d vectors to %s", len(embs), *projector); err != nil {
+			return err
+		}
+		if *svg == "" && *page == "" {
+			return nil
+		}
+	}
+
+	points, err := reduce(a, embs, *method, *dims, *perplexity, *iterations)
+	if err != nil {
+		return err
+	}
+	labels := make([]string, len(embs))
+	for i, e := range embs {
+		labels[i] = e.Word
+	}
+	plot := projection.PlotOptions{Title: fmt.Sprintf("%s (%s)", name, strings.ToUpper(*method))}
+	plots := []struct {
+		file  string
+		write func(w io.Writer, points []projection.Point, opts projection.PlotOptions) error
+	}{{*svg, projection.WriteSVG}, {*page, projection.WriteHTML}}
+	for _, p := range plots {
+		if p.file == "" {
+			continue
+		}
+		err := writeOutput(p.file, func(w io.Writer) error {
+			return p.write(w, projection.Points(labels, points), plot)
+		})
+		if err != nil {
+			return err
+		}
+		if err := a.message("plotted %d words to %s", len(embs), p.file); err != nil {
+			return err
+		}
+	}
+	if *projector != "" || *svg != "" || *page != "" {
+		return nil
+	}
+
+	type projected struct {
+		Word        string    `json:"word"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	values := make([]projected, len(embs))
+	headers := []string{"word"}
+	for d := 0; d < *dims; d++ {
+		if *dims <= 3 {
+			headers = append(headers, []string{"x", "y", "z"}[d])
+		} else {
+			headers = append(headers, fmt.Sprintf("c%d", d+1))
+		}
+	}
+	rows := make([][]string, len(embs))
+	for i, e := range embs {
+		values[i] = projected{Word: e.Word, Coordinates: points[i]}
+		rows[i] = []string{e.Word}
+		for _, x := range points[i] {
+			rows[i] = append(rows[i], strconv.FormatFloat(x, 'f', 4, 64))
+		}
+	}
+	return a.print(values, headers, rows)
+}
+
+// selectWords keeps the listed words in that order, or the first limit embeddings
+func selectWords(a *app, embs []embeddings.Embedding, words []string, limit int) ([]embeddings.Embedding, error) {
+	if len(words) == 0 {
+		if limit > 0 && limit < len(embs) {
+			embs = embs[:limit]
+		}
+		return embs, nil
+	}
+	byWord := make(map[string]embeddings.Embedding, len(embs))
+	for _, e := range embs {
+		byWord[e.Word] = e
+	}
+	var selected []embeddings.Embedding
+	var missing []string
+	for _, word := range words {
+		if e, ok := byWord[word]; ok {
+			selected = append(selected, e)
+		} else {
+			missing = append(missing, word)
+		}
+	}
+	if len(missing) > 0 {
+		a.logger.Warn("words not in the vector file", "words", strings.Join(missing, ","))
+	}
+	if len(selected) == 0 {
+		return nil, usagef("none of -words are in the vector file")
+	}
+	return selected, nil
+}
+
+func reduce(a *app, embs []embeddings.Embedding, method string, dims int, perplexity float64, iterations int) ([][]float64, error) {
+	vectors := make([][]float32, len(embs))
+	for i, e := range embs {
+		vectors[i] = e.Vector
+	}
+	if method == "tsne" {
+		return projection.TSNE(vectors, projection.TSNEOptions{Dims: dims, Perplexity: perplexity, Iterations: iterations}, a.ctx)
+	}
+	result, err := projection.PCA(vectors, dims)
+	if err != nil {
+		return nil, err
+	}
+	explained := make([]string, len(result.ExplainedVariance))
+	for i, v := range result.ExplainedVariance {
+		explained[i] = fmt.Sprintf("%.1f%%", v*100)
+	}
+	a.logger.Info("pca explained variance", "components", strings.Join(explained, " "))
+	return result.Points, nil
+}
+
+func writeOutput(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return errors.FileCreationErr(path, err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return nil
+}
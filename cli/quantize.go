@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"milvus/embeddings"
+	"milvus/quantize"
+)
+
+func runQuantize(a *app, args []string) error {
+	fs := a.flags("quantize")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file to quantize")
+	model := fs.String("model", "", "quantize a registered model instead of -vectors, e.g. words@2")
+	output := fs.String("output", "", "write the quantized vectors to this file (search them with query -quantized)")
+	method := fs.String("method", "sq8", "sq8 (one byte per component) or pq (product quantization)")
+	metric := fs.String("metric", "L2", "L2, IP or COSINE")
+	subspaces := fs.Int("subspaces", 0, "pq: bytes per vector, must divide the dimension (default: largest divisor up to dim/4)")
+	bits := fs.Int("bits", 8, "pq: bits per subspace code, 1 to 8")
+	trainSize := fs.Int("train-size", 65536, "pq: vectors to train the codebooks on (-1 = all)")
+	report := fs.Bool("report", true, "measure reconstruction error and recall against the float32 vectors")
+	k := fs.Int("k", 10, "neighbours per query for the recall")
+	queries := fs.Int("queries", 200, "query words for the recall (0 = all)")
+	seed := fs.Int64("seed", 1, "random seed")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *output == "" && !*report {
+		return usagef("nothing to do: give -output or -report")
+	}
+
+	path, err := a.vectorsPath(*vectors, *model)
+	if err != nil {
+		return err
+	}
+	embs, err := embeddings.Load(path)
+	if err != nil {
+		return err
+	}
+	idx, err := quantize.Train(embs, quantize.Config{
+		Method:    quantize.Method(strings.ToLower(*method)),
+		Metric:    quantize.Metric(strings.ToUpper(*metric)),
+		Subspaces: *subspaces,
+		Bits:      *bits,
+		TrainSize: *trainSize,
+		Seed:      *seed,
+	})
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		if err := idx.Save(*output); err != nil {
+			return err
+		}
+		a.logger.Info("saved quantized vectors", "output", *output, "vectors", idx.Len(), "bytes_per_vector", idx.CodeSize())
+	}
+	if !*report {
+		return a.message("quantized %d vectors to %s", idx.Len(), *output)
+	}
+
+	r, err := quantize.Evaluate(embs, idx, *k, *queries, *seed)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{
+		{"method", fmt.Sprintf("%s (%s)", r.Method, r.Metric)},
+		{"vectors", fmt.Sprintf("%d x %d", r.Vectors, r.Dim)},
+		{"bytes per vector", fmt.Sprintf("%d (float32: %d)", r.CodeSize, 4*r.Dim)},
+		{"size", fmt.Sprintf("%s (float32: %s)", humanBytes(r.CompressedBytes), humanBytes(r.OriginalBytes))},
+		{"compression", fmt.Sprintf("%.1fx", r.Ratio)},
+		{"mse", fmt.Sprintf("%.6g", r.MSE)},
+		{"relative error", fmt.Sprintf("%.2f%%", r.RelativeError*100)},
+		{fmt.Sprintf("recall@%d", r.K), fmt.Sprintf("%.3f (%d queries)", r.Recall, r.Queries)},
+	}
+	return a.print(r, []string{"metric", "value"}, rows)
+}
+
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
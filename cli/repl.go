@@ -0,0 +1,513 @@
+package cli
+
+import (
+	"bufio"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"milvus/embeddings"
+	"milvus/vectordb"
+
+	"github.com/chzyer/readline"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+var errQuit = stdErrors.New("quit")
+
+// shell is the state of an interactive session
+type shell struct {
+	a *app
+
+	table      *embeddings.Table
+	vocab      []string // sorted copy of the table's words, for completion
+	k          int
+	collection string
+	fields     []string
+	limit      int64
+
+	collectionNames []string // cached for completion
+}
+
+type shellCommand struct {
+	usage   string
+	summary string
+	run     func(sh *shell, args []string) error
+}
+
+var shellCommands map[string]shellCommand
+
+func init() {
+	// assigned in init because help refers back to the map
+	shellCommands = map[string]shellCommand{
+		"load":        {usage: "load <vector file>", summary: "load word vectors (any supported format)", run: (*shell).load},
+		"nn":          {usage: "nn <word> [k]", summary: "nearest neighbours of a word, typing just a word does the same", run: (*shell).neighbors},
+		"analogy":     {usage: "analogy <a> <b> <c> [k]", summary: "a is to b as c is to ?", run: (*shell).analogy},
+		"sim":         {usage: "sim <word> <word>", summary: "cosine similarity of two words", run: (*shell).similarity},
+		"embed":       {usage: "embed <text>", summary: "mean vector of a text and the words with no vector", run: (*shell).embed},
+		"k":           {usage: "k <n>", summary: "number of results for nn, analogy and search", run: (*shell).setK},
+		"collections": {usage: "collections", summary: "list Milvus collections", run: (*shell).collections},
+		"use":         {usage: "use <collection>", summary: "switch the collection for query and search", run: (*shell).use},
+		"describe":    {usage: "describe [collection]", summary: "fields, indexes and row count", run: (*shell).describe},
+		"fields":      {usage: "fields [f1,f2,...]", summary: "output fields for query and search, no argument clears them", run: (*shell).setFields},
+		"limit":       {usage: "limit <n>", summary: "maximum rows returned by query", run: (*shell).setLimit},
+		"query":       {usage: "query <expr>", summary: "filter query against the current collection", run: (*shell).query},
+		"search":      {usage: "search <word | text | v1,v2,...>", summary: "vector search in the current collection", run: (*shell).search},
+		"help":        {usage: "help", summary: "this list", run: (*shell).help},
+		"exit":        {usage: "exit", summary: "leave the shell (Ctrl+D works too)", run: func(*shell, []string) error { return errQuit }},
+	}
+}
+
+func runRepl(a *app, args []string) error {
+	fs := a.flags("repl")
+	vectors := fs.String("vectors", "", "vector file to load on start")
+	model := fs.String("model", "", "registered model to load on start instead, e.g. words@2")
+	collection := fs.String("collection", "", "collection to use on start")
+	historyFile := fs.String("history", defaultHistoryFile(), "history file, empty to keep no history")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	sh := &shell{a: a, k: 10, limit: 20}
+	if *model != "" {
+		var err error
+		if *vectors, err = a.vectorsPath(*vectors, *model); err != nil {
+			return err
+		}
+	}
+	if *vectors != "" {
+		if err := sh.load([]string{*vectors}); err != nil {
+			return err
+		}
+	}
+	if *collection != "" {
+		if err := sh.use([]string{*collection}); err != nil {
+			return err
+		}
+	}
+
+	// Scripts piped into the shell don't get line editing
+	if f, ok := a.stdin.(*os.File); !ok || !readline.IsTerminal(int(f.Fd())) {
+		return sh.runScript(a.stdin)
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          sh.prompt(),
+		HistoryFile:     *historyFile,
+		AutoComplete:    sh,
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+		Stdout:          a.stdout,
+		Stderr:          a.stderr,
+	})
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	fmt.Fprintln(a.stdout, "Type a word for its neighbours, help for the commands, exit to leave.")
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := sh.exec(line); err != nil {
+			if err == errQuit {
+				return nil
+			}
+			fmt.Fprintf(a.stderr, "error: %s\n", err)
+		}
+		rl.SetPrompt(sh.prompt())
+	}
+}
+
+// runScript executes one command per line, stopping at the first error
+func (sh *shell) runScript(r io.Reader) error {
+	if r == nil {
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := sh.exec(scanner.Text()); err != nil {
+			if err == errQuit {
+				return nil
+			}
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".vectorize_history")
+}
+
+func (sh *shell) prompt() string {
+	if sh.collection != "" {
+		return fmt.Sprintf("vectorize [%s]> ", sh.collection)
+	}
+	return "vectorize> "
+}
+
+// exec runs one line. A line that isn't a command is looked up as a word.
+func (sh *shell) exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+	cmd, ok := shellCommands[fields[0]]
+	if !ok {
+		if len(fields) == 1 && sh.table != nil {
+			return sh.neighbors(fields)
+		}
+		return fmt.Errorf("unknown command %q, try help", fields[0])
+	}
+	return cmd.run(sh, fields[1:])
+}
+
+func (sh *shell) help(args []string) error {
+	names := make([]string, 0, len(shellCommands))
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		rows[i] = []string{shellCommands[name].usage, shellCommands[name].summary}
+	}
+	return sh.a.print(rows, []string{"command", "description"}, rows)
+}
+
+func (sh *shell) requireTable() error {
+	if sh.table == nil {
+		return fmt.Errorf("no vectors loaded, use load <vector file>")
+	}
+	return nil
+}
+
+func (sh *shell) requireCollection() error {
+	if sh.collection == "" {
+		return fmt.Errorf("no collection selected, use use <collection>")
+	}
+	return nil
+}
+
+func (sh *shell) load(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: load <vector file>")
+	}
+	table, err := embeddings.LoadTable(args[0])
+	if err != nil {
+		return err
+	}
+	sh.table = table
+	sh.vocab = append([]string(nil), table.Words()...)
+	sort.Strings(sh.vocab)
+	return sh.a.message("loaded %d words of dimension %d from %s", table.Len(), table.Dim(), args[0])
+}
+
+// optionalK parses an optional trailing k argument
+func (sh *shell) optionalK(args []string, fixed int) (int, error) {
+	switch len(args) {
+	case fixed:
+		return sh.k, nil
+	case fixed + 1:
+		k, err := strconv.Atoi(args[fixed])
+		if err != nil || k <= 0 {
+			return 0, fmt.Errorf("k must be a positive integer")
+		}
+		return k, nil
+	}
+	return 0, fmt.Errorf("expected %d arguments and an optional k", fixed)
+}
+
+func (sh *shell) printNeighbors(neighbors []embeddings.Neighbor) error {
+	rows := make([][]string, len(neighbors))
+	for i, n := range neighbors {
+		rows[i] = []string{strconv.Itoa(i + 1), n.Word, fmt.Sprintf("%.6f", n.Similarity)}
+	}
+	return sh.a.print(neighbors, []string{"rank", "word", "similarity"}, rows)
+}
+
+func (sh *shell) neighbors(args []string) error {
+	if err := sh.requireTable(); err != nil {
+		return err
+	}
+	k, err := sh.optionalK(args, 1)
+	if err != nil {
+		return err
+	}
+	neighbors, err := sh.table.NearestWords(args[0], k)
+	if err != nil {
+		return err
+	}
+	return sh.printNeighbors(neighbors)
+}
+
+func (sh *shell) analogy(args []string) error {
+	if err := sh.requireTable(); err != nil {
+		return err
+	}
+	k, err := sh.optionalK(args, 3)
+	if err != nil {
+		return err
+	}
+	neighbors, err := sh.table.Analogy(args[0], args[1], args[2], k)
+	if err != nil {
+		return err
+	}
+	return sh.printNeighbors(neighbors)
+}
+
+func (sh *shell) similarity(args []string) error {
+	if err := sh.requireTable(); err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: sim <word> <word>")
+	}
+	similarity, err := sh.table.Similarity(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return sh.a.print(map[string]interface{}{"a": args[0], "b": args[1], "similarity": similarity},
+		[]string{"a", "b", "similarity"}, [][]string{{args[0], args[1], fmt.Sprintf("%.6f", similarity)}})
+}
+
+func (sh *shell) embed(args []string) error {
+	if err := sh.requireTable(); err != nil {
+		return err
+	}
+	text := strings.Join(args, " ")
+	vector, oov, err := sh.table.Embed(text)
+	if err != nil {
+		return err
+	}
+	return sh.a.print(map[string]interface{}{"text": text, "vector": vector, "oov": oov},
+		[]string{"text", "vector", "oov"}, [][]string{{text, formatValue(vector), strings.Join(oov, ",")}})
+}
+
+func (sh *shell) setK(args []string) error {
+	k, err := sh.optionalK(args, 0)
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("usage: k <n>")
+	}
+	sh.k = k
+	return nil
+}
+
+func (sh *shell) setLimit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: limit <n>")
+	}
+	limit, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || limit <= 0 {
+		return fmt.Errorf("limit must be a positive integer")
+	}
+	sh.limit = limit
+	return nil
+}
+
+func (sh *shell) setFields(args []string) error {
+	sh.fields = splitList(strings.Join(args, ","))
+	return nil
+}
+
+func (sh *shell) collections(args []string) error {
+	milvusClient, err := sh.a.client()
+	if err != nil {
+		return err
+	}
+	collections, err := vectordb.ListCollections(milvusClient, sh.a.ctx)
+	if err != nil {
+		return err
+	}
+	sh.collectionNames = sh.collectionNames[:0]
+	rows := make([][]string, len(collections))
+	for i, c := range collections {
+		sh.collectionNames = append(sh.collectionNames, c.Name)
+		rows[i] = []string{c.Name, strconv.FormatBool(c.Loaded)}
+	}
+	sort.Strings(sh.collectionNames)
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return sh.a.print(sh.collectionNames, []string{"name", "loaded"}, rows)
+}
+
+func (sh *shell) use(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: use <collection>")
+	}
+	milvusClient, err := sh.a.client()
+	if err != nil {
+		return err
+	}
+	exists, err := milvusClient.HasCollection(sh.a.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("collection %s does not exist", args[0])
+	}
+	sh.collection, sh.fields = args[0], nil
+	return nil
+}
+
+func (sh *shell) describe(args []string) error {
+	name := sh.collection
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" {
+		return fmt.Errorf("usage: describe <collection>")
+	}
+	return collectionsDescribe(sh.a, []string{name})
+}
+
+func (sh *shell) query(args []string) error {
+	if err := sh.requireCollection(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: query <expr>")
+	}
+	milvusClient, err := sh.a.client()
+	if err != nil {
+		return err
+	}
+	columns, err := vectordb.Query(milvusClient, vectordb.QueryParams{
+		CollectionName: sh.collection,
+		Expr:           strings.Join(args, " "),
+		OutputFields:   sh.fields,
+		Limit:          sh.limit,
+	}, sh.a.ctx)
+	if err != nil {
+		return err
+	}
+	return sh.a.printRows(vectordb.Rows(columns))
+}
+
+// searchVector turns search arguments into a vector: comma separated floats, a word, or a text
+func (sh *shell) searchVector(args []string) ([]float32, error) {
+	if len(args) == 1 && strings.Contains(args[0], ",") {
+		return queryVector(args[0], "", "")
+	}
+	if err := sh.requireTable(); err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		if vector, ok := sh.table.Lookup(args[0]); ok {
+			return vector, nil
+		}
+	}
+	vector, _, err := sh.table.Embed(strings.Join(args, " "))
+	return vector, err
+}
+
+func (sh *shell) search(args []string) error {
+	if err := sh.requireCollection(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: search <word | text | v1,v2,...>")
+	}
+	vector, err := sh.searchVector(args)
+	if err != nil {
+		return err
+	}
+	milvusClient, err := sh.a.client()
+	if err != nil {
+		return err
+	}
+	info, err := vectordb.DescribeCollection(milvusClient, sh.collection, sh.a.ctx)
+	if err != nil {
+		return err
+	}
+	field, ok := info.VectorField()
+	if !ok {
+		return fmt.Errorf("collection %s has no float vector field", sh.collection)
+	}
+
+	results, err := vectordb.Search(milvusClient, vectordb.SearchParams{
+		CollectionName: sh.collection,
+		OutputFields:   sh.fields,
+		VectorField:    field.Name,
+		Vectors:        []entity.Vector{entity.FloatVector(vector)},
+		TopK:           sh.k,
+	}, sh.a.ctx)
+	if err != nil {
+		return err
+	}
+	hits := []vectordb.Hit{}
+	if len(results) > 0 {
+		hits = vectordb.Hits(results[0])
+	}
+	rows := make([][]string, len(hits))
+	for i, h := range hits {
+		rows[i] = []string{strconv.Itoa(i + 1), fmt.Sprint(h.ID), fmt.Sprintf("%.6f", h.Score)}
+		for _, f := range sh.fields {
+			rows[i] = append(rows[i], formatValue(h.Fields[f]))
+		}
+	}
+	return sh.a.print(hits, append([]string{"rank", "id", "score"}, sh.fields...), rows)
+}
+
+// Do implements readline.AutoCompleter: commands first, then words or collection names
+func (sh *shell) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	fields := strings.Fields(text)
+	current := ""
+	if len(fields) > 0 && !strings.HasSuffix(text, " ") {
+		current = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+
+	var candidates []string
+	switch {
+	case len(fields) == 0:
+		for name := range shellCommands {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+		candidates = append(candidates, sh.completeWords(current)...)
+	case fields[0] == "use" || fields[0] == "describe":
+		candidates = sh.collectionNames
+	case fields[0] == "nn" || fields[0] == "analogy" || fields[0] == "sim" || fields[0] == "embed" || fields[0] == "search":
+		candidates = sh.completeWords(current)
+	}
+
+	var completions [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) && candidate != current {
+			completions = append(completions, []rune(candidate[len(current):]+" "))
+		}
+	}
+	return completions, len([]rune(current))
+}
+
+// completeWords returns up to 50 vocabulary words starting with prefix
+func (sh *shell) completeWords(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	start := sort.SearchStrings(sh.vocab, prefix)
+	var words []string
+	for i := start; i < len(sh.vocab) && len(words) < 50 && strings.HasPrefix(sh.vocab[i], prefix); i++ {
+		words = append(words, sh.vocab[i])
+	}
+	return words
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+
+	"milvus/embeddings"
+	"milvus/grpcserver"
+	"milvus/server"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+)
+
+func runServe(a *app, args []string) error {
+	defaults := server.DefaultConfig()
+	fs := a.flags("serve")
+	listen := fs.String("listen", defaults.Addr, "address to serve the REST API on")
+	grpcListen := fs.String("grpc", "", "also serve the gRPC API on this address, e.g. localhost:9090")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file for /v1/embed, /v1/neighbors and word/text searches, empty to disable")
+	model := fs.String("model", "", "serve a registered model instead of -vectors, e.g. words@production")
+	milvus := fs.Bool("milvus", true, "connect to Milvus and serve the collection endpoints")
+	requestTimeout := fs.Duration("request-timeout", defaults.RequestTimeout, "deadline for each request, 0 = none")
+	shutdownTimeout := fs.Duration("shutdown-timeout", defaults.ShutdownTimeout, "how long in-flight requests get to finish on shutdown")
+	maxBody := fs.Int64("max-body", defaults.MaxBodyBytes, "maximum request body size in bytes")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if !*milvus && *vectors == "" {
+		return usagef("nothing to serve, set -vectors or -milvus")
+	}
+
+	if *model != "" {
+		var err error
+		if *vectors, err = a.vectorsPath(*vectors, *model); err != nil {
+			return err
+		}
+	}
+	var table *embeddings.Table
+	if *vectors != "" {
+		var err error
+		if table, err = embeddings.LoadTable(*vectors); err != nil {
+			return err
+		}
+	}
+	var milvusClient client.Client
+	if *milvus {
+		var err error
+		if milvusClient, err = a.client(); err != nil {
+			return err
+		}
+	}
+
+	// a.ctx is cancelled on Ctrl+C, which starts the graceful shutdown of both servers
+	rest := server.New(milvusClient, table, server.Config{
+		Addr:            *listen,
+		RequestTimeout:  *requestTimeout,
+		ShutdownTimeout: *shutdownTimeout,
+		MaxBodyBytes:    *maxBody,
+		Logger:          a.logger,
+	})
+	if *grpcListen == "" {
+		return rest.ListenAndServe(a.ctx)
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	errs := make(chan error, 2)
+	rpc := grpcserver.New(milvusClient, table).WithLogger(a.logger)
+	go func() { errs <- rest.ListenAndServe(ctx) }()
+	go func() { errs <- rpc.ListenAndServe(*grpcListen, ctx) }()
+
+	// whichever stops first stops the other
+	err := <-errs
+	cancel()
+	if err2 := <-errs; err == nil {
+		err = err2
+	}
+	return err
+}
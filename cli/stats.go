@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"milvus/vectorize"
+)
+
+func runStats(a *app, args []string) error {
+	fs := a.flags("stats")
+	input := fs.String("input", "string-vectors/input", "UTF-8 corpus to analyze")
+	vectors := fs.String("vectors", "", "report the out of vocabulary rate against this vector file")
+	model := fs.String("model", "", "report the out of vocabulary rate against a registered model")
+	top := fs.Int("top", 20, "number of most frequent (and missing) words to list")
+	minCounts := fs.String("min-counts", "1,2,5,10,20,50,100", "comma separated min-count thresholds")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	opts := vectorize.StatsOptions{Top: *top}
+	for _, item := range splitList(*minCounts) {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 1 {
+			return usagef("invalid -min-counts value %q, expected positive integers", item)
+		}
+		opts.MinCounts = append(opts.MinCounts, n)
+	}
+	var err error
+	if opts.Vectors, err = a.vectorsPath(*vectors, *model); err != nil {
+		return err
+	}
+
+	stats, err := vectorize.AnalyzeCorpus(*input, opts, a.ctx)
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.print(stats, nil, nil)
+	}
+
+	summary := [][]string{
+		{"tokens", strconv.FormatInt(stats.Tokens, 10)},
+		{"vocabulary", strconv.Itoa(stats.Vocabulary)},
+		{"sentences", strconv.FormatInt(stats.Sentences, 10)},
+		{"avg sentence length", fmt.Sprintf("%.1f", stats.AvgSentenceLength)},
+		{"zipf exponent", fmt.Sprintf("%.3f", stats.ZipfExponent)},
+	}
+	if stats.OOV != nil {
+		summary = append(summary,
+			[]string{"oov words", fmt.Sprintf("%d of %d (%.2f%%)", stats.OOV.Types, stats.Vocabulary, stats.OOV.TypeRate*100)},
+			[]string{"oov tokens", fmt.Sprintf("%d of %d (%.2f%%)", stats.OOV.Tokens, stats.Tokens, stats.OOV.TokenRate*100)},
+		)
+	}
+	sections := []struct {
+		headers []string
+		rows    [][]string
+	}{
+		{headers: []string{"corpus", stats.Path}, rows: summary},
+		{headers: []string{"min count", "vocabulary", "coverage"}, rows: minCountRows(stats.MinCounts)},
+		{headers: []string{"rank", "word", "count", "frequency"}, rows: wordCountRows(stats.TopWords)},
+		{headers: []string{"zipf rank", "word", "count", "frequency"}, rows: wordCountRows(stats.Zipf)},
+	}
+	if stats.OOV != nil && len(stats.OOV.TopMissing) > 0 {
+		sections = append(sections, struct {
+			headers []string
+			rows    [][]string
+		}{headers: []string{"rank", "missing word", "count", "frequency"}, rows: wordCountRows(stats.OOV.TopMissing)})
+	}
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(a.stdout)
+		}
+		if err := a.print(nil, section.headers, section.rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func minCountRows(stats []vectorize.MinCountStats) [][]string {
+	rows := make([][]string, len(stats))
+	for i, s := range stats {
+		rows[i] = []string{strconv.Itoa(s.MinCount), strconv.Itoa(s.Vocabulary), fmt.Sprintf("%.2f%%", s.Coverage*100)}
+	}
+	return rows
+}
+
+func wordCountRows(words []vectorize.WordCount) [][]string {
+	rows := make([][]string, len(words))
+	for i, w := range words {
+		rows[i] = []string{strconv.Itoa(w.Rank), w.Word, strconv.FormatInt(w.Count, 10), fmt.Sprintf("%.6f", w.Frequency)}
+	}
+	return rows
+}
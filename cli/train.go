@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+
+	"milvus/hnsw"
+	"milvus/quantize"
+	"milvus/vectordb"
+	"milvus/vectorize"
+)
+
+func runTrain(a *app, args []string) error {
+	fs := a.flags("train")
+	input := fs.String("input", "string-vectors/input", "UTF-8 corpus to train on")
+	output := fs.String("output", "string-vectors/word_vector.txt", "where to write the word vectors")
+	progress := fs.Bool("progress", true, "draw a progress bar on stderr while training")
+	checkpoint := fs.String("checkpoint", "", "save the training state to this file after every epoch")
+	checkpointEvery := fs.Duration("checkpoint-every", 0, "also checkpoint this often within an epoch, e.g. 5m")
+	resume := fs.Bool("resume", false, "resume from -checkpoint if it exists")
+	from := fs.String("from", "", "continue training this checkpoint or vector file on -input, adding new words")
+	register := fs.String("register", "", "register the vectors as a new version of this model instead of writing -output")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *resume && *checkpoint == "" {
+		return usagef("-resume needs -checkpoint")
+	}
+
+	// Ctrl-C cancels a.ctx, which stops training and leaves -output as it was; with -checkpoint
+	// the run can be picked up again with -resume
+	opts := vectorize.TrainOptions{CheckpointPath: *checkpoint, CheckpointInterval: *checkpointEvery, Resume: *resume, From: *from}
+	bar := &progressBar{w: a.stderr}
+	if *progress {
+		opts.Progress = bar.update
+	}
+	if *register != "" {
+		reg, err := a.registry()
+		if err != nil {
+			return err
+		}
+		manifest, err := vectorize.TrainModel(*input, reg, *register, opts, a.ctx)
+		bar.finish()
+		if err != nil {
+			return err
+		}
+		return a.message("trained %s into %s", *input, manifest.Ref())
+	}
+	err := vectorize.TrainWithOptions(*input, *output, opts, a.ctx)
+	bar.finish()
+	if err != nil {
+		return err
+	}
+	return a.message("trained %s into %s", *input, *output)
+}
+
+func runQuery(a *app, args []string) error {
+	fs := a.flags("query")
+	vectors := fs.String("vectors", "string-vectors/word_vector.txt", "vector file to search")
+	model := fs.String("model", "", "search a registered model instead of -vectors, e.g. words@2")
+	index := fs.String("index", "", "search a saved HNSW index instead of the vector file")
+	quantized := fs.String("quantized", "", "search a file written by quantize instead of the vector file")
+	k := fs.Int("k", 10, "number of similar words")
+	collection := fs.String("collection", "", "run a Milvus filter query against this collection instead")
+	expr := fs.String("expr", "", "Milvus boolean filter expression, e.g. \"word in ['cat', 'dog']\"")
+	fields := fs.String("fields", "", "comma separated output fields for Milvus queries")
+	limit := fs.Int64("limit", 0, "maximum number of Milvus rows (0 = no limit)")
+	offset := fs.Int64("offset", 0, "rows to skip, used with -limit")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *collection != "" {
+		if *expr == "" {
+			return usagef("-expr is required with -collection")
+		}
+		milvusClient, err := a.client()
+		if err != nil {
+			return err
+		}
+		columns, err := vectordb.Query(milvusClient, vectordb.QueryParams{
+			CollectionName: *collection,
+			Expr:           *expr,
+			OutputFields:   splitList(*fields),
+			Limit:          *limit,
+			Offset:         *offset,
+		}, a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.printRows(vectordb.Rows(columns))
+	}
+
+	if fs.NArg() != 1 {
+		return usagef("expected exactly one word")
+	}
+	word := fs.Arg(0)
+
+	// L2 indexes score by squared distance (lower is closer), so their results are reported as
+	// distances rather than similarities
+	var neighbors []vectorize.Neighbor
+	var distances []wordDistance
+	if *index != "" {
+		idx, err := hnsw.Load(*index)
+		if err != nil {
+			return err
+		}
+		results, err := idx.SearchWord(word, *k)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if idx.Config().Metric == hnsw.L2 {
+				distances = append(distances, wordDistance{Word: r.Word, Distance: float64(r.Score)})
+			} else {
+				neighbors = append(neighbors, vectorize.Neighbor{Word: r.Word, Similarity: float64(r.Score)})
+			}
+		}
+	} else if *quantized != "" {
+		idx, err := quantize.Load(*quantized)
+		if err != nil {
+			return err
+		}
+		results, err := idx.SearchWord(word, *k)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if idx.Config().Metric == quantize.L2 {
+				distances = append(distances, wordDistance{Word: r.Word, Distance: float64(r.Score)})
+			} else {
+				neighbors = append(neighbors, vectorize.Neighbor{Word: r.Word, Similarity: float64(r.Score)})
+			}
+		}
+	} else {
+		path, err := a.vectorsPath(*vectors, *model)
+		if err != nil {
+			return err
+		}
+		if neighbors, err = vectorize.SimilarWords(word, path, *k); err != nil {
+			return err
+		}
+	}
+
+	if distances != nil {
+		rows := make([][]string, len(distances))
+		for i, d := range distances {
+			rows[i] = []string{fmt.Sprint(i + 1), d.Word, fmt.Sprintf("%.6f", d.Distance)}
+		}
+		return a.print(distances, []string{"rank", "word", "distance"}, rows)
+	}
+	rows := make([][]string, len(neighbors))
+	for i, n := range neighbors {
+		rows[i] = []string{fmt.Sprint(i + 1), n.Word, fmt.Sprintf("%.6f", n.Similarity)}
+	}
+	if neighbors == nil {
+		neighbors = []vectorize.Neighbor{}
+	}
+	return a.print(neighbors, []string{"rank", "word", "similarity"}, rows)
+}
+
+// wordDistance is a result of query against an L2 index, Distance is the squared L2 distance
+type wordDistance struct {
+	Word     string  `json:"word"`
+	Distance float64 `json:"distance"`
+}
@@ -0,0 +1,340 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+/*
+	K-means clustering of embeddings
+
+	- Initialization is k-means++ (Arthur and Vassilvitskii, 2007): each new centroid is drawn with
+	  probability proportional to its squared distance from the closest centroid so far
+	- Options.BatchSize = 0 runs Lloyd's algorithm over all vectors every iteration, a positive
+	  BatchSize runs mini-batch k-means (Sculley, 2010), which scales to millions of vectors
+	- Cosine clusters unit length vectors around unit length centroids (spherical k-means)
+*/
+
+type Metric string
+
+const (
+	L2     Metric = "L2"
+	Cosine Metric = "COSINE"
+)
+
+type Options struct {
+	K             int
+	Metric        Metric  // L2 by default
+	MaxIterations int     // Lloyd iterations or mini-batches, 100 by default
+	Tolerance     float64 // stop once the centroids move less than this (relative to their norm), 1e-4 by default
+	BatchSize     int     // vectors per mini-batch, 0 for full batch
+	Seed          int64
+}
+
+// Result is a fitted clustering. Assignments has the cluster of every input vector, in order.
+type Result struct {
+	K           int         `json:"k"`
+	Metric      Metric      `json:"metric"`
+	Assignments []int       `json:"assignments"`
+	Centroids   [][]float32 `json:"centroids"`
+	Sizes       []int       `json:"sizes"`
+	// Inertia is the sum over all vectors of the squared L2 distance (or the cosine distance for
+	// Cosine) to their centroid
+	Inertia    float64 `json:"inertia"`
+	Iterations int     `json:"iterations"`
+	Converged  bool    `json:"converged"`
+}
+
+// KMeans partitions vectors into opts.K clusters. If ctx is cancelled KMeans returns ctx.Err().
+func KMeans(vectors [][]float32, opts Options, ctx context.Context) (*Result, error) {
+	if opts.Metric == "" {
+		opts.Metric = L2
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = 100
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 1e-4
+	}
+	if opts.Metric != L2 && opts.Metric != Cosine {
+		return nil, errors.Invalid("metric", "unsupported metric %q, expected L2 or COSINE", opts.Metric)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.Invalid("vectors", "no vectors to cluster")
+	}
+	if opts.K < 1 || opts.K > len(vectors) {
+		return nil, errors.Invalid("k", "k must be between 1 and the number of vectors (%d), got %d", len(vectors), opts.K)
+	}
+	dim := len(vectors[0])
+	for _, v := range vectors {
+		if len(v) != dim {
+			return nil, errors.DimensionMismatch("vector", len(v), dim)
+		}
+	}
+	if opts.Metric == Cosine {
+		vectors = normalized(vectors)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	centroids, err := kmeansPlusPlus(vectors, opts.K, rng, ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{K: opts.K, Metric: opts.Metric, Assignments: make([]int, len(vectors))}
+	if opts.BatchSize > 0 && opts.BatchSize < len(vectors) {
+		err = miniBatch(vectors, centroids, opts, rng, result, ctx)
+	} else {
+		err = lloyd(vectors, centroids, opts, result, ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Centroids = make([][]float32, opts.K)
+	for c, centroid := range centroids {
+		result.Centroids[c] = make([]float32, dim)
+		for j, x := range centroid {
+			result.Centroids[c][j] = float32(x)
+		}
+	}
+	result.Sizes = make([]int, opts.K)
+	result.Inertia = 0
+	for i, v := range vectors {
+		c, d := nearest(v, centroids, opts.Metric)
+		result.Assignments[i] = c
+		result.Sizes[c]++
+		result.Inertia += d
+	}
+	return result, nil
+}
+
+// Representatives returns, for every cluster, the indexes of up to n of its vectors closest to
+// the centroid, closest first. vectors are the ones the clustering was fitted on.
+func (r *Result) Representatives(vectors [][]float32, n int) [][]int {
+	if r.Metric == Cosine {
+		vectors = normalized(vectors)
+	}
+	centroids := make([][]float64, len(r.Centroids))
+	for c, centroid := range r.Centroids {
+		centroids[c] = toFloat64(centroid)
+	}
+	members := make([][]int, r.K)
+	distances := make([]float64, len(vectors))
+	for i, v := range vectors {
+		c := r.Assignments[i]
+		if r.Metric == Cosine {
+			distances[i] = 1 - vecmath.DotCentroid(v, centroids[c])
+		} else {
+			distances[i] = vecmath.SquaredL2Centroid(v, centroids[c])
+		}
+		members[c] = append(members[c], i)
+	}
+	for c := range members {
+		sort.SliceStable(members[c], func(a, b int) bool {
+			return distances[members[c][a]] < distances[members[c][b]]
+		})
+		if len(members[c]) > n {
+			members[c] = members[c][:n]
+		}
+	}
+	return members
+}
+
+func kmeansPlusPlus(vectors [][]float32, k int, rng *rand.Rand, ctx context.Context) ([][]float64, error) {
+	centroids := [][]float64{toFloat64(vectors[rng.Intn(len(vectors))])}
+	closest := make([]float64, len(vectors))
+	for i, v := range vectors {
+		closest[i] = vecmath.SquaredL2Centroid(v, centroids[0])
+	}
+	for len(centroids) < k {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var total float64
+		for _, d := range closest {
+			total += d
+		}
+		next := 0
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range closest {
+				if target -= d; target <= 0 {
+					next = i
+					break
+				}
+			}
+		} else {
+			// fewer distinct vectors than k, duplicate centroids end up with empty clusters
+			next = rng.Intn(len(vectors))
+		}
+		centroid := toFloat64(vectors[next])
+		centroids = append(centroids, centroid)
+		for i, v := range vectors {
+			closest[i] = math.Min(closest[i], vecmath.SquaredL2Centroid(v, centroid))
+		}
+	}
+	return centroids, nil
+}
+
+// lloyd alternates assigning every vector to its nearest centroid and moving the centroids to
+// the mean of their vectors, until no assignment changes or the centroids stop moving
+func lloyd(vectors [][]float32, centroids [][]float64, opts Options, result *Result, ctx context.Context) error {
+	dim := len(centroids[0])
+	for i := range result.Assignments {
+		result.Assignments[i] = -1
+	}
+	for result.Iterations < opts.MaxIterations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.Iterations++
+
+		changed := 0
+		distances := make([]float64, len(vectors))
+		for i, v := range vectors {
+			c, d := nearest(v, centroids, opts.Metric)
+			if c != result.Assignments[i] {
+				result.Assignments[i] = c
+				changed++
+			}
+			distances[i] = d
+		}
+
+		sums := make([][]float64, len(centroids))
+		counts := make([]int, len(centroids))
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, v := range vectors {
+			c := result.Assignments[i]
+			counts[c]++
+			for j, x := range v {
+				sums[c][j] += float64(x)
+			}
+		}
+		var shift float64
+		for c := range centroids {
+			if counts[c] == 0 {
+				// reseed an empty cluster with the vector furthest from its centroid
+				furthest := 0
+				for i, d := range distances {
+					if d > distances[furthest] {
+						furthest = i
+					}
+				}
+				distances[furthest] = 0
+				sums[c], counts[c] = toFloat64(vectors[furthest]), 1
+			}
+			for j := range sums[c] {
+				sums[c][j] /= float64(counts[c])
+			}
+			if opts.Metric == Cosine {
+				vecmath.Normalize64(sums[c])
+			}
+			shift = math.Max(shift, relativeShift(centroids[c], sums[c]))
+			centroids[c] = sums[c]
+		}
+		if changed == 0 || shift < opts.Tolerance {
+			result.Converged = true
+			return nil
+		}
+	}
+	return nil
+}
+
+// miniBatch moves each centroid towards the vectors of random batches assigned to it with a
+// learning rate of 1/(vectors seen by that centroid)
+func miniBatch(vectors [][]float32, centroids [][]float64, opts Options, rng *rand.Rand, result *Result, ctx context.Context) error {
+	seen := make([]int, len(centroids))
+	batch := make([]int, opts.BatchSize)
+	assigned := make([]int, opts.BatchSize)
+	previous := make([][]float64, len(centroids))
+	for result.Iterations < opts.MaxIterations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.Iterations++
+
+		for c, centroid := range centroids {
+			previous[c] = append(previous[c][:0], centroid...)
+		}
+		for b := range batch {
+			batch[b] = rng.Intn(len(vectors))
+			assigned[b], _ = nearest(vectors[batch[b]], centroids, opts.Metric)
+		}
+		for b, i := range batch {
+			c := assigned[b]
+			seen[c]++
+			eta := 1 / float64(seen[c])
+			for j, x := range vectors[i] {
+				centroids[c][j] = (1-eta)*centroids[c][j] + eta*float64(x)
+			}
+		}
+		var shift float64
+		for c := range centroids {
+			if opts.Metric == Cosine {
+				vecmath.Normalize64(centroids[c])
+			}
+			shift = math.Max(shift, relativeShift(previous[c], centroids[c]))
+		}
+		// the first batches move every centroid a lot, don't mistake a quiet batch for convergence
+		if result.Iterations > 10 && shift < opts.Tolerance {
+			result.Converged = true
+			return nil
+		}
+	}
+	return nil
+}
+
+// nearest returns the closest centroid and the distance Inertia sums: squared L2, or 1 - cosine
+// for unit length vectors
+func nearest(v []float32, centroids [][]float64, metric Metric) (int, float64) {
+	best, bestDistance := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		var d float64
+		if metric == Cosine {
+			d = 1 - vecmath.DotCentroid(v, centroid)
+		} else {
+			d = vecmath.SquaredL2Centroid(v, centroid)
+		}
+		if d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best, bestDistance
+}
+
+func relativeShift(from []float64, to []float64) float64 {
+	var moved, norm float64
+	for j := range from {
+		d := to[j] - from[j]
+		moved += d * d
+		norm += from[j] * from[j]
+	}
+	if norm == 0 {
+		return math.Sqrt(moved)
+	}
+	return math.Sqrt(moved / norm)
+}
+
+func toFloat64(v []float32) []float64 {
+	out := make([]float64, len(v))
+	for j, x := range v {
+		out[j] = float64(x)
+	}
+	return out
+}
+
+// normalized returns unit length copies of vectors; zero vectors stay zero
+func normalized(vectors [][]float32) [][]float32 {
+	out := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		out[i] = vecmath.Normalized(v)
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"context"
+	stdErrors "errors"
+	"math/rand"
+	"testing"
+
+	"milvus/errors"
+)
+
+// blobs returns n vectors around each of the centers, in order
+func blobs(centers [][]float32, n int, spread float64, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	var vectors [][]float32
+	for _, center := range centers {
+		for i := 0; i < n; i++ {
+			v := make([]float32, len(center))
+			for j, x := range center {
+				v[j] = x + float32(rng.NormFloat64()*spread)
+			}
+			vectors = append(vectors, v)
+		}
+	}
+	return vectors
+}
+
+// sameClusters reports whether every blob of n vectors ended up in its own cluster
+func sameClusters(assignments []int, blobs int, n int) bool {
+	seen := map[int]bool{}
+	for b := 0; b < blobs; b++ {
+		c := assignments[b*n]
+		if seen[c] {
+			return false
+		}
+		seen[c] = true
+		for i := b * n; i < (b+1)*n; i++ {
+			if assignments[i] != c {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestKMeans(t *testing.T) {
+	centers := [][]float32{{10, 0, 0}, {0, 10, 0}, {0, 0, 10}, {-10, -10, -10}}
+	vectors := blobs(centers, 50, 0.5, 1)
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "Lloyd L2", opts: Options{K: 4}},
+		{name: "Lloyd cosine", opts: Options{K: 4, Metric: Cosine}},
+		{name: "Mini-batch L2", opts: Options{K: 4, BatchSize: 32, MaxIterations: 200}},
+		{name: "Mini-batch cosine", opts: Options{K: 4, Metric: Cosine, BatchSize: 32, MaxIterations: 200}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := KMeans(vectors, test.opts, context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !sameClusters(result.Assignments, len(centers), 50) {
+				t.Errorf("blobs were split or merged: %v", result.Assignments)
+			}
+			for c, size := range result.Sizes {
+				if size != 50 {
+					t.Errorf("cluster %d has %d vectors", c, size)
+				}
+			}
+			if test.opts.BatchSize == 0 && !result.Converged {
+				t.Errorf("did not converge in %d iterations", result.Iterations)
+			}
+
+			silhouette, err := Silhouette(vectors, result, 0, 1, context.Background())
+			if err != nil || silhouette < 0.8 {
+				t.Errorf("silhouette = %f, %v", silhouette, err)
+			}
+		})
+	}
+}
+
+func TestKMeansInertia(t *testing.T) {
+	vectors := blobs([][]float32{{0, 0}, {5, 5}}, 30, 1, 2)
+	var previous float64
+	for k := 1; k <= 4; k++ {
+		result, err := KMeans(vectors, Options{K: k, Seed: 3}, context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if k > 1 && result.Inertia >= previous {
+			t.Errorf("inertia did not drop from k=%d to k=%d: %f, %f", k-1, k, previous, result.Inertia)
+		}
+		previous = result.Inertia
+	}
+
+	result, _ := KMeans(vectors, Options{K: 2}, context.Background())
+	sampled, err := Silhouette(vectors, result, 20, 1, context.Background())
+	if err != nil || sampled < 0.5 {
+		t.Errorf("sampled silhouette = %f, %v", sampled, err)
+	}
+}
+
+func TestKMeansErrors(t *testing.T) {
+	vectors := [][]float32{{1, 2}, {3, 4}}
+	if _, err := KMeans(vectors, Options{K: 3}, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for k > n, got %v", err)
+	}
+	if _, err := KMeans(vectors, Options{K: 1, Metric: "IP"}, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for an unknown metric, got %v", err)
+	}
+	if _, err := KMeans([][]float32{{1, 2}, {3}}, Options{K: 1}, context.Background()); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := KMeans(vectors, Options{K: 2}, ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRepresentatives(t *testing.T) {
+	vectors := [][]float32{{0, 0}, {1, 0}, {0.1, 0}, {10, 10}, {10, 11}}
+	result, err := KMeans(vectors, Options{K: 2}, context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	representatives := result.Representatives(vectors, 2)
+	small := representatives[result.Assignments[0]]
+	if len(small) != 2 || small[0] != 2 || small[1] != 0 {
+		t.Errorf("representatives of the first cluster = %v, want [2 0]", small)
+	}
+	if large := representatives[result.Assignments[3]]; len(large) != 2 {
+		t.Errorf("representatives of the second cluster = %v", large)
+	}
+}
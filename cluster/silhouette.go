@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// Silhouette is the mean silhouette coefficient of a clustering, from -1 (vectors sit closer to
+// another cluster than to their own) to 1 (tight, well separated clusters). It costs a pass over
+// all vectors per scored vector, so sample > 0 scores only that many randomly chosen vectors.
+// Distances are Euclidean for L2 and 1 - cosine for Cosine.
+func Silhouette(vectors [][]float32, result *Result, sample int, seed int64, ctx context.Context) (float64, error) {
+	if result.K < 2 {
+		return 0, nil
+	}
+	if result.Metric == Cosine {
+		vectors = normalized(vectors)
+	}
+	scored := make([]int, len(vectors))
+	for i := range scored {
+		scored[i] = i
+	}
+	if sample > 0 && sample < len(vectors) {
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(scored), func(i, j int) { scored[i], scored[j] = scored[j], scored[i] })
+		scored = scored[:sample]
+	}
+
+	var total float64
+	sums := make([]float64, result.K)
+	for n, i := range scored {
+		if n%100 == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		own := result.Assignments[i]
+		if result.Sizes[own] < 2 {
+			// a vector alone in its cluster scores 0
+			continue
+		}
+		for c := range sums {
+			sums[c] = 0
+		}
+		for j, v := range vectors {
+			if j != i {
+				sums[result.Assignments[j]] += distance(vectors[i], v, result.Metric)
+			}
+		}
+		a := sums[own] / float64(result.Sizes[own]-1)
+		b := math.Inf(1)
+		for c, sum := range sums {
+			if c != own && result.Sizes[c] > 0 {
+				b = math.Min(b, sum/float64(result.Sizes[c]))
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		if m := math.Max(a, b); m > 0 {
+			total += (b - a) / m
+		}
+	}
+	return total / float64(len(scored)), nil
+}
+
+func distance(a []float32, b []float32, metric Metric) float64 {
+	var sum float64
+	if metric == Cosine {
+		for j := range a {
+			sum += float64(a[j]) * float64(b[j])
+		}
+		return 1 - sum
+	}
+	for j := range a {
+		d := float64(a[j]) - float64(b[j])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,148 @@
+package datasets
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"milvus/benchmark"
+	"milvus/errors"
+)
+
+/*
+	Standard ANN benchmark datasets
+
+	- TEXMEX (http://corpus-texmex.irisa.fr/) - SIFT/GIST as base/query .fvecs (or .bvecs) with .ivecs ground truth
+	- ann-benchmarks (https://github.com/erikbern/ann-benchmarks) - one HDF5 file with
+	  train, test, neighbors and distances datasets, metric in the "distance" attribute / file name
+*/
+
+type Dataset struct {
+	Name      string
+	Train     [][]float32
+	Test      [][]float32
+	Neighbors [][]int64   // ground truth train rows for each test vector, closest first
+	Distances [][]float32 // optional, distances matching Neighbors
+	Metric    benchmark.Metric
+}
+
+// Dim returns the vector dimension of the train set
+func (d *Dataset) Dim() int {
+	if len(d.Train) == 0 {
+		return 0
+	}
+	return len(d.Train[0])
+}
+
+// Benchmark converts the dataset for benchmark.Run, reusing its ground truth
+func (d *Dataset) Benchmark() benchmark.Dataset {
+	return benchmark.Dataset{
+		Name:        d.Name,
+		Train:       d.Train,
+		Queries:     d.Test,
+		GroundTruth: d.Neighbors,
+	}
+}
+
+// LoadTexmex loads a TEXMEX style dataset. The base and query files may be .fvecs or .bvecs,
+// groundTruthPath may be empty to let the benchmark compute it.
+func LoadTexmex(basePath string, queryPath string, groundTruthPath string) (*Dataset, error) {
+	train, err := readVectors(basePath)
+	if err != nil {
+		return nil, err
+	}
+	test, err := readVectors(queryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{
+		Name:   strings.TrimSuffix(filepath.Base(basePath), filepath.Ext(basePath)),
+		Train:  train,
+		Test:   test,
+		Metric: benchmark.L2,
+	}
+	if groundTruthPath != "" {
+		if dataset.Neighbors, err = ReadGroundTruth(groundTruthPath); err != nil {
+			return nil, err
+		}
+		if len(dataset.Neighbors) != len(test) {
+			return nil, fmt.Errorf("%s has %d rows but there are %d queries", groundTruthPath, len(dataset.Neighbors), len(test))
+		}
+	}
+	return dataset, dataset.validate()
+}
+
+func readVectors(path string) ([][]float32, error) {
+	if strings.EqualFold(filepath.Ext(path), ".bvecs") {
+		return ReadBvecs(path)
+	}
+	return ReadFvecs(path)
+}
+
+// Load opens a dataset by extension: .hdf5/.h5 is read as ann-benchmarks, .fvecs/.bvecs as a TEXMEX
+// base file with "query" and "groundtruth" files next to it (e.g. sift_base.fvecs, sift_query.fvecs, sift_groundtruth.ivecs)
+func Load(path string) (*Dataset, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdf5", ".h5":
+		return LoadHDF5(path)
+	case ".fvecs", ".bvecs":
+		ext := filepath.Ext(path)
+		prefix := strings.TrimSuffix(path, "base"+ext)
+		if prefix == path {
+			return nil, fmt.Errorf("%s: expected a TEXMEX base file named <name>_base%s", path, ext)
+		}
+		return LoadTexmex(path, prefix+"query"+ext, prefix+"groundtruth.ivecs")
+	}
+	return nil, fmt.Errorf("%s: unknown dataset format", path)
+}
+
+// IsDataset reports whether path looks like a file Load understands
+func IsDataset(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdf5", ".h5", ".fvecs", ".bvecs":
+		return true
+	}
+	return false
+}
+
+// MetricFromName maps ann-benchmarks distance names ("euclidean", "angular", "dot") to benchmark metrics
+func MetricFromName(name string) benchmark.Metric {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == '/'
+	})
+	for _, token := range tokens {
+		switch token {
+		case "angular", "cosine":
+			return benchmark.Cosine
+		case "dot", "ip":
+			return benchmark.IP
+		}
+	}
+	return benchmark.L2
+}
+
+func (d *Dataset) validate() error {
+	dim := d.Dim()
+	if dim == 0 {
+		return fmt.Errorf("dataset %s has no train vectors", d.Name)
+	}
+	for i, v := range d.Train {
+		if len(v) != dim {
+			return errors.DimensionMismatch(fmt.Sprintf("dataset %s: train vector %d", d.Name, i), len(v), dim)
+		}
+	}
+	for _, v := range d.Test {
+		if len(v) != dim {
+			return errors.DimensionMismatch(fmt.Sprintf("dataset %s: query", d.Name), len(v), dim)
+		}
+	}
+	for q, row := range d.Neighbors {
+		for _, id := range row {
+			if id < 0 || id >= int64(len(d.Train)) {
+				return fmt.Errorf("dataset %s: ground truth row %d references train vector %d of %d", d.Name, q, id, len(d.Train))
+			}
+		}
+	}
+	return nil
+}
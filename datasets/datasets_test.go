@@ -0,0 +1,129 @@
+package datasets
+
+import (
+	stdErrors "errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"milvus/benchmark"
+	"milvus/errors"
+)
+
+const (
+	baseFvecs   = "../tests/mockdata/siftsmall_base.fvecs"
+	queryFvecs  = "../tests/mockdata/siftsmall_query.fvecs"
+	baseBvecs   = "../tests/mockdata/siftsmall_base.bvecs"
+	groundTruth = "../tests/mockdata/siftsmall_groundtruth.ivecs"
+	emptyPath   = "../tests/mockdata/empty"
+	unknownPath = "imaginary/path/to/invalid/base.fvecs"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		expectedErrFn func(error) bool
+	}{
+		{
+			name: "TEXMEX fvecs",
+			path: baseFvecs,
+			expectedErrFn: func(err error) bool {
+				return err == nil
+			},
+		},
+		{
+			name: "TEXMEX bvecs",
+			path: baseBvecs,
+			expectedErrFn: func(err error) bool {
+				return err == nil
+			},
+		},
+		{
+			name: "Invalid Input - Passing unknown file path",
+			path: unknownPath,
+			expectedErrFn: func(err error) bool {
+				return errors.IsFileError(err, "FileNotFound")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataset, err := Load(tt.path)
+			if !tt.expectedErrFn(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if len(dataset.Train) != 20 || len(dataset.Test) != 3 || dataset.Dim() != 4 {
+				t.Fatalf("got %d train, %d test vectors of dimension %d", len(dataset.Train), len(dataset.Test), dataset.Dim())
+			}
+			// The stored ground truth must agree with a brute force search
+			expected := benchmark.GroundTruth(dataset.Train, dataset.Test, 5, dataset.Metric)
+			if !reflect.DeepEqual(expected, dataset.Neighbors) {
+				t.Errorf("ground truth %v does not match brute force %v", dataset.Neighbors, expected)
+			}
+		})
+	}
+}
+
+func TestReadVecs(t *testing.T) {
+	if _, err := ReadFvecs(emptyPath); !errors.IsFileError(err, "FileEmpty") {
+		t.Errorf("expected FileEmpty, got %v", err)
+	}
+	// Reading byte vectors as floats runs into garbage dimensions
+	if _, err := ReadFvecs(baseBvecs); !errors.IsFileError(err, "FileFormatError") {
+		t.Errorf("expected FileFormatError, got %v", err)
+	}
+
+	vectors, err := ReadFvecs(queryFvecs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "copy.fvecs")
+	if err := WriteFvecs(path, vectors); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := ReadFvecs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vectors, copied) {
+		t.Errorf("round trip changed vectors")
+	}
+}
+
+func TestMixedDimensions(t *testing.T) {
+	mixed := [][]float32{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10}}
+	path := filepath.Join(t.TempDir(), "mixed.fvecs")
+	if err := WriteFvecs(path, mixed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFvecs(path); !errors.IsFileError(err, "FileFormatError") {
+		t.Errorf("expected FileFormatError, got %v", err)
+	}
+	if _, err := LoadTexmex(path, queryFvecs, ""); !errors.IsFileError(err, "FileFormatError") {
+		t.Errorf("expected FileFormatError loading the dataset, got %v", err)
+	}
+
+	dataset := &Dataset{Name: "mixed", Train: mixed, Test: [][]float32{{0, 0, 0, 0}}, Metric: benchmark.L2}
+	if err := dataset.validate(); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+}
+
+func TestMetricFromName(t *testing.T) {
+	tests := map[string]benchmark.Metric{
+		"sift-128-euclidean.hdf5": benchmark.L2,
+		"glove-100-angular.hdf5":  benchmark.Cosine,
+		"lastfm-64-dot.hdf5":      benchmark.IP,
+		"clip-512.hdf5":           benchmark.L2,
+	}
+	for name, expected := range tests {
+		if metric := MetricFromName(name); metric != expected {
+			t.Errorf("MetricFromName(%q) = %s, expected %s", name, metric, expected)
+		}
+	}
+}
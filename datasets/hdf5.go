@@ -0,0 +1,115 @@
+//go:build hdf5
+
+package datasets
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"milvus/errors"
+
+	"gonum.org/v1/hdf5"
+)
+
+// HDF5 support needs cgo and libhdf5 (apt install libhdf5-dev), build with -tags hdf5
+
+// LoadHDF5 reads an ann-benchmarks file with train, test, neighbors and (optionally) distances datasets
+func LoadHDF5(path string) (*Dataset, error) {
+	if _, err := os.Stat(path); err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+
+	file, err := hdf5.OpenFile(path, hdf5.F_ACC_RDONLY)
+	if err != nil {
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	dataset := &Dataset{
+		Name:   strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Metric: MetricFromName(filepath.Base(path)),
+	}
+	if dataset.Train, err = readFloatMatrix(file, "train"); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	if dataset.Test, err = readFloatMatrix(file, "test"); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	if file.LinkExists("neighbors") {
+		if dataset.Neighbors, err = readIntMatrix(file, "neighbors"); err != nil {
+			return nil, errors.FileFormatError(path, err)
+		}
+	}
+	if file.LinkExists("distances") {
+		if dataset.Distances, err = readFloatMatrix(file, "distances"); err != nil {
+			return nil, errors.FileFormatError(path, err)
+		}
+	}
+	if err := dataset.validate(); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	return dataset, nil
+}
+
+func matrixShape(dset *hdf5.Dataset, name string) (int, int, error) {
+	space := dset.Space()
+	defer space.Close()
+	dims, _, err := space.SimpleExtentDims()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(dims) != 2 {
+		return 0, 0, fmt.Errorf("%s: expected a 2-D dataset, got %d dimensions", name, len(dims))
+	}
+	return int(dims[0]), int(dims[1]), nil
+}
+
+func readFloatMatrix(file *hdf5.File, name string) ([][]float32, error) {
+	dset, err := file.OpenDataset(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer dset.Close()
+
+	rows, cols, err := matrixShape(dset, name)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]float32, rows*cols)
+	if err := dset.Read(&data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	matrix := make([][]float32, rows)
+	for i := range matrix {
+		matrix[i] = data[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return matrix, nil
+}
+
+func readIntMatrix(file *hdf5.File, name string) ([][]int64, error) {
+	dset, err := file.OpenDataset(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer dset.Close()
+
+	rows, cols, err := matrixShape(dset, name)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]int64, rows*cols)
+	if err := dset.Read(&data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	matrix := make([][]int64, rows)
+	for i := range matrix {
+		matrix[i] = data[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return matrix, nil
+}
@@ -0,0 +1,14 @@
+//go:build !hdf5
+
+package datasets
+
+import (
+	stdErrors "errors"
+
+	"milvus/errors"
+)
+
+// LoadHDF5 needs cgo and libhdf5, see hdf5.go
+func LoadHDF5(path string) (*Dataset, error) {
+	return nil, errors.FileLoadingError(path, stdErrors.New("built without HDF5 support, rebuild with -tags hdf5"))
+}
@@ -0,0 +1,88 @@
+//go:build hdf5
+
+package datasets
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"milvus/benchmark"
+
+	"gonum.org/v1/hdf5"
+)
+
+// writeFixture writes a small ann-benchmarks style file from the TEXMEX fixtures
+func writeFixture(t *testing.T, path string) *Dataset {
+	t.Helper()
+	texmex, err := LoadTexmex(baseFvecs, queryFvecs, groundTruth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := hdf5.CreateFile(path, hdf5.F_ACC_TRUNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writeFloats := func(name string, matrix [][]float32) {
+		data := make([]float32, 0, len(matrix)*len(matrix[0]))
+		for _, row := range matrix {
+			data = append(data, row...)
+		}
+		space, err := hdf5.CreateSimpleDataspace([]uint{uint(len(matrix)), uint(len(matrix[0]))}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dset, err := file.CreateDataset(name, hdf5.T_NATIVE_FLOAT, space)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dset.Close()
+		if err := dset.Write(&data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFloats("train", texmex.Train)
+	writeFloats("test", texmex.Test)
+
+	neighbors := make([]int32, 0)
+	for _, row := range texmex.Neighbors {
+		for _, id := range row {
+			neighbors = append(neighbors, int32(id))
+		}
+	}
+	space, err := hdf5.CreateSimpleDataspace([]uint{uint(len(texmex.Neighbors)), uint(len(texmex.Neighbors[0]))}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dset, err := file.CreateDataset("neighbors", hdf5.T_NATIVE_INT32, space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if err := dset.Write(&neighbors); err != nil {
+		t.Fatal(err)
+	}
+	return texmex
+}
+
+func TestLoadHDF5(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "siftsmall-4-euclidean.hdf5")
+	expected := writeFixture(t, path)
+
+	dataset, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if dataset.Metric != benchmark.L2 {
+		t.Errorf("metric = %s, expected L2", dataset.Metric)
+	}
+	if !reflect.DeepEqual(dataset.Train, expected.Train) || !reflect.DeepEqual(dataset.Test, expected.Test) {
+		t.Errorf("vectors differ from the fixture")
+	}
+	if !reflect.DeepEqual(dataset.Neighbors, expected.Neighbors) {
+		t.Errorf("neighbors %v, expected %v", dataset.Neighbors, expected.Neighbors)
+	}
+}
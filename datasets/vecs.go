@@ -0,0 +1,162 @@
+package datasets
+
+import (
+	"bufio"
+	"encoding/binary"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"milvus/errors"
+)
+
+/*
+	TEXMEX vector files are a sequence of records, each a little-endian int32 dimension followed by
+
+	.fvecs  dim x float32
+	.ivecs  dim x int32
+	.bvecs  dim x uint8
+*/
+
+// ReadFvecs reads float vectors
+func ReadFvecs(path string) ([][]float32, error) {
+	var vectors [][]float32
+	err := readVecs(path, 4, func(record []byte) {
+		v := make([]float32, len(record)/4)
+		for i := range v {
+			v[i] = math.Float32frombits(binary.LittleEndian.Uint32(record[4*i:]))
+		}
+		vectors = append(vectors, v)
+	})
+	return vectors, err
+}
+
+// ReadBvecs reads byte vectors, widened to float32 so they can be stored in a float vector field
+func ReadBvecs(path string) ([][]float32, error) {
+	var vectors [][]float32
+	err := readVecs(path, 1, func(record []byte) {
+		v := make([]float32, len(record))
+		for i, b := range record {
+			v[i] = float32(b)
+		}
+		vectors = append(vectors, v)
+	})
+	return vectors, err
+}
+
+// ReadIvecs reads integer vectors
+func ReadIvecs(path string) ([][]int32, error) {
+	var vectors [][]int32
+	err := readVecs(path, 4, func(record []byte) {
+		v := make([]int32, len(record)/4)
+		for i := range v {
+			v[i] = int32(binary.LittleEndian.Uint32(record[4*i:]))
+		}
+		vectors = append(vectors, v)
+	})
+	return vectors, err
+}
+
+// ReadGroundTruth reads an .ivecs neighbour file as row ids
+func ReadGroundTruth(path string) ([][]int64, error) {
+	rows, err := ReadIvecs(path)
+	if err != nil {
+		return nil, err
+	}
+	truth := make([][]int64, len(rows))
+	for i, row := range rows {
+		truth[i] = make([]int64, len(row))
+		for j, id := range row {
+			truth[i][j] = int64(id)
+		}
+	}
+	return truth, nil
+}
+
+// readVecs calls record with the data of every record, which must all have the same dimension
+func readVecs(path string, size int, record func([]byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return errors.FileNotFound(path, err)
+		}
+		return errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 1<<20)
+	header := make([]byte, 4)
+	var buf []byte
+	first := 0
+	for n := 0; ; n++ {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				if n == 0 {
+					return errors.FileEmpty(path, stdErrors.New("Empty File"))
+				}
+				return nil
+			}
+			return errors.FileFormatError(path, fmt.Errorf("record %d: truncated dimension", n))
+		}
+		dim := int(int32(binary.LittleEndian.Uint32(header)))
+		if dim <= 0 || dim > 1<<20 {
+			return errors.FileFormatError(path, fmt.Errorf("record %d: invalid dimension %d", n, dim))
+		}
+		if n == 0 {
+			first = dim
+		} else if dim != first {
+			return errors.FileFormatError(path, fmt.Errorf("record %d: dimension %d, the records before have %d", n, dim, first))
+		}
+		if cap(buf) < dim*size {
+			buf = make([]byte, dim*size)
+		}
+		buf = buf[:dim*size]
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return errors.FileFormatError(path, fmt.Errorf("record %d: truncated vector", n))
+		}
+		record(buf)
+	}
+}
+
+// WriteFvecs writes float vectors in .fvecs format
+func WriteFvecs(path string, vectors [][]float32) error {
+	return writeVecs(path, len(vectors), func(i int) []uint32 {
+		values := make([]uint32, len(vectors[i]))
+		for j, v := range vectors[i] {
+			values[j] = math.Float32bits(v)
+		}
+		return values
+	})
+}
+
+// WriteIvecs writes integer vectors in .ivecs format
+func WriteIvecs(path string, vectors [][]int32) error {
+	return writeVecs(path, len(vectors), func(i int) []uint32 {
+		values := make([]uint32, len(vectors[i]))
+		for j, v := range vectors[i] {
+			values[j] = uint32(v)
+		}
+		return values
+	})
+}
+
+func writeVecs(path string, n int, row func(int) []uint32) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer output.Close()
+
+	writer := bufio.NewWriter(output)
+	for i := 0; i < n; i++ {
+		values := row(i)
+		binary.Write(writer, binary.LittleEndian, int32(len(values)))
+		binary.Write(writer, binary.LittleEndian, values)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return output.Close()
+}
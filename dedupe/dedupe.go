@@ -0,0 +1,290 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/hnsw"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+/*
+	Near-duplicate detection
+
+	Every entity is range searched for the neighbours within Options.Threshold, each such pair is
+	merged with union-find, and the resulting connected components with more than one member are
+	the duplicate groups. Being transitive, a chain a~b~c ends up in one group even if a and c are
+	further apart than the threshold.
+
+	The threshold is in the units search results are reported in, as Milvus' range search radius:
+	a squared distance for L2 (a pair is a duplicate below it), a similarity for IP and COSINE (a
+	pair is a duplicate above it).
+
+	Entities are processed in batches of Options.BatchSize, only their ids and the union-find
+	forest are kept across batches, so millions of rows work. Each entity returns at most
+	Options.MaxNeighbours hits; Result.Saturated counts the ones that hit that cap and may have
+	more duplicates than were linked.
+*/
+
+type Metric string
+
+const (
+	L2     Metric = "L2"
+	IP     Metric = "IP"
+	Cosine Metric = "COSINE"
+)
+
+type Options struct {
+	Metric        Metric // L2 by default
+	Threshold     float64
+	MaxNeighbours int // hits per entity, 100 by default
+	BatchSize     int // entities per range search, 1000 by default
+}
+
+// Group is a set of near-duplicates. Canonical is the member seen first: the first in file order
+// for vector files and the smallest primary key for collections.
+type Group struct {
+	Canonical  string   `json:"canonical"`
+	Duplicates []string `json:"duplicates"`
+}
+
+type Result struct {
+	Metric     Metric  `json:"metric"`
+	Threshold  float64 `json:"threshold"`
+	Entities   int     `json:"entities"`
+	Duplicates int     `json:"duplicates"` // members of all groups but the canonical ones
+	Saturated  int     `json:"saturated"`
+	Groups     []Group `json:"groups"` // largest first
+}
+
+func (o *Options) validate() error {
+	if o.Metric == "" {
+		o.Metric = L2
+	}
+	if o.MaxNeighbours <= 0 {
+		o.MaxNeighbours = 100
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+	switch o.Metric {
+	case L2, IP, Cosine:
+	default:
+		return errors.Invalid("metric", "unsupported metric %q, expected L2, IP or COSINE", o.Metric)
+	}
+	if math.IsNaN(o.Threshold) || (o.Metric == L2 && o.Threshold <= 0) {
+		return errors.Invalid("threshold", "invalid %s threshold %v", o.Metric, o.Threshold)
+	}
+	return nil
+}
+
+// within reports whether a search score is inside the threshold, with Milvus' strict bounds
+func (o *Options) within(score float32) bool {
+	if o.Metric == L2 {
+		return float64(score) < o.Threshold
+	}
+	return float64(score) > o.Threshold
+}
+
+// finder accumulates duplicate pairs of entities identified by string ids
+type finder struct {
+	ids     map[string]int
+	names   []string
+	order   []int // scan position of each id, -1 until it is scanned itself
+	scanned int
+	sets    unionFind
+}
+
+func newFinder() *finder {
+	return &finder{ids: make(map[string]int)}
+}
+
+func (f *finder) id(name string) int {
+	if i, ok := f.ids[name]; ok {
+		return i
+	}
+	i := f.sets.add()
+	f.ids[name] = i
+	f.names = append(f.names, name)
+	f.order = append(f.order, -1)
+	return i
+}
+
+// scan records that name is being processed, in order
+func (f *finder) scan(name string) {
+	if i := f.id(name); f.order[i] == -1 {
+		f.order[i] = f.scanned
+		f.scanned++
+	}
+}
+
+func (f *finder) link(a string, b string) {
+	if a != b {
+		f.sets.union(f.id(a), f.id(b))
+	}
+}
+
+// groups returns the sets with more than one member, largest first
+func (f *finder) groups() []Group {
+	members := make(map[int][]int)
+	for i := range f.names {
+		if root := f.sets.find(i); f.sets.size[root] > 1 {
+			members[root] = append(members[root], i)
+		}
+	}
+	rank := func(i int) int {
+		if f.order[i] == -1 {
+			return math.MaxInt
+		}
+		return f.order[i]
+	}
+
+	groups := make([]Group, 0, len(members))
+	for _, ids := range members {
+		sort.Slice(ids, func(a, b int) bool {
+			if rank(ids[a]) != rank(ids[b]) {
+				return rank(ids[a]) < rank(ids[b])
+			}
+			return ids[a] < ids[b]
+		})
+		group := Group{Canonical: f.names[ids[0]], Duplicates: make([]string, len(ids)-1)}
+		for j, i := range ids[1:] {
+			group.Duplicates[j] = f.names[i]
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		if len(groups[a].Duplicates) != len(groups[b].Duplicates) {
+			return len(groups[a].Duplicates) > len(groups[b].Duplicates)
+		}
+		return rank(f.ids[groups[a].Canonical]) < rank(f.ids[groups[b].Canonical])
+	})
+	return groups
+}
+
+func (f *finder) result(opts Options, saturated int) *Result {
+	result := &Result{Metric: opts.Metric, Threshold: opts.Threshold, Entities: f.scanned, Saturated: saturated, Groups: f.groups()}
+	for _, g := range result.Groups {
+		result.Duplicates += len(g.Duplicates)
+	}
+	return result
+}
+
+// Embeddings finds the near-duplicates among embs with a local HNSW index, so the range search is
+// approximate. If ctx is cancelled Embeddings returns ctx.Err().
+func Embeddings(embs []embeddings.Embedding, opts Options, ctx context.Context) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(embs) == 0 {
+		return nil, errors.Invalid("embeddings", "no vectors to deduplicate")
+	}
+	config := hnsw.DefaultConfig()
+	config.Metric = hnsw.Metric(opts.Metric)
+	if config.EfSearch < opts.MaxNeighbours+1 {
+		config.EfSearch = opts.MaxNeighbours + 1
+	}
+	idx, err := hnsw.Build(embs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	f := newFinder()
+	saturated := 0
+	for start := 0; start < len(embs); start += opts.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, e := range embs[start:min(start+opts.BatchSize, len(embs))] {
+			f.scan(e.Word)
+			hits, err := idx.Search(e.Vector, opts.MaxNeighbours+1)
+			if err != nil {
+				return nil, err
+			}
+			// the entity itself, or an exact duplicate in its place, is among the hits
+			if len(hits) == opts.MaxNeighbours+1 && opts.within(hits[len(hits)-1].Score) {
+				saturated++
+			}
+			for _, hit := range hits {
+				if !opts.within(hit.Score) {
+					break
+				}
+				f.link(e.Word, hit.Word)
+			}
+		}
+	}
+	return f.result(opts, saturated), nil
+}
+
+// Collection finds the near-duplicates among the words of a loaded collection keyed by a VarChar
+// word field, with Milvus range searches of opts.BatchSize query vectors. The vector field must
+// be indexed with opts.Metric; sp selects the search parameters of that index (flat if nil).
+func Collection(milvusClient client.Client, collection string, wordField string, vectorField string, sp entity.SearchParam, opts Options, ctx context.Context) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	// hits are linked to the scanned words by their primary keys, so the words have to be them
+	info, err := vectordb.DescribeCollection(milvusClient, collection, ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyed := false
+	for _, field := range info.Fields {
+		if field.Name == wordField && field.PrimaryKey && field.DataType == entity.FieldTypeVarChar.String() {
+			keyed = true
+		}
+	}
+	if !keyed {
+		return nil, errors.Invalid("collection", "collection %s is not keyed by the VarChar field %s", collection, wordField)
+	}
+	sp = vectordb.RangeSearchParam(sp, opts.Threshold)
+
+	f := newFinder()
+	saturated := 0
+	err = vectordb.ScanWords(milvusClient, collection, wordField, vectorField, opts.BatchSize, ctx, func(words []string, vectors [][]float32) error {
+		queries := make([]entity.Vector, len(vectors))
+		for i, v := range vectors {
+			queries[i] = entity.FloatVector(v)
+		}
+		results, err := vectordb.Search(milvusClient, vectordb.SearchParams{
+			CollectionName: collection,
+			VectorField:    vectorField,
+			Vectors:        queries,
+			Metric:         entity.MetricType(opts.Metric),
+			TopK:           opts.MaxNeighbours + 1,
+			SearchParam:    sp,
+		}, ctx)
+		if err != nil {
+			return err
+		}
+		if len(results) != len(words) {
+			return errors.MilvusFailure("range search", collection, fmt.Errorf("got %d results for %d queries", len(results), len(words)))
+		}
+		for i, word := range words {
+			f.scan(word)
+			if results[i].ResultCount == opts.MaxNeighbours+1 {
+				saturated++
+			}
+			for _, hit := range vectordb.Hits(results[i]) {
+				if neighbour, ok := hit.ID.(string); ok {
+					f.link(word, neighbour)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f.scanned == 0 {
+		return nil, errors.Invalid("collection", "collection %s has no entities", collection)
+	}
+	return f.result(opts, saturated), nil
+}
@@ -0,0 +1,120 @@
+package dedupe
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+// noisyCopies returns n random unit-ish vectors followed by copies of some of them with a little noise:
+// w1 has copies w1_a and w1_b, w2 has w2_a
+func noisyCopies(n int, dim int, seed int64) []embeddings.Embedding {
+	rng := rand.New(rand.NewSource(seed))
+	embs := make([]embeddings.Embedding, n)
+	for i := range embs {
+		embs[i] = embeddings.Embedding{Word: fmt.Sprintf("w%d", i), Vector: make([]float32, dim)}
+		for j := range embs[i].Vector {
+			embs[i].Vector[j] = float32(rng.NormFloat64())
+		}
+	}
+	for _, dup := range []struct {
+		of   int
+		word string
+	}{{1, "w1_a"}, {1, "w1_b"}, {2, "w2_a"}} {
+		v := make([]float32, dim)
+		for j, x := range embs[dup.of].Vector {
+			v[j] = x + float32(rng.NormFloat64()*0.01)
+		}
+		embs = append(embs, embeddings.Embedding{Word: dup.word, Vector: v})
+	}
+	return embs
+}
+
+func TestEmbeddings(t *testing.T) {
+	embs := noisyCopies(200, 16, 1)
+	expected := []Group{
+		{Canonical: "w1", Duplicates: []string{"w1_a", "w1_b"}},
+		{Canonical: "w2", Duplicates: []string{"w2_a"}},
+	}
+	for _, opts := range []Options{
+		{Metric: L2, Threshold: 0.1},
+		{Metric: Cosine, Threshold: 0.99, BatchSize: 7},
+	} {
+		result, err := Embeddings(embs, opts, context.Background())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", opts.Metric, err)
+		}
+		if !reflect.DeepEqual(result.Groups, expected) {
+			t.Errorf("%s: groups = %+v, want %+v", opts.Metric, result.Groups, expected)
+		}
+		if result.Entities != len(embs) || result.Duplicates != 3 || result.Saturated != 0 {
+			t.Errorf("%s: entities %d, duplicates %d, saturated %d", opts.Metric, result.Entities, result.Duplicates, result.Saturated)
+		}
+	}
+
+	// one neighbour per entity still chains w1, w1_a and w1_b together, but is saturated
+	result, err := Embeddings(embs, Options{Threshold: 0.1, MaxNeighbours: 1}, context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Groups) != 2 || result.Duplicates != 3 || result.Saturated == 0 {
+		t.Errorf("groups %+v, saturated %d", result.Groups, result.Saturated)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Embeddings(embs, Options{Threshold: 0.1}, ctx); !stdErrors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestEmbeddingsErrors(t *testing.T) {
+	embs := noisyCopies(10, 4, 2)
+	for _, opts := range []Options{{Threshold: 0}, {Metric: "HAMMING", Threshold: 1}} {
+		if _, err := Embeddings(embs, opts, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+			t.Errorf("%+v: expected a validation error, got %v", opts, err)
+		}
+	}
+	if _, err := Embeddings(nil, Options{Threshold: 1}, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error without vectors, got %v", err)
+	}
+}
+
+func TestFinder(t *testing.T) {
+	f := newFinder()
+	// "c" links to "z" before "z" is scanned, "z" still can't be canonical
+	f.scan("c")
+	f.link("c", "z")
+	f.scan("b")
+	f.link("b", "a")
+	f.scan("a")
+	f.scan("z")
+	f.link("z", "c")
+	f.scan("d")
+	f.link("d", "d")
+
+	expected := []Group{
+		{Canonical: "c", Duplicates: []string{"z"}},
+		{Canonical: "b", Duplicates: []string{"a"}},
+	}
+	if groups := f.groups(); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("groups = %+v, want %+v", groups, expected)
+	}
+
+	var u unionFind
+	for i := 0; i < 6; i++ {
+		u.add()
+	}
+	if !u.union(0, 1) || !u.union(2, 3) || !u.union(1, 3) || u.union(0, 2) {
+		t.Fatal("unexpected union results")
+	}
+	if u.find(0) != u.find(3) || u.find(4) == u.find(0) || u.size[u.find(2)] != 4 {
+		t.Errorf("parent %v, size %v", u.parent, u.size)
+	}
+}
@@ -0,0 +1,37 @@
+package dedupe
+
+// unionFind is a disjoint set forest over 0..n-1 with union by size and path halving, so a
+// sequence of m operations takes O(m α(n))
+type unionFind struct {
+	parent []int
+	size   []int
+}
+
+// add appends a new singleton set and returns its element
+func (u *unionFind) add() int {
+	u.parent = append(u.parent, len(u.parent))
+	u.size = append(u.size, 1)
+	return len(u.parent) - 1
+}
+
+func (u *unionFind) find(x int) int {
+	for u.parent[x] != x {
+		u.parent[x] = u.parent[u.parent[x]]
+		x = u.parent[x]
+	}
+	return x
+}
+
+// union merges the sets of a and b, reporting whether they were distinct
+func (u *unionFind) union(a int, b int) bool {
+	a, b = u.find(a), u.find(b)
+	if a == b {
+		return false
+	}
+	if u.size[a] < u.size[b] {
+		a, b = b, a
+	}
+	u.parent[b] = a
+	u.size[a] += u.size[b]
+	return true
+}
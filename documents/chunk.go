@@ -0,0 +1,215 @@
+package documents
+
+import (
+	stdErrors "errors"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"milvus/errors"
+)
+
+/*
+	Semantic document search
+
+	Documents are split into overlapping chunks of sentences, paragraphs or tokens, each chunk is
+	embedded as the mean of its word vectors (see Embed) and stored with its text, source path and
+	byte offsets in a Milvus collection (see CreateCollection). A query is embedded the same way
+	and the closest chunks are shown within their source text (see Context and Highlight).
+*/
+
+type Mode string
+
+const (
+	Sentences  Mode = "sentence"
+	Paragraphs Mode = "paragraph"
+	Tokens     Mode = "token"
+)
+
+// ChunkOptions sets how a text is split. Size and Overlap count units of Mode: consecutive chunks
+// share Overlap units, so each chunk starts Size - Overlap units after the previous one.
+type ChunkOptions struct {
+	Mode    Mode
+	Size    int
+	Overlap int
+}
+
+// DefaultChunkOptions returns the chunk size and overlap used for mode: 5 sentences overlapping by
+// 1, single paragraphs, or 200 tokens overlapping by 50
+func DefaultChunkOptions(mode Mode) ChunkOptions {
+	switch mode {
+	case Paragraphs:
+		return ChunkOptions{Mode: Paragraphs, Size: 1}
+	case Tokens:
+		return ChunkOptions{Mode: Tokens, Size: 200, Overlap: 50}
+	}
+	return ChunkOptions{Mode: Sentences, Size: 5, Overlap: 1}
+}
+
+// Chunk is a piece of a document. Start and End are the byte offsets of Text in the source.
+type Chunk struct {
+	Source string `json:"source"`
+	Index  int    `json:"chunk"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Text   string `json:"text"`
+}
+
+// span is the byte range of a unit of text
+type span struct {
+	start int
+	end   int
+}
+
+// Split cuts text into chunks. Whitespace between units stays in the chunk text, leading and
+// trailing whitespace doesn't.
+func Split(text string, opts ChunkOptions) ([]Chunk, error) {
+	if opts.Mode == "" {
+		opts.Mode = Sentences
+	}
+	if opts.Size <= 0 {
+		opts.Size = DefaultChunkOptions(opts.Mode).Size
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.Size {
+		return nil, errors.Invalid("overlap", "overlap must be between 0 and the chunk size (%d), got %d", opts.Size-1, opts.Overlap)
+	}
+
+	var units []span
+	switch opts.Mode {
+	case Sentences:
+		for _, p := range paragraphs(text) {
+			units = append(units, sentences(text, p)...)
+		}
+	case Paragraphs:
+		units = paragraphs(text)
+	case Tokens:
+		units = tokens(text)
+	default:
+		return nil, errors.Invalid("mode", "unsupported chunk mode %q, expected sentence, paragraph or token", opts.Mode)
+	}
+
+	var chunks []Chunk
+	for first := 0; first < len(units); first += opts.Size - opts.Overlap {
+		last := min(first+opts.Size, len(units)) - 1
+		start, end := units[first].start, units[last].end
+		chunks = append(chunks, Chunk{Index: len(chunks), Start: start, End: end, Text: text[start:end]})
+		if last == len(units)-1 {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// SplitFile reads a UTF-8 text file and splits it, setting the chunks' Source to path
+func SplitFile(path string, opts ChunkOptions) ([]Chunk, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	if !utf8.Valid(content) {
+		return nil, errors.FileFormatError(path, stdErrors.New("not UTF-8 text"))
+	}
+	chunks, err := Split(string(content), opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		chunks[i].Source = path
+	}
+	return chunks, nil
+}
+
+// paragraphs returns the blocks of text separated by blank lines
+func paragraphs(text string) []span {
+	var units []span
+	current := span{start: -1}
+	for line := 0; line < len(text); {
+		end := len(text)
+		if i := strings.IndexByte(text[line:], '\n'); i != -1 {
+			end = line + i
+		}
+		switch {
+		case strings.TrimSpace(text[line:end]) != "":
+			if current.start == -1 {
+				current.start = line
+			}
+			current.end = end
+		case current.start != -1:
+			if p, ok := trim(text, current); ok {
+				units = append(units, p)
+			}
+			current.start = -1
+		}
+		line = end + 1
+	}
+	if current.start != -1 {
+		if p, ok := trim(text, current); ok {
+			units = append(units, p)
+		}
+	}
+	return units
+}
+
+// sentences splits a paragraph after ".", "!" or "?" (and any closing quotes or brackets) that is
+// followed by whitespace
+func sentences(text string, p span) []span {
+	var units []span
+	start := p.start
+	for i := p.start; i < p.end; {
+		r, width := utf8.DecodeRuneInString(text[i:])
+		i += width
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		for i < p.end {
+			next, w := utf8.DecodeRuneInString(text[i:])
+			if !strings.ContainsRune(".!?\"')]»”’", next) {
+				break
+			}
+			i += w
+		}
+		if next, _ := utf8.DecodeRuneInString(text[i:]); i < p.end && !unicode.IsSpace(next) {
+			continue
+		}
+		if s, ok := trim(text, span{start, i}); ok {
+			units = append(units, s)
+		}
+		start = i
+	}
+	if s, ok := trim(text, span{start, p.end}); ok {
+		units = append(units, s)
+	}
+	return units
+}
+
+// tokens returns the runs of non-whitespace in text
+func tokens(text string) []span {
+	var units []span
+	start := -1
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r) && start != -1:
+			units = append(units, span{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start == -1:
+			start = i
+		}
+	}
+	if start != -1 {
+		units = append(units, span{start, len(text)})
+	}
+	return units
+}
+
+// trim shrinks s to exclude surrounding whitespace, reporting false if nothing is left
+func trim(text string, s span) (span, bool) {
+	part := text[s.start:s.end]
+	trimmed := strings.TrimLeftFunc(part, unicode.IsSpace)
+	s.start += len(part) - len(trimmed)
+	s.end = s.start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	return s, s.end > s.start
+}
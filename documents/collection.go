@@ -0,0 +1,215 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"milvus/errors"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// Fields of a chunk collection
+const (
+	IDField     = "id" // Int64, generated by Milvus
+	SourceField = "source"
+	ChunkField  = "chunk"
+	StartField  = "start"
+	EndField    = "end"
+	TextField   = "text"
+	VectorField = "embedding"
+
+	// MaxSourceLength and MaxTextLength are the VarChar lengths of the source and text fields.
+	// Longer chunk texts are stored truncated, their offsets still cover all of the chunk.
+	MaxSourceLength = 1024
+	MaxTextLength   = 65535
+)
+
+// Metric is the metric chunk collections are indexed and searched with
+const Metric = entity.COSINE
+
+// Match is a chunk returned by Search, Score is its cosine similarity with the query
+type Match struct {
+	Chunk
+	Score float32 `json:"score"`
+}
+
+// CreateCollection creates a chunk collection for dim dimensional vectors if it doesn't exist yet,
+// indexes its vector field with index if it has no index, and loads it
+func CreateCollection(milvusClient client.Client, collection string, dim int, index entity.Index, ctx context.Context) error {
+	err := vectordb.NewCollectionBuilder().
+		WithName(collection).
+		WithDescription("document chunks for semantic search").
+		WithFields(
+			vectordb.NewFieldInt64(IDField, true, true),
+			vectordb.NewFieldVarChar(SourceField, MaxSourceLength, false, false),
+			vectordb.NewFieldInt64(ChunkField, false, false),
+			vectordb.NewFieldInt64(StartField, false, false),
+			vectordb.NewFieldInt64(EndField, false, false),
+			vectordb.NewFieldVarChar(TextField, MaxTextLength, false, false),
+			vectordb.NewFieldFloatVector(VectorField, dim),
+		).
+		Create(milvusClient, ctx)
+	if err != nil {
+		return err
+	}
+
+	info, err := vectordb.DescribeCollection(milvusClient, collection, ctx)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]vectordb.FieldInfo, len(info.Fields))
+	for _, f := range info.Fields {
+		fields[f.Name] = f
+	}
+	for _, name := range []string{SourceField, ChunkField, StartField, EndField, TextField, VectorField} {
+		if _, ok := fields[name]; !ok {
+			return errors.Invalid("collection", "collection %s has no %s field, it doesn't hold document chunks", collection, name)
+		}
+	}
+	if stored, _ := strconv.Atoi(fields[VectorField].TypeParams["dim"]); stored != dim {
+		return errors.DimensionMismatch("vectors for collection "+collection, dim, stored)
+	}
+	if _, ok := info.Indexes[VectorField]; !ok {
+		if err := vectordb.CreateIndexWith(milvusClient, collection, VectorField, index, ctx); err != nil {
+			return err
+		}
+	}
+	return vectordb.LoadCollection(milvusClient, collection, ctx)
+}
+
+// Insert stores chunks and their vectors in batches of batchSize
+func Insert(milvusClient client.Client, collection string, chunks []Chunk, vectors [][]float32, batchSize int, ctx context.Context) error {
+	if len(chunks) != len(vectors) {
+		return errors.Invalid("vectors", "got %d chunks but %d vectors", len(chunks), len(vectors))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	dim := len(vectors[0])
+	for start := 0; start < len(chunks); start += batchSize {
+		batch := chunks[start:min(start+batchSize, len(chunks))]
+		sources := make([]string, len(batch))
+		texts := make([]string, len(batch))
+		indexes := make([]int64, len(batch))
+		starts := make([]int64, len(batch))
+		ends := make([]int64, len(batch))
+		for i, c := range batch {
+			if len(c.Source) > MaxSourceLength {
+				return errors.Invalid("source", "source path is longer than %d bytes: %s", MaxSourceLength, c.Source)
+			}
+			sources[i], texts[i] = c.Source, truncate(c.Text, MaxTextLength)
+			indexes[i], starts[i], ends[i] = int64(c.Index), int64(c.Start), int64(c.End)
+		}
+		columns := []entity.Column{
+			entity.NewColumnVarChar(SourceField, sources),
+			entity.NewColumnInt64(ChunkField, indexes),
+			entity.NewColumnInt64(StartField, starts),
+			entity.NewColumnInt64(EndField, ends),
+			entity.NewColumnVarChar(TextField, texts),
+			entity.NewColumnFloatVector(VectorField, dim, vectors[start:start+len(batch)]),
+		}
+		if err := vectordb.Write(milvusClient, collection, "", false, columns, ctx); err != nil {
+			return err
+		}
+	}
+	if err := milvusClient.Flush(ctx, collection, false); err != nil {
+		return errors.MilvusFailure("flush", collection, err)
+	}
+	return nil
+}
+
+// SourceIDs returns the ids of the chunks stored for a source. Taken before a changed document is
+// inserted again, they are the chunks DeleteIDs removes once the new ones are stored.
+func SourceIDs(milvusClient client.Client, collection string, source string, ctx context.Context) ([]int64, error) {
+	quoted, ok := vectordb.QuoteString(source)
+	if !ok {
+		return nil, errors.Invalid("source", "source path %q can't be written in a Milvus expression", source)
+	}
+	columns, err := vectordb.Query(milvusClient, vectordb.QueryParams{
+		CollectionName: collection,
+		Expr:           fmt.Sprintf("%s == %s", SourceField, quoted),
+		OutputFields:   []string{IDField},
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, row := range vectordb.Rows(columns) {
+		if id, ok := row[IDField].(int64); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteIDs deletes chunks by id, in batches of batchSize
+func DeleteIDs(milvusClient client.Client, collection string, ids []int64, batchSize int, ctx context.Context) error {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+		list := make([]string, len(batch))
+		for i, id := range batch {
+			list[i] = strconv.FormatInt(id, 10)
+		}
+		expr := fmt.Sprintf("%s in [%s]", IDField, strings.Join(list, ", "))
+		if err := vectordb.DeleteByExpr(milvusClient, collection, "", expr, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search returns the topK chunks closest to vector. expr optionally filters them first, e.g.
+// `source like "%/docs/%"`; sp selects the search parameters of the collection's index (flat if nil).
+func Search(milvusClient client.Client, collection string, vector []float32, topK int, expr string, sp entity.SearchParam, ctx context.Context) ([]Match, error) {
+	results, err := vectordb.Search(milvusClient, vectordb.SearchParams{
+		CollectionName: collection,
+		Expr:           expr,
+		OutputFields:   []string{SourceField, ChunkField, StartField, EndField, TextField},
+		VectorField:    VectorField,
+		Vectors:        []entity.Vector{entity.FloatVector(vector)},
+		Metric:         Metric,
+		TopK:           topK,
+		SearchParam:    sp,
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches := []Match{}
+	if len(results) == 0 {
+		return matches, nil
+	}
+	for _, hit := range vectordb.Hits(results[0]) {
+		m := Match{Score: hit.Score}
+		m.Source, _ = hit.Fields[SourceField].(string)
+		m.Text, _ = hit.Fields[TextField].(string)
+		index, _ := hit.Fields[ChunkField].(int64)
+		start, _ := hit.Fields[StartField].(int64)
+		end, _ := hit.Fields[EndField].(int64)
+		m.Index, m.Start, m.End = int(index), int(start), int(end)
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// truncate cuts s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,155 @@
+package documents
+
+import (
+	stdErrors "errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+const text = `The cat sat on the mat. A dog barked! Was the puppy scared? No.
+
+Tigers are big cats.
+The lion (a cat) is too.
+
+
+Mr. Darwin wrote "On the Origin of Species." It sold well.`
+
+func chunkTexts(t *testing.T, opts ChunkOptions) []string {
+	t.Helper()
+	chunks, err := Split(text, opts)
+	if err != nil {
+		t.Fatalf("%+v: unexpected error: %v", opts, err)
+	}
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		if c.Index != i || text[c.Start:c.End] != c.Text {
+			t.Errorf("chunk %d has index %d and offsets %d-%d for %q", i, c.Index, c.Start, c.End, c.Text)
+		}
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		opts     ChunkOptions
+		expected []string
+	}{
+		{ChunkOptions{Mode: Sentences, Size: 1}, []string{
+			"The cat sat on the mat.", "A dog barked!", "Was the puppy scared?", "No.",
+			"Tigers are big cats.", "The lion (a cat) is too.",
+			"Mr.", `Darwin wrote "On the Origin of Species."`, "It sold well.",
+		}},
+		{ChunkOptions{Mode: Sentences, Size: 4, Overlap: 1}, []string{
+			"The cat sat on the mat. A dog barked! Was the puppy scared? No.",
+			"No.\n\nTigers are big cats.\nThe lion (a cat) is too.\n\n\nMr.",
+			"Mr. Darwin wrote \"On the Origin of Species.\" It sold well.",
+		}},
+		{ChunkOptions{Mode: Paragraphs, Size: 1}, []string{
+			"The cat sat on the mat. A dog barked! Was the puppy scared? No.",
+			"Tigers are big cats.\nThe lion (a cat) is too.",
+			"Mr. Darwin wrote \"On the Origin of Species.\" It sold well.",
+		}},
+		{ChunkOptions{Mode: Tokens, Size: 10, Overlap: 3}, []string{
+			"The cat sat on the mat. A dog barked! Was",
+			"dog barked! Was the puppy scared? No.\n\nTigers are big",
+			"Tigers are big cats.\nThe lion (a cat) is too.",
+			"cat) is too.\n\n\nMr. Darwin wrote \"On the Origin of",
+			"the Origin of Species.\" It sold well.",
+		}},
+	}
+	for _, tt := range tests {
+		if texts := chunkTexts(t, tt.opts); !reflect.DeepEqual(texts, tt.expected) {
+			t.Errorf("%+v: chunks = %q, want %q", tt.opts, texts, tt.expected)
+		}
+	}
+
+	if chunks, err := Split(" \n\n ", ChunkOptions{}); err != nil || len(chunks) != 0 {
+		t.Errorf("blank text gave %v, %v", chunks, err)
+	}
+	for _, opts := range []ChunkOptions{{Mode: Sentences, Size: 2, Overlap: 2}, {Mode: "page"}} {
+		if _, err := Split(text, opts); !stdErrors.Is(err, errors.ErrValidation) {
+			t.Errorf("%+v: expected a validation error, got %v", opts, err)
+		}
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	table, err := embeddings.NewTable([]embeddings.Embedding{
+		{Word: "cat", Vector: []float32{1, 0}},
+		{Word: "kitten", Vector: []float32{0.9, 0.1}},
+		{Word: "dog", Vector: []float32{0, 1}},
+		{Word: "Darwin", Vector: []float32{-1, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms := Terms(table, "The Cat met a (dog). Darwin, darwin"); !reflect.DeepEqual(terms, []string{"cat", "dog", "Darwin"}) {
+		t.Errorf("terms = %q", terms)
+	}
+	vector, err := Embed(table, "CAT! dog?")
+	if err != nil || !reflect.DeepEqual(vector, []float32{0.5, 0.5}) {
+		t.Errorf("embedding = %v, %v", vector, err)
+	}
+	if _, err := Embed(table, "no known words"); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+
+	match := Matcher(table, "cats and a cat", 0.9)
+	highlighted := Highlight("A Kitten, a dog and the cat.", match, "[", "]")
+	if highlighted != "A [Kitten], a dog and the [cat]." {
+		t.Errorf("highlighted = %q", highlighted)
+	}
+	if highlighted := Highlight("A Kitten and the cat.", Matcher(table, "cat", 1.1), "[", "]"); highlighted != "A Kitten and the [cat]." {
+		t.Errorf("highlighted without similar words = %q", highlighted)
+	}
+}
+
+func TestContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := SplitFile(path, ChunkOptions{Mode: Sentences, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks[1].Source != path {
+		t.Errorf("source = %q", chunks[1].Source)
+	}
+
+	snippet := Context(chunks[1], 12)
+	expected := Snippet{Before: "the mat.", Text: "A dog barked!", After: "Was the"}
+	if snippet != expected {
+		t.Errorf("snippet = %+v, want %+v", snippet, expected)
+	}
+	if snippet := Context(chunks[0], 1000); snippet.Before != "" || !strings.HasSuffix(snippet.After, "It sold well.") {
+		t.Errorf("snippet of the first chunk = %+v", snippet)
+	}
+
+	// a stored text truncated for Milvus still shows the whole chunk
+	truncated := chunks[1]
+	truncated.Text = truncate(truncated.Text, 5)
+	if snippet := Context(truncated, 0); snippet.Text != "A dog barked!" {
+		t.Errorf("snippet of a truncated chunk = %+v", snippet)
+	}
+	// the source changed since the chunk was stored
+	moved := chunks[1]
+	moved.Start++
+	if snippet := Context(moved, 12); snippet != (Snippet{Text: moved.Text}) {
+		t.Errorf("snippet of a changed source = %+v", snippet)
+	}
+
+	if truncated := truncate("naïve", 3); truncated != "na" {
+		t.Errorf("truncate split a rune: %q", truncated)
+	}
+	if _, err := SplitFile(filepath.Join(t.TempDir(), "missing.txt"), ChunkOptions{}); !stdErrors.Is(err, errors.ErrFileNotFound) {
+		t.Errorf("expected file not found, got %v", err)
+	}
+}
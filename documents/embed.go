@@ -0,0 +1,81 @@
+package documents
+
+import (
+	"strings"
+	"unicode"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+// term returns the vocabulary word a token of text stands for: the token itself if table has a
+// vector for it, else the token without surrounding punctuation, as it is or lowercased, or "" if
+// none is known. Training corpora are usually lowercased and stripped of punctuation, documents aren't.
+func term(table *embeddings.Table, token string) string {
+	if _, ok := table.Lookup(token); ok {
+		return token
+	}
+	word := core(token)
+	for _, candidate := range []string{word, strings.ToLower(word)} {
+		if _, ok := table.Lookup(candidate); ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// core strips the punctuation around a token, "(cats)." -> "cats"
+func core(token string) string {
+	return strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+}
+
+// Terms returns the words of text that table has vectors for, in order
+func Terms(table *embeddings.Table, text string) []string {
+	var terms []string
+	for _, token := range embeddings.Tokenize(text) {
+		if word := term(table, token); word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Embed averages the vectors of the words of text, see Terms. Chunks and queries are embedded the
+// same way, so their cosine similarity compares the words they use.
+func Embed(table *embeddings.Table, text string) ([]float32, error) {
+	terms := Terms(table, text)
+	if len(terms) == 0 {
+		return nil, errors.Invalid("text", "none of the words of the text have vectors")
+	}
+	vector, _, err := table.Embed(strings.Join(terms, " "))
+	return vector, err
+}
+
+// Matcher returns a function reporting whether a token of a search result is relevant to query:
+// it stands for one of the query's words or for a word whose cosine similarity with one of them
+// is at least threshold (above 1 only the query's own words match)
+func Matcher(table *embeddings.Table, query string, threshold float64) func(token string) bool {
+	queryTerms := Terms(table, query)
+	matches := make(map[string]bool)
+	for _, word := range queryTerms {
+		matches[word] = true
+	}
+	return func(token string) bool {
+		word := term(table, token)
+		if word == "" {
+			return false
+		}
+		if match, ok := matches[word]; ok {
+			return match
+		}
+		match := false
+		for _, q := range queryTerms {
+			if similarity, err := table.Similarity(word, q); err == nil && similarity >= threshold {
+				match = true
+				break
+			}
+		}
+		matches[word] = match
+		return match
+	}
+}
@@ -0,0 +1,85 @@
+package documents
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Snippet is the text of a chunk with the source text before and after it
+type Snippet struct {
+	Before string `json:"before"`
+	Text   string `json:"text"`
+	After  string `json:"after"`
+}
+
+// Context reads up to radius bytes of chunk's source on either side of the chunk, cut back to
+// whole words. If the source can't be read or no longer holds the chunk's text at its offsets,
+// the snippet is the stored text alone.
+func Context(chunk Chunk, radius int) Snippet {
+	snippet := Snippet{Text: chunk.Text}
+	content, err := os.ReadFile(chunk.Source)
+	if err != nil || chunk.Start < 0 || chunk.End > len(content) || chunk.Start > chunk.End ||
+		!strings.HasPrefix(string(content[chunk.Start:chunk.End]), chunk.Text) {
+		return snippet
+	}
+	text := string(content)
+	// the stored text may have been truncated, the source has all of it
+	snippet.Text = text[chunk.Start:chunk.End]
+
+	if start := max(chunk.Start-radius, 0); start < chunk.Start {
+		before := text[start:chunk.Start]
+		if start > 0 {
+			before = dropPartialWord(before, true)
+		}
+		snippet.Before = strings.TrimSpace(before)
+	}
+	if end := min(chunk.End+radius, len(text)); end > chunk.End {
+		after := text[chunk.End:end]
+		if end < len(text) {
+			after = dropPartialWord(after, false)
+		}
+		snippet.After = strings.TrimSpace(after)
+	}
+	return snippet
+}
+
+// dropPartialWord removes the possibly cut word at the start of s, or at its end if atStart is
+// false. A rune cut by slicing s at a byte offset is part of that word.
+func dropPartialWord(s string, atStart bool) string {
+	if atStart {
+		if i := strings.IndexFunc(s, unicode.IsSpace); i != -1 {
+			return s[i:]
+		}
+		return ""
+	}
+	if i := strings.LastIndexFunc(s, unicode.IsSpace); i != -1 {
+		return s[:i]
+	}
+	return ""
+}
+
+// Highlight wraps the words of text that match reports as relevant in markStart and markEnd, leaving the
+// punctuation around them and all whitespace as it is: "a cat." -> "a **cat**."
+func Highlight(text string, match func(token string) bool, markStart string, markEnd string) string {
+	var b strings.Builder
+	last := 0
+	for _, t := range tokens(text) {
+		token := text[t.start:t.end]
+		if !match(token) {
+			continue
+		}
+		word := core(token)
+		if word == "" {
+			continue
+		}
+		start := t.start + strings.Index(token, word)
+		b.WriteString(text[last:start])
+		b.WriteString(markStart)
+		b.WriteString(word)
+		b.WriteString(markEnd)
+		last = start + len(word)
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,259 @@
+package embeddings
+
+import (
+	"bufio"
+	"bytes"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"milvus/errors"
+)
+
+// Embedding is a single word and its vector, independent of the file format it came from
+type Embedding struct {
+	Word   string
+	Vector []float32
+}
+
+type Format int
+
+const (
+	FormatUnknown        Format = iota
+	FormatWego                  // wego text output: "word v1 v2 ... vn " per line, no header
+	FormatGloVe                 // GloVe text: "word v1 v2 ... vn" per line, no header
+	FormatFastText              // fastText .vec: "count dim" header followed by text lines
+	FormatWord2VecBinary        // word2vec C binary: "count dim\n" header then "word " + dim little-endian float32
+	FormatNumPy                 // .npy float matrix plus a vocabulary file with one word per line
+	FormatFloat16               // compact binary with half precision vectors, see float16.go
+)
+
+var formatNames = map[Format]string{
+	FormatWego:           "wego",
+	FormatGloVe:          "glove",
+	FormatFastText:       "fasttext",
+	FormatWord2VecBinary: "word2vec",
+	FormatNumPy:          "npy",
+	FormatFloat16:        "f16",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseFormat maps a format name (as printed by Format.String) back to a Format
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for f, n := range formatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	switch name {
+	case "text", "txt":
+		return FormatWego, nil
+	case "vec":
+		return FormatFastText, nil
+	case "bin", "word2vec-binary":
+		return FormatWord2VecBinary, nil
+	case "numpy":
+		return FormatNumPy, nil
+	case "float16":
+		return FormatFloat16, nil
+	}
+	return FormatUnknown, fmt.Errorf("unknown embedding format %q", name)
+}
+
+// Dim returns the dimension shared by all embeddings, or an error if they disagree
+func Dim(embs []Embedding) (int, error) {
+	if len(embs) == 0 {
+		return 0, nil
+	}
+	dim := len(embs[0].Vector)
+	for _, e := range embs {
+		if len(e.Vector) != dim {
+			return 0, errors.DimensionMismatch(fmt.Sprintf("word %q", e.Word), len(e.Vector), dim)
+		}
+	}
+	return dim, nil
+}
+
+// VocabPath is where the vocabulary of a .npy matrix is read from and written to
+func VocabPath(npyPath string) string {
+	return strings.TrimSuffix(npyPath, filepath.Ext(npyPath)) + ".vocab"
+}
+
+// DetectFormat guesses the format of an embedding file from its magic bytes, header and extension
+func DetectFormat(path string) (Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return FormatUnknown, errors.FileNotFound(path, err)
+		}
+		return FormatUnknown, errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, errors.FileLoadingError(path, err)
+	}
+	head = head[:n]
+	if len(head) == 0 {
+		return FormatUnknown, errors.FileEmpty(path, stdErrors.New("Empty File"))
+	}
+
+	return sniffFormat(head), nil
+}
+
+func sniffFormat(head []byte) Format {
+	switch {
+	case bytes.HasPrefix(head, npyMagic):
+		return FormatNumPy
+	case bytes.HasPrefix(head, f16Magic):
+		return FormatFloat16
+	}
+
+	firstLine, rest, found := bytes.Cut(head, []byte("\n"))
+	if _, _, err := parseHeader(string(firstLine)); err == nil && found {
+		// The header is shared by fastText and word2vec binary, the first record tells them apart
+		if isText(rest) {
+			return FormatFastText
+		}
+		return FormatWord2VecBinary
+	}
+
+	if bytes.HasSuffix(bytes.TrimRight(firstLine, "\r"), []byte(" ")) {
+		return FormatWego
+	}
+	return FormatGloVe
+}
+
+// isText reports whether the record after a header looks like a text vector line
+func isText(record []byte) bool {
+	line, _, _ := bytes.Cut(record, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 {
+		return false
+	}
+	for _, field := range fields[1:] {
+		if _, err := parseFloat(field); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Load reads an embedding file, detecting its format automatically
+func Load(path string) ([]Embedding, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFormat(path, format)
+}
+
+// LoadFormat reads an embedding file stored in the given format
+func LoadFormat(path string, format Format) ([]Embedding, error) {
+	if format == FormatNumPy {
+		return LoadNumPy(path, VocabPath(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.FileLoadingError(path, err)
+	}
+	embs, err := read(bufio.NewReader(file), format, info.Size())
+	if err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	return embs, nil
+}
+
+// Read decodes embeddings from r. NumPy matrices need a vocabulary file and are read with LoadNumPy instead.
+func Read(r io.Reader, format Format) ([]Embedding, error) {
+	return read(r, format, -1)
+}
+
+// read is Read of a file of size bytes, which bounds the counts its header can declare; -1 is unknown
+func read(r io.Reader, format Format, size int64) ([]Embedding, error) {
+	switch format {
+	case FormatWego, FormatGloVe:
+		return readText(r, false, size)
+	case FormatFastText:
+		return readText(r, true, size)
+	case FormatWord2VecBinary:
+		return readWord2VecBinary(r, size)
+	case FormatFloat16:
+		return readFloat16(r, size)
+	}
+	return nil, fmt.Errorf("format %s can not be read from a stream", format)
+}
+
+// Save writes embeddings to path in the given format
+func Save(path string, embs []Embedding, format Format) error {
+	if format == FormatNumPy {
+		return SaveNumPy(path, VocabPath(path), embs)
+	}
+
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer output.Close()
+
+	writer := bufio.NewWriter(output)
+	if err := Write(writer, embs, format); err != nil {
+		return errors.FileFormatError(path, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return output.Close()
+}
+
+// Write encodes embeddings to w in the given format
+func Write(w io.Writer, embs []Embedding, format Format) error {
+	dim, err := Dim(embs)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatWego:
+		return writeText(w, embs, dim, false, true)
+	case FormatGloVe:
+		return writeText(w, embs, dim, false, false)
+	case FormatFastText:
+		return writeText(w, embs, dim, true, false)
+	case FormatWord2VecBinary:
+		return writeWord2VecBinary(w, embs, dim)
+	case FormatFloat16:
+		return writeFloat16(w, embs, dim)
+	}
+	return fmt.Errorf("format %s can not be written to a stream", format)
+}
+
+// Convert reads inputPath (auto detected) and writes it to outputPath in the given format
+func Convert(inputPath string, outputPath string, format Format) error {
+	embs, err := Load(inputPath)
+	if err != nil {
+		return err
+	}
+	return Save(outputPath, embs, format)
+}
@@ -0,0 +1,331 @@
+package embeddings
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"milvus/errors"
+)
+
+const (
+	validModelPath = "../tests/mockdata/word_vector.txt"
+	emptyInputPath = "../tests/mockdata/empty"
+	unknownPath    = "imaginary/path/to/invalid/vectors.txt"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name          string
+		inputPath     string
+		expectedErrFn func(error) bool
+	}{
+		{
+			name:      "Valid wego vectors",
+			inputPath: validModelPath,
+			expectedErrFn: func(err error) bool {
+				return err == nil
+			},
+		},
+		{
+			name:      "Invalid Input - Passing unknown file path",
+			inputPath: unknownPath,
+			expectedErrFn: func(err error) bool {
+				return errors.IsFileError(err, "FileNotFound")
+			},
+		},
+		{
+			name:      "Invalid Input - Passing empty file",
+			inputPath: emptyInputPath,
+			expectedErrFn: func(err error) bool {
+				return errors.IsFileError(err, "FileEmpty")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.inputPath)
+			if !tt.expectedErrFn(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	embs, err := Load(validModelPath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	if len(embs) != 20 || len(embs[0].Vector) != 10 {
+		t.Fatalf("expected 20 words of dimension 10, got %d of %d", len(embs), len(embs[0].Vector))
+	}
+
+	tests := []struct {
+		name      string
+		file      string
+		format    Format
+		tolerance float64
+	}{
+		{name: "wego", file: "vectors.txt", format: FormatWego, tolerance: 1e-6},
+		{name: "GloVe", file: "glove.txt", format: FormatGloVe, tolerance: 1e-6},
+		{name: "fastText", file: "vectors.vec", format: FormatFastText, tolerance: 1e-6},
+		{name: "word2vec binary", file: "vectors.bin", format: FormatWord2VecBinary, tolerance: 0},
+		{name: "NumPy", file: "vectors.npy", format: FormatNumPy, tolerance: 0},
+		{name: "float16", file: "vectors.f16", format: FormatFloat16, tolerance: 1e-4},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := Save(path, embs, tt.format); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+
+			detected, err := DetectFormat(path)
+			if err != nil {
+				t.Fatalf("detect failed: %v", err)
+			}
+			if detected != tt.format {
+				t.Errorf("detected format %s, expected %s", detected, tt.format)
+			}
+
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if len(loaded) != len(embs) {
+				t.Fatalf("loaded %d words, expected %d", len(loaded), len(embs))
+			}
+			for i := range embs {
+				if loaded[i].Word != embs[i].Word {
+					t.Fatalf("word %d is %q, expected %q", i, loaded[i].Word, embs[i].Word)
+				}
+				for j := range embs[i].Vector {
+					if diff := math.Abs(float64(loaded[i].Vector[j] - embs[i].Vector[j])); diff > tt.tolerance {
+						t.Fatalf("%s[%d] differs by %g", embs[i].Word, j, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOversizedHeaders(t *testing.T) {
+	f16 := func(count uint32, dim uint32) string {
+		header := make([]byte, 10)
+		binary.LittleEndian.PutUint16(header, f16Version)
+		binary.LittleEndian.PutUint32(header[2:], count)
+		binary.LittleEndian.PutUint32(header[6:], dim)
+		return string(f16Magic) + string(header)
+	}
+	tests := []struct {
+		name    string
+		format  Format
+		content string
+	}{
+		{"word2vec count", FormatWord2VecBinary, "99999999999 300\ncat \x00\x00\x80\x3f"},
+		{"word2vec dimension", FormatWord2VecBinary, "1 99999999999\ncat \x00\x00\x80\x3f"},
+		{"fastText count", FormatFastText, "99999999999 2\ncat 0.1 0.2\n"},
+		{"float16 count", FormatFloat16, f16(math.MaxUint32, 300)},
+		{"float16 dimension", FormatFloat16, f16(1, math.MaxUint32)},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "vectors")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFormat(path, tt.format); !errors.IsFileError(err, "FileFormatError") {
+			t.Errorf("%s: expected a FileFormatError, got %v", tt.name, err)
+		}
+		// without a file size the preallocation is still capped, the read fails at the end of the data
+		if _, err := Read(strings.NewReader(tt.content), tt.format); err == nil {
+			t.Errorf("%s: expected an error reading a stream", tt.name)
+		}
+	}
+}
+
+func TestOversizedNumPyShapes(t *testing.T) {
+	npy := func(shape string) string {
+		header := "{'descr': '<f4', 'fortran_order': False, 'shape': " + shape + ", }\n"
+		return string(npyMagic) + "\x01\x00" + string([]byte{byte(len(header)), 0}) + header + "\x00\x00\x80\x3f"
+	}
+	dir := t.TempDir()
+	vocab := filepath.Join(dir, "vectors.vocab")
+	if err := os.WriteFile(vocab, []byte("cat\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, shape := range []string{"(4611686018427387904, 4)", "(1, 4611686018427387904)", "(99999999999999999999, 1)", "(1000000, 1)", "(1, 0)"} {
+		path := filepath.Join(dir, "vectors.npy")
+		if err := os.WriteFile(path, []byte(npy(shape)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadNumPy(path, vocab); !errors.IsFileError(err, "FileFormatError") {
+			t.Errorf("shape %s: expected a FileFormatError, got %v", shape, err)
+		}
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	tests := []struct {
+		value    float32
+		expected uint16
+	}{
+		{value: 0, expected: 0x0000},
+		{value: 1, expected: 0x3c00},
+		{value: -2, expected: 0xc000},
+		{value: 65504, expected: 0x7bff},
+		{value: 1e6, expected: 0x7c00},
+		{value: 5.960464477539063e-08, expected: 0x0001},
+		{value: float32(math.Inf(-1)), expected: 0xfc00},
+	}
+
+	for _, tt := range tests {
+		if got := float32ToFloat16(tt.value); got != tt.expected {
+			t.Errorf("float32ToFloat16(%g) = %#04x, expected %#04x", tt.value, got, tt.expected)
+		}
+		if tt.expected != 0x7c00 {
+			if back := float16ToFloat32(tt.expected); back != tt.value {
+				t.Errorf("float16ToFloat32(%#04x) = %g, expected %g", tt.expected, back, tt.value)
+			}
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	embs, err := Load(validModelPath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "vectors.store")
+	if err := ConvertToStore(validModelPath, path); err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer store.Close()
+
+	if store.Len() != len(embs) || store.Dim() != len(embs[0].Vector) {
+		t.Fatalf("store has %d words of dimension %d", store.Len(), store.Dim())
+	}
+	for i, e := range embs {
+		row, ok := store.Index(e.Word)
+		if !ok || row != i {
+			t.Fatalf("Index(%q) = %d, %v, expected %d", e.Word, row, ok, i)
+		}
+		vector, _ := store.Lookup(e.Word)
+		for j := range e.Vector {
+			if vector[j] != e.Vector[j] {
+				t.Fatalf("%s[%d] = %g, expected %g", e.Word, j, vector[j], e.Vector[j])
+			}
+		}
+	}
+	if _, ok := store.Lookup("unknownword"); ok {
+		t.Errorf("found a word that is not in the store")
+	}
+
+	if _, err := OpenStore(validModelPath); !errors.IsFileError(err, "FileFormatError") {
+		t.Errorf("expected a FileFormatError opening a text file, got %v", err)
+	}
+
+	// Corrupt headers and vocabulary offsets are rejected when the store is opened, not when it's used
+	valid, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptions := map[string]func(data []byte){
+		"zero table size":    func(data []byte) { binary.LittleEndian.PutUint64(data[40:], 0) },
+		"huge count":         func(data []byte) { binary.LittleEndian.PutUint64(data[16:], math.MaxUint64/2) },
+		"huge dimension":     func(data []byte) { binary.LittleEndian.PutUint32(data[12:], math.MaxUint32) },
+		"huge matrix offset": func(data []byte) { binary.LittleEndian.PutUint64(data[48:], math.MaxUint64-3) },
+		"offset past vocab":  func(data []byte) { binary.LittleEndian.PutUint64(data[storeHeaderSize+8:], 1<<40) },
+		"decreasing offsets": func(data []byte) { binary.LittleEndian.PutUint64(data[storeHeaderSize+16:], 0) },
+		"table row past count": func(data []byte) {
+			tableOffset := binary.LittleEndian.Uint64(data[32:])
+			binary.LittleEndian.PutUint32(data[tableOffset:], 1000)
+		},
+	}
+	for name, corrupt := range corruptions {
+		data := append([]byte(nil), valid...)
+		corrupt(data)
+		corruptPath := filepath.Join(t.TempDir(), "corrupt.store")
+		if err := os.WriteFile(corruptPath, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenStore(corruptPath); !errors.IsFileError(err, "FileFormatError") {
+			t.Errorf("%s: expected a FileFormatError, got %v", name, err)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	table, err := LoadTable(validModelPath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	cat, ok := table.Lookup("cat")
+	if !ok {
+		t.Fatal("cat not found")
+	}
+	vector, oov, err := table.Embed("cat zzzunknown")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(oov) != 1 || oov[0] != "zzzunknown" {
+		t.Errorf("oov = %v, want [zzzunknown]", oov)
+	}
+	for i := range cat {
+		if vector[i] != cat[i] {
+			t.Fatalf("embedding of a single known word should be its vector")
+		}
+	}
+	if _, _, err := table.Embed("zzzunknown"); err == nil {
+		t.Error("expected an error embedding only unknown words")
+	}
+
+	neighbors, err := table.NearestWords("cat", 3)
+	if err != nil {
+		t.Fatalf("NearestWords: %v", err)
+	}
+	if len(neighbors) != 3 {
+		t.Fatalf("got %d neighbours, want 3", len(neighbors))
+	}
+	for i, n := range neighbors {
+		if n.Word == "cat" {
+			t.Error("query word returned as its own neighbour")
+		}
+		if i > 0 && n.Similarity > neighbors[i-1].Similarity {
+			t.Error("neighbours not sorted by similarity")
+		}
+	}
+	if _, err := table.Nearest([]float32{1, 2}, 3); err == nil {
+		t.Error("expected a dimension mismatch error")
+	}
+
+	analogy, err := table.Analogy("cat", "dog", "english", 2)
+	if err != nil {
+		t.Fatalf("Analogy: %v", err)
+	}
+	for _, n := range analogy {
+		if n.Word == "cat" || n.Word == "dog" || n.Word == "english" {
+			t.Errorf("analogy returned one of its inputs: %s", n.Word)
+		}
+	}
+	if _, err := table.Analogy("cat", "dog", "zzzunknown", 2); err == nil {
+		t.Error("expected an error for an unknown analogy word")
+	}
+
+	self, err := table.Similarity("cat", "cat")
+	if err != nil || math.Abs(self-1) > 1e-5 {
+		t.Errorf("Similarity(cat, cat) = %v, %v, want 1", self, err)
+	}
+}
@@ -0,0 +1,168 @@
+package embeddings
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+	Compact float16 format - half the size of float32 binaries with ~3 significant digits,
+	which is plenty for similarity search on normalised word vectors.
+
+	magic    "EMBF16"
+	version  uint16 (1)
+	count    uint32
+	dim      uint32
+	records  count x { uint16 word length, word bytes, dim x uint16 IEEE 754 half }
+
+	All integers are little-endian.
+*/
+
+var f16Magic = []byte("EMBF16")
+
+const f16Version = 1
+
+// readFloat16 reads the float16 format. size is the size of the file in bytes, or -1 if unknown.
+func readFloat16(r io.Reader, size int64) ([]Embedding, error) {
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(f16Magic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return nil, fmt.Errorf("reading magic: %w", err)
+	}
+	if string(magic) != string(f16Magic) {
+		return nil, fmt.Errorf("not a float16 embedding file")
+	}
+
+	var header struct {
+		Version uint16
+		Count   uint32
+		Dim     uint32
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if header.Version != f16Version {
+		return nil, fmt.Errorf("unsupported float16 format version %d", header.Version)
+	}
+
+	if header.Dim > maxDim {
+		return nil, fmt.Errorf("invalid dimension %d", header.Dim)
+	}
+	dim := int(header.Dim)
+	// a record is at least the word length and the vector, after the 16 byte header
+	if err := checkCount(int(header.Count), 2+2*dim, size-16); err != nil {
+		return nil, err
+	}
+	embs := make([]Embedding, 0, min(int(header.Count), maxPrealloc))
+	buf := make([]byte, 2*dim)
+	for i := 0; i < int(header.Count); i++ {
+		var wordLen uint16
+		if err := binary.Read(reader, binary.LittleEndian, &wordLen); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		word := make([]byte, wordLen)
+		if _, err := io.ReadFull(reader, word); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, fmt.Errorf("vector for %q: %w", word, err)
+		}
+		vector := make([]float32, dim)
+		for j := range vector {
+			vector[j] = float16ToFloat32(binary.LittleEndian.Uint16(buf[2*j:]))
+		}
+		embs = append(embs, Embedding{Word: string(word), Vector: vector})
+	}
+	return embs, nil
+}
+
+func writeFloat16(w io.Writer, embs []Embedding, dim int) error {
+	writer := bufio.NewWriter(w)
+	writer.Write(f16Magic)
+	binary.Write(writer, binary.LittleEndian, uint16(f16Version))
+	binary.Write(writer, binary.LittleEndian, uint32(len(embs)))
+	binary.Write(writer, binary.LittleEndian, uint32(dim))
+
+	buf := make([]byte, 2*dim)
+	for _, e := range embs {
+		if len(e.Word) > math.MaxUint16 {
+			return fmt.Errorf("word %q is too long", e.Word[:32])
+		}
+		binary.Write(writer, binary.LittleEndian, uint16(len(e.Word)))
+		writer.WriteString(e.Word)
+		for j, v := range e.Vector {
+			binary.LittleEndian.PutUint16(buf[2*j:], float32ToFloat16(v))
+		}
+		writer.Write(buf)
+	}
+	return writer.Flush()
+}
+
+// float32ToFloat16 converts to IEEE 754 half precision, rounding to nearest even
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign
+	case bits>>23&0xff == 0xff:
+		// Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		// Too large, saturate to infinity
+		return sign | 0x7c00
+	case exp <= 0:
+		// Subnormal half or underflow to zero
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - exp)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		mid := uint32(1) << (shift - 1)
+		if rem > mid || (rem == mid && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+
+	half := uint32(exp)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		// may carry into the exponent, which correctly rounds up to the next power of two or infinity
+		half++
+	}
+	return sign | uint16(half)
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal, normalise it
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
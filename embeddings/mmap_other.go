@@ -0,0 +1,23 @@
+//go:build !unix
+
+package embeddings
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// Without mmap the store is read into memory, aligned so the matrix can still be viewed as float32
+func mmapFile(file *os.File, size int) ([]byte, error) {
+	buf := make([]uint64, (size+7)/8)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func munmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,264 @@
+package embeddings
+
+import (
+	"bufio"
+	"encoding/binary"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"milvus/errors"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape'\s*:\s*\(\s*(\d+)\s*,\s*(\d+)\s*,?\s*\)`)
+)
+
+// LoadNumPy reads a 2-D .npy matrix and pairs its rows with the words in vocabPath, one word per line
+func LoadNumPy(npyPath string, vocabPath string) ([]Embedding, error) {
+	words, err := readVocab(vocabPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(npyPath)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(npyPath, err)
+		}
+		return nil, errors.FileLoadingError(npyPath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.FileLoadingError(npyPath, err)
+	}
+
+	matrix, err := readNpy(bufio.NewReader(file), info.Size())
+	if err != nil {
+		return nil, errors.FileFormatError(npyPath, err)
+	}
+	if len(matrix) != len(words) {
+		return nil, errors.FileFormatError(npyPath, fmt.Errorf("matrix has %d rows but vocabulary %s has %d words", len(matrix), vocabPath, len(words)))
+	}
+
+	embs := make([]Embedding, len(words))
+	for i, word := range words {
+		embs[i] = Embedding{Word: word, Vector: matrix[i]}
+	}
+	return embs, nil
+}
+
+// SaveNumPy writes the vectors as a little-endian float32 .npy matrix and the words to vocabPath
+func SaveNumPy(npyPath string, vocabPath string, embs []Embedding) error {
+	dim, err := Dim(embs)
+	if err != nil {
+		return errors.FileFormatError(npyPath, err)
+	}
+
+	vocab, err := os.Create(vocabPath)
+	if err != nil {
+		return errors.FileCreationErr(vocabPath, err)
+	}
+	defer vocab.Close()
+	vocabWriter := bufio.NewWriter(vocab)
+	for _, e := range embs {
+		if strings.Contains(e.Word, "\n") {
+			return errors.FileFormatError(vocabPath, fmt.Errorf("word %q contains a newline", e.Word))
+		}
+		vocabWriter.WriteString(e.Word)
+		vocabWriter.WriteByte('\n')
+	}
+	if err := vocabWriter.Flush(); err != nil {
+		return errors.FileCreationErr(vocabPath, err)
+	}
+
+	output, err := os.Create(npyPath)
+	if err != nil {
+		return errors.FileCreationErr(npyPath, err)
+	}
+	defer output.Close()
+	writer := bufio.NewWriter(output)
+	if err := writeNpy(writer, embs, dim); err != nil {
+		return errors.FileCreationErr(npyPath, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.FileCreationErr(npyPath, err)
+	}
+	if err := vocab.Close(); err != nil {
+		return errors.FileCreationErr(vocabPath, err)
+	}
+	return output.Close()
+}
+
+func readVocab(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.TrimRight(scanner.Text(), "\r")
+		if word == "" {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.FileLoadingError(path, err)
+	}
+	return words, nil
+}
+
+// readNpy reads a 2-D matrix from a .npy file of size bytes, or -1 if unknown, which bounds the
+// shape its header can declare
+func readNpy(r io.Reader, size int64) ([][]float32, error) {
+	preamble := make([]byte, 8)
+	if _, err := io.ReadFull(r, preamble); err != nil {
+		return nil, fmt.Errorf("reading npy preamble: %w", err)
+	}
+	if string(preamble[:6]) != string(npyMagic) {
+		return nil, stdErrors.New("not a npy file")
+	}
+
+	var headerLen int
+	switch major := preamble[6]; major {
+	case 1:
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint16(buf))
+		size -= 10
+	case 2, 3:
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint32(buf))
+		size -= 12
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", major)
+	}
+	if size >= 0 {
+		if int64(headerLen) > size {
+			return nil, fmt.Errorf("npy header of %d bytes is longer than the file", headerLen)
+		}
+		size -= int64(headerLen)
+	}
+
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, headerBytes); err != nil {
+		return nil, fmt.Errorf("reading npy header: %w", err)
+	}
+	header := string(headerBytes)
+
+	descr := npyDescr.FindStringSubmatch(header)
+	shape := npyShape.FindStringSubmatch(header)
+	if descr == nil || shape == nil {
+		return nil, fmt.Errorf("unsupported npy header %q, expected a 2-D array", strings.TrimSpace(header))
+	}
+	fortran := npyFortran.FindStringSubmatch(header)
+	rows, err := strconv.Atoi(shape[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid npy shape: %w", err)
+	}
+	cols, err := strconv.Atoi(shape[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid npy shape: %w", err)
+	}
+	if cols <= 0 || cols > maxDim {
+		return nil, fmt.Errorf("invalid npy shape (%d, %d)", rows, cols)
+	}
+
+	var (
+		itemSize int
+		order    binary.ByteOrder = binary.LittleEndian
+		decode   func([]byte, binary.ByteOrder) float32
+	)
+	switch descr[1][1:] {
+	case "f4":
+		itemSize, decode = 4, func(b []byte, o binary.ByteOrder) float32 { return math.Float32frombits(o.Uint32(b)) }
+	case "f8":
+		itemSize, decode = 8, func(b []byte, o binary.ByteOrder) float32 { return float32(math.Float64frombits(o.Uint64(b))) }
+	case "f2":
+		itemSize, decode = 2, func(b []byte, o binary.ByteOrder) float32 { return float16ToFloat32(o.Uint16(b)) }
+	default:
+		return nil, fmt.Errorf("unsupported npy dtype %q", descr[1])
+	}
+	if descr[1][0] == '>' {
+		order = binary.BigEndian
+	}
+
+	// cols*itemSize is at most 8*maxDim, so a row count the file holds can't overflow the product
+	if err := checkCount(rows, cols*itemSize, size); err != nil {
+		return nil, err
+	}
+	if size < 0 && rows > math.MaxInt/(cols*itemSize) {
+		return nil, fmt.Errorf("invalid npy shape (%d, %d)", rows, cols)
+	}
+	data := make([]byte, rows*cols*itemSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("reading npy data: %w", err)
+	}
+
+	matrix := make([][]float32, rows)
+	for i := range matrix {
+		matrix[i] = make([]float32, cols)
+	}
+	columnMajor := fortran != nil && fortran[1] == "True"
+	for k := 0; k < rows*cols; k++ {
+		i, j := k/cols, k%cols
+		if columnMajor {
+			i, j = k%rows, k/rows
+		}
+		matrix[i][j] = decode(data[k*itemSize:], order)
+	}
+	return matrix, nil
+}
+
+func writeNpy(w io.Writer, embs []Embedding, dim int) error {
+	header := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%d, %d), }", len(embs), dim)
+	// magic + version + header length + header + '\n' must be a multiple of 64 bytes
+	padding := 64 - (len(npyMagic)+2+2+len(header)+1)%64
+	header += strings.Repeat(" ", padding%64) + "\n"
+
+	if _, err := w.Write(npyMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{1, 0}); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(header))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4*dim)
+	for _, e := range embs {
+		for j, v := range e.Vector {
+			binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(v))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package embeddings
+
+import (
+	"bufio"
+	"encoding/binary"
+	stdErrors "errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"os"
+	"unsafe"
+
+	"milvus/errors"
+)
+
+/*
+	On-disk embedding store, opened with mmap so million word vocabularies load instantly
+	and vectors are read straight out of the page cache.
+
+	header   64 bytes, see storeHeader
+	offsets  (count+1) x uint64 - byte offsets of each word inside the vocabulary blob
+	vocab    concatenated word bytes
+	table    tableSize x uint32 - open addressing hash table of row+1 (0 marks an empty slot)
+	matrix   count x dim float32, row major, starts on a 64 byte boundary
+
+	All integers and floats are little-endian. Vectors are handed out without copying,
+	so the store must stay open while they are in use.
+*/
+
+var storeMagic = [8]byte{'E', 'M', 'B', 'S', 'T', 'O', 'R', 'E'}
+
+const (
+	storeVersion    = 1
+	storeHeaderSize = 64
+)
+
+type storeHeader struct {
+	Magic        [8]byte
+	Version      uint32
+	Dim          uint32
+	Count        uint64
+	VocabOffset  uint64
+	TableOffset  uint64
+	TableSize    uint64
+	MatrixOffset uint64
+	_            [8]byte
+}
+
+type Store struct {
+	path    string
+	data    []byte
+	header  storeHeader
+	offsets []byte
+	vocab   []byte
+	table   []byte
+	matrix  []float32
+}
+
+// WriteStore writes embeddings into the binary store format at path
+func WriteStore(path string, embs []Embedding) error {
+	dim, err := Dim(embs)
+	if err != nil {
+		return errors.FileFormatError(path, err)
+	}
+	if len(embs) == 0 {
+		return errors.FileFormatError(path, stdErrors.New("no embeddings to store"))
+	}
+	// the hash table holds row+1 as a uint32
+	if uint64(len(embs)) > math.MaxUint32 || uint64(dim) > math.MaxUint32 {
+		return errors.FileFormatError(path, fmt.Errorf("%d words of dimension %d are too many for a store", len(embs), dim))
+	}
+
+	vocabSize := 0
+	for _, e := range embs {
+		vocabSize += len(e.Word)
+	}
+	tableSize := uint64(1)
+	for tableSize < uint64(2*len(embs)) {
+		tableSize <<= 1
+	}
+
+	header := storeHeader{
+		Magic:       storeMagic,
+		Version:     storeVersion,
+		Dim:         uint32(dim),
+		Count:       uint64(len(embs)),
+		VocabOffset: storeHeaderSize + 8*uint64(len(embs)+1),
+		TableSize:   tableSize,
+	}
+	header.TableOffset = header.VocabOffset + uint64(vocabSize)
+	header.MatrixOffset = align64(header.TableOffset + 4*tableSize)
+
+	table := make([]uint32, tableSize)
+	for i, e := range embs {
+		slot := hashWord(e.Word) & (tableSize - 1)
+		for table[slot] != 0 {
+			if embs[table[slot]-1].Word == e.Word {
+				return errors.FileFormatError(path, fmt.Errorf("duplicate word %q", e.Word))
+			}
+			slot = (slot + 1) & (tableSize - 1)
+		}
+		table[slot] = uint32(i + 1)
+	}
+
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer output.Close()
+	writer := bufio.NewWriterSize(output, 1<<20)
+
+	binary.Write(writer, binary.LittleEndian, header)
+	offset := uint64(0)
+	for _, e := range embs {
+		binary.Write(writer, binary.LittleEndian, offset)
+		offset += uint64(len(e.Word))
+	}
+	binary.Write(writer, binary.LittleEndian, offset)
+	for _, e := range embs {
+		writer.WriteString(e.Word)
+	}
+	binary.Write(writer, binary.LittleEndian, table)
+	written := header.TableOffset + 4*tableSize
+	writer.Write(make([]byte, header.MatrixOffset-written))
+
+	buf := make([]byte, 4*dim)
+	for _, e := range embs {
+		for j, v := range e.Vector {
+			binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(v))
+		}
+		writer.Write(buf)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return output.Close()
+}
+
+// ConvertToStore converts an embedding file in any supported format into a store
+func ConvertToStore(inputPath string, outputPath string) error {
+	embs, err := Load(inputPath)
+	if err != nil {
+		return err
+	}
+	return WriteStore(outputPath, embs)
+}
+
+// OpenStore maps a store file into memory. Call Close when done with it and its vectors.
+func OpenStore(path string) (*Store, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.FileLoadingError(path, err)
+	}
+	if info.Size() < storeHeaderSize {
+		return nil, errors.FileFormatError(path, stdErrors.New("file too small to be an embedding store"))
+	}
+
+	data, err := mmapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, errors.FileLoadingError(path, err)
+	}
+
+	store := &Store{path: path, data: data}
+	if err := store.parse(); err != nil {
+		store.Close()
+		return nil, errors.FileFormatError(path, err)
+	}
+	return store, nil
+}
+
+func (s *Store) parse() error {
+	h := &s.header
+	h.Magic = [8]byte(s.data[:8])
+	if h.Magic != storeMagic {
+		return stdErrors.New("not an embedding store")
+	}
+	h.Version = binary.LittleEndian.Uint32(s.data[8:])
+	h.Dim = binary.LittleEndian.Uint32(s.data[12:])
+	h.Count = binary.LittleEndian.Uint64(s.data[16:])
+	h.VocabOffset = binary.LittleEndian.Uint64(s.data[24:])
+	h.TableOffset = binary.LittleEndian.Uint64(s.data[32:])
+	h.TableSize = binary.LittleEndian.Uint64(s.data[40:])
+	h.MatrixOffset = binary.LittleEndian.Uint64(s.data[48:])
+	if h.Version != storeVersion {
+		return fmt.Errorf("unsupported store version %d", h.Version)
+	}
+
+	// Count, TableSize and the offsets are bounded by the file size before they are multiplied or
+	// added, so a corrupt header can't overflow the checks
+	size := uint64(len(s.data))
+	if h.Count >= size/8 || h.TableSize == 0 || h.TableSize > size/4 || h.TableSize&(h.TableSize-1) != 0 ||
+		h.TableSize <= h.Count || h.TableOffset > size || h.MatrixOffset > size {
+		return stdErrors.New("corrupt store header")
+	}
+	hi, matrixBytes := bits.Mul64(h.Count, 4*uint64(h.Dim))
+	if hi != 0 || h.VocabOffset != storeHeaderSize+8*(h.Count+1) || h.TableOffset < h.VocabOffset ||
+		h.MatrixOffset < h.TableOffset+4*h.TableSize || h.MatrixOffset%4 != 0 ||
+		matrixBytes != size-h.MatrixOffset {
+		return stdErrors.New("corrupt store header")
+	}
+
+	s.offsets = s.data[storeHeaderSize:h.VocabOffset]
+	s.vocab = s.data[h.VocabOffset:h.TableOffset]
+	s.table = s.data[h.TableOffset : h.TableOffset+4*h.TableSize]
+	if err := s.check(); err != nil {
+		return err
+	}
+	if matrixBytes > 0 {
+		// The matrix is 64 byte aligned inside a page aligned mapping, so it can be viewed as float32 directly
+		s.matrix = unsafe.Slice((*float32)(unsafe.Pointer(&s.data[h.MatrixOffset])), h.Count*uint64(h.Dim))
+	}
+	return nil
+}
+
+// check validates the vocabulary offsets and the hash table, which Word and Index trust
+func (s *Store) check() error {
+	previous := uint64(0)
+	for i := uint64(0); i <= s.header.Count; i++ {
+		offset := binary.LittleEndian.Uint64(s.offsets[8*i:])
+		if offset < previous || offset > uint64(len(s.vocab)) {
+			return fmt.Errorf("corrupt vocabulary offset %d", i)
+		}
+		previous = offset
+	}
+	used := uint64(0)
+	for slot := uint64(0); slot < s.header.TableSize; slot++ {
+		row := binary.LittleEndian.Uint32(s.table[4*slot:])
+		if uint64(row) > s.header.Count {
+			return fmt.Errorf("corrupt hash table slot %d", slot)
+		}
+		if row != 0 {
+			used++
+		}
+	}
+	// Index stops at an empty slot, so there has to be one
+	if used > s.header.Count {
+		return stdErrors.New("corrupt hash table")
+	}
+	return nil
+}
+
+// Close unmaps the store. Vectors returned by the store must not be used afterwards.
+func (s *Store) Close() error {
+	if s.data == nil {
+		return nil
+	}
+	err := munmapFile(s.data)
+	s.data, s.matrix = nil, nil
+	return err
+}
+
+func (s *Store) Len() int {
+	return int(s.header.Count)
+}
+
+func (s *Store) Dim() int {
+	return int(s.header.Dim)
+}
+
+// Word returns the word stored at row i
+func (s *Store) Word(i int) string {
+	return string(s.wordBytes(i))
+}
+
+func (s *Store) wordBytes(i int) []byte {
+	start := binary.LittleEndian.Uint64(s.offsets[8*i:])
+	end := binary.LittleEndian.Uint64(s.offsets[8*(i+1):])
+	return s.vocab[start:end]
+}
+
+// Index returns the row of word, in O(1) through the on-disk hash table
+func (s *Store) Index(word string) (int, bool) {
+	mask := s.header.TableSize - 1
+	slot := hashWord(word) & mask
+	for {
+		row := binary.LittleEndian.Uint32(s.table[4*slot:])
+		if row == 0 {
+			return -1, false
+		}
+		if string(s.wordBytes(int(row-1))) == word {
+			return int(row - 1), true
+		}
+		slot = (slot + 1) & mask
+	}
+}
+
+// Vector returns row i of the matrix without copying it. The slice must not be modified.
+func (s *Store) Vector(i int) []float32 {
+	dim := s.Dim()
+	return s.matrix[i*dim : (i+1)*dim : (i+1)*dim]
+}
+
+// Lookup returns the vector of word without copying it
+func (s *Store) Lookup(word string) ([]float32, bool) {
+	i, ok := s.Index(word)
+	if !ok {
+		return nil, false
+	}
+	return s.Vector(i), true
+}
+
+// Embeddings copies the whole store into memory
+func (s *Store) Embeddings() []Embedding {
+	embs := make([]Embedding, s.Len())
+	for i := range embs {
+		embs[i] = Embedding{Word: s.Word(i), Vector: append([]float32(nil), s.Vector(i)...)}
+	}
+	return embs
+}
+
+func hashWord(word string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(word))
+	return h.Sum64()
+}
+
+func align64(n uint64) uint64 {
+	return (n + 63) &^ 63
+}
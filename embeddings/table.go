@@ -0,0 +1,178 @@
+package embeddings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+// Table is an in-memory word -> vector lookup with normalised copies for cosine similarity
+type Table struct {
+	dim     int
+	words   []string
+	vectors [][]float32
+	normed  [][]float32
+	index   map[string]int
+}
+
+// Neighbor is a word close to a query vector, Similarity is the cosine similarity
+type Neighbor struct {
+	Word       string  `json:"word"`
+	Similarity float64 `json:"similarity"`
+}
+
+// NewTable indexes embs by word. Later duplicates of a word are ignored.
+func NewTable(embs []Embedding) (*Table, error) {
+	dim, err := Dim(embs)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{dim: dim, index: make(map[string]int, len(embs))}
+	for _, e := range embs {
+		if _, ok := t.index[e.Word]; ok {
+			continue
+		}
+		t.index[e.Word] = len(t.words)
+		t.words = append(t.words, e.Word)
+		t.vectors = append(t.vectors, e.Vector)
+		t.normed = append(t.normed, vecmath.Normalized(e.Vector))
+	}
+	return t, nil
+}
+
+// LoadTable loads a vector file in any supported format into a Table
+func LoadTable(path string) (*Table, error) {
+	embs, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(embs)
+}
+
+func (t *Table) Len() int {
+	return len(t.words)
+}
+
+func (t *Table) Dim() int {
+	return t.dim
+}
+
+// Words returns the vocabulary in file order. The slice is shared, don't modify it.
+func (t *Table) Words() []string {
+	return t.words
+}
+
+// Lookup returns the vector of word
+func (t *Table) Lookup(word string) ([]float32, bool) {
+	i, ok := t.index[word]
+	if !ok {
+		return nil, false
+	}
+	return t.vectors[i], true
+}
+
+// Tokenize splits text into words the same way the trainer reads its corpus, on whitespace
+func Tokenize(text string) []string {
+	return strings.Fields(text)
+}
+
+// Embed averages the vectors of the known words in text and returns the words it had no vector for
+func (t *Table) Embed(text string) ([]float32, []string, error) {
+	sum := make([]float64, t.dim)
+	known := 0
+	var oov []string
+	for _, word := range Tokenize(text) {
+		vector, ok := t.Lookup(word)
+		if !ok {
+			oov = append(oov, word)
+			continue
+		}
+		for i, v := range vector {
+			sum[i] += float64(v)
+		}
+		known++
+	}
+	if known == 0 {
+		return nil, oov, errors.Invalid("text", "no known words in %q", text)
+	}
+	mean := make([]float32, t.dim)
+	for i := range sum {
+		mean[i] = float32(sum[i] / float64(known))
+	}
+	return mean, oov, nil
+}
+
+// Nearest returns the k words most similar to vector by cosine similarity, skipping exclude
+func (t *Table) Nearest(vector []float32, k int, exclude ...string) ([]Neighbor, error) {
+	if len(vector) != t.dim {
+		return nil, errors.DimensionMismatch("vector", len(vector), t.dim)
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, word := range exclude {
+		skip[word] = true
+	}
+	query := vecmath.Normalized(vector)
+	neighbors := make([]Neighbor, 0, len(t.words))
+	for i, normed := range t.normed {
+		if skip[t.words[i]] {
+			continue
+		}
+		var dot float64
+		for j := range normed {
+			dot += float64(normed[j]) * float64(query[j])
+		}
+		neighbors = append(neighbors, Neighbor{Word: t.words[i], Similarity: dot})
+	}
+	sort.SliceStable(neighbors, func(i, j int) bool { return neighbors[i].Similarity > neighbors[j].Similarity })
+	if k > 0 && k < len(neighbors) {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// NearestWords returns the k words most similar to word, not counting word itself
+func (t *Table) NearestWords(word string, k int) ([]Neighbor, error) {
+	vector, ok := t.Lookup(word)
+	if !ok {
+		return nil, fmt.Errorf("word %q not found", word)
+	}
+	return t.Nearest(vector, k, word)
+}
+
+// Analogy answers "a is to b as c is to ?" with the words nearest to b - a + c, using normalised vectors
+func (t *Table) Analogy(a string, b string, c string, k int) ([]Neighbor, error) {
+	query := make([]float32, t.dim)
+	for _, term := range []struct {
+		word string
+		sign float32
+	}{{a, -1}, {b, 1}, {c, 1}} {
+		i, ok := t.index[term.word]
+		if !ok {
+			return nil, fmt.Errorf("word %q not found", term.word)
+		}
+		for j, v := range t.normed[i] {
+			query[j] += term.sign * v
+		}
+	}
+	return t.Nearest(query, k, a, b, c)
+}
+
+// Similarity is the cosine similarity of two words
+func (t *Table) Similarity(a string, b string) (float64, error) {
+	i, ok := t.index[a]
+	if !ok {
+		return 0, fmt.Errorf("word %q not found", a)
+	}
+	j, ok := t.index[b]
+	if !ok {
+		return 0, fmt.Errorf("word %q not found", b)
+	}
+	var dot float64
+	for d := range t.normed[i] {
+		dot += float64(t.normed[i][d]) * float64(t.normed[j][d])
+	}
+	return dot, nil
+}
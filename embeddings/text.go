@@ -0,0 +1,132 @@
+package embeddings
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"milvus/errors"
+)
+
+func parseFloat(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	return float32(v), err
+}
+
+// maxDim bounds the dimension a header may declare, so a corrupt one can't make the readers
+// allocate gigabytes for a single vector
+const maxDim = 1 << 20
+
+// maxPrealloc bounds how many embeddings are allocated for the count a header declares, past it
+// the slice grows with the records actually read
+const maxPrealloc = 1 << 16
+
+// checkCount rejects a header declaring more records of at least recordSize bytes than a file of
+// size bytes holds. A negative size is unknown, e.g. for a stream.
+func checkCount(count int, recordSize int, size int64) error {
+	if size >= 0 && int64(count) > size/int64(recordSize) {
+		return fmt.Errorf("header declares %d words but the file holds at most %d", count, size/int64(recordSize))
+	}
+	return nil
+}
+
+// parseHeader parses the "count dim" line used by fastText and word2vec
+func parseHeader(line string) (count int, dim int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid header %q", line)
+	}
+	if count, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("invalid vocabulary size in header %q", line)
+	}
+	if dim, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("invalid dimension in header %q", line)
+	}
+	if count < 0 || dim <= 0 || dim > maxDim {
+		return 0, 0, fmt.Errorf("invalid header %q", line)
+	}
+	return count, dim, nil
+}
+
+// readText reads whitespace separated "word v1 ... vn" lines, optionally preceded by a "count dim"
+// header. size is the size of the file in bytes, or -1 if unknown.
+func readText(r io.Reader, header bool, size int64) ([]Embedding, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var embs []Embedding
+	count, dim := -1, -1
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if header && lineNum == 1 {
+			var err error
+			if count, dim, err = parseHeader(line); err != nil {
+				return nil, err
+			}
+			// "w 0 0 ... 0\n" is the shortest line
+			if err := checkCount(count, 2*dim+2, size); err != nil {
+				return nil, err
+			}
+			embs = make([]Embedding, 0, min(count, maxPrealloc))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a word followed by its vector", lineNum)
+		}
+		if dim == -1 {
+			dim = len(fields) - 1
+		}
+		if len(fields)-1 != dim {
+			return nil, errors.DimensionMismatch(fmt.Sprintf("line %d: word %q", lineNum, fields[0]), len(fields)-1, dim)
+		}
+
+		vector := make([]float32, dim)
+		for i, field := range fields[1:] {
+			v, err := parseFloat(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", lineNum, field)
+			}
+			vector[i] = v
+		}
+		embs = append(embs, Embedding{Word: fields[0], Vector: vector})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if count >= 0 && len(embs) != count {
+		return nil, fmt.Errorf("header declares %d words but file contains %d", count, len(embs))
+	}
+	return embs, nil
+}
+
+// writeText writes one "word v1 ... vn" line per embedding. wego leaves a trailing space after the last value.
+func writeText(w io.Writer, embs []Embedding, dim int, header bool, trailingSpace bool) error {
+	writer := bufio.NewWriter(w)
+	if header {
+		fmt.Fprintf(writer, "%d %d\n", len(embs), dim)
+	}
+	for _, e := range embs {
+		if strings.ContainsAny(e.Word, " \t\n") {
+			return fmt.Errorf("word %q contains whitespace", e.Word)
+		}
+		writer.WriteString(e.Word)
+		for _, v := range e.Vector {
+			writer.WriteByte(' ')
+			writer.WriteString(strconv.FormatFloat(float64(v), 'f', 6, 32))
+		}
+		if trailingSpace {
+			writer.WriteByte(' ')
+		}
+		writer.WriteByte('\n')
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,69 @@
+package embeddings
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// readWord2VecBinary reads the format written by the original word2vec tool with -binary 1. size
+// is the size of the file in bytes, or -1 if unknown.
+func readWord2VecBinary(r io.Reader, size int64) ([]Embedding, error) {
+	reader := bufio.NewReader(r)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	count, dim, err := parseHeader(line)
+	if err != nil {
+		return nil, err
+	}
+
+	// a record is at least a one byte word, a space and the vector
+	if err := checkCount(count, 4*dim+2, size); err != nil {
+		return nil, err
+	}
+	embs := make([]Embedding, 0, min(count, maxPrealloc))
+	buf := make([]byte, 4*dim)
+	for i := 0; i < count; i++ {
+		word, err := reader.ReadString(' ')
+		if err != nil {
+			return nil, fmt.Errorf("word %d: %w", i, err)
+		}
+		// Vectors are usually followed by a newline which ends up in front of the next word
+		word = strings.TrimLeft(strings.TrimSuffix(word, " "), "\n")
+
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, fmt.Errorf("vector for %q: %w", word, err)
+		}
+		vector := make([]float32, dim)
+		for j := range vector {
+			vector[j] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*j:]))
+		}
+		embs = append(embs, Embedding{Word: word, Vector: vector})
+	}
+	return embs, nil
+}
+
+func writeWord2VecBinary(w io.Writer, embs []Embedding, dim int) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "%d %d\n", len(embs), dim)
+
+	buf := make([]byte, 4*dim)
+	for _, e := range embs {
+		if strings.ContainsAny(e.Word, " \n") {
+			return fmt.Errorf("word %q contains whitespace", e.Word)
+		}
+		for j, v := range e.Vector {
+			binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(v))
+		}
+		writer.WriteString(e.Word)
+		writer.WriteByte(' ')
+		writer.Write(buf)
+		writer.WriteByte('\n')
+	}
+	return writer.Flush()
+}
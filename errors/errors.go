@@ -1,44 +1,243 @@
 package errors
 
-import "fmt"
+import (
+	stdErrors "errors"
+	"fmt"
+)
+
+// Sentinel errors, matched with the standard errors.Is through any amount of wrapping:
+//
+//	if stdErrors.Is(err, errors.ErrFileNotFound) { ... }
+//
+// ErrFile and ErrModel match every file and model error respectively.
+var (
+	ErrFile         = stdErrors.New("file error")
+	ErrFileNotFound = stdErrors.New("file not found")
+	ErrFileEmpty    = stdErrors.New("file is empty")
+	ErrFileCreation = stdErrors.New("file could not be created")
+	ErrFileLoading  = stdErrors.New("file could not be loaded")
+	ErrFileFormat   = stdErrors.New("invalid file format")
 
+	ErrModel        = stdErrors.New("model error")
+	ErrModelSearch  = stdErrors.New("model search failed")
+	ErrModelLoading = stdErrors.New("model could not be loaded")
+
+	ErrTraining          = stdErrors.New("training failed")
+	ErrDimensionMismatch = stdErrors.New("vector dimension mismatch")
+	ErrMilvus            = stdErrors.New("milvus request failed")
+	ErrValidation        = stdErrors.New("invalid input")
+)
+
+// FileError is a problem reading or writing a file. Type is one of FileNotFound, FileEmpty,
+// FileCreationError, FileLoadingError or FileFormatError.
 type FileError struct {
 	Type    string
 	Path    string
 	Message string
+	Err     error // the underlying error, if any
 }
 
 func (fe *FileError) Error() string {
 	return fmt.Sprintf("%s: %s - %s", fe.Type, fe.Path, fe.Message)
 }
 
+func (fe *FileError) Unwrap() error {
+	return fe.Err
+}
+
+var fileSentinels = map[string]error{
+	"FileNotFound":      ErrFileNotFound,
+	"FileEmpty":         ErrFileEmpty,
+	"FileCreationError": ErrFileCreation,
+	"FileLoadingError":  ErrFileLoading,
+	"FileFormatError":   ErrFileFormat,
+}
+
+func (fe *FileError) Is(target error) bool {
+	return target == ErrFile || target == fileSentinels[fe.Type]
+}
+
+// IsFileError reports whether err, or an error it wraps, is a FileError of the given type
 func IsFileError(err error, action string) bool {
-	fe, ok := err.(*FileError)
-	if !ok {
-		return false
+	var fe *FileError
+	return stdErrors.As(err, &fe) && fe.Type == action
+}
+
+// ModelError is a problem loading or searching word vectors. Type is ModelLoadingError or ModelSearchError.
+type ModelError struct {
+	Type    string
+	Path    string
+	Message string
+	Err     error
+}
+
+func (me *ModelError) Error() string {
+	return fmt.Sprintf("%s: %s - %s", me.Type, me.Path, me.Message)
+}
+
+func (me *ModelError) Unwrap() error {
+	return me.Err
+}
+
+func (me *ModelError) Is(target error) bool {
+	switch target {
+	case ErrModel:
+		return true
+	case ErrModelSearch:
+		return me.Type == "ModelSearchError"
+	case ErrModelLoading:
+		return me.Type == "ModelLoadingError"
+	}
+	return false
+}
+
+// Training stages reported by TrainingError
+const (
+	StageOpen  = "open"
+	StageTrain = "train"
+	StageSave  = "save"
+)
+
+// TrainingError is a failed training run. Stage says whether opening the corpus, training or
+// saving the vectors failed.
+type TrainingError struct {
+	Stage string
+	Path  string
+	Err   error
+}
+
+func (te *TrainingError) Error() string {
+	return fmt.Sprintf("TrainingError: %s - %s failed: %s", te.Path, te.Stage, message(te.Err))
+}
+
+func (te *TrainingError) Unwrap() error {
+	return te.Err
+}
+
+func (te *TrainingError) Is(target error) bool {
+	return target == ErrTraining
+}
+
+// DimensionError is a vector whose length doesn't match the model, index or collection it is used with
+type DimensionError struct {
+	Subject  string // what had the wrong dimension, e.g. `word "cat"` or "query"
+	Got      int
+	Expected int
+}
+
+func (de *DimensionError) Error() string {
+	return fmt.Sprintf("%s has dimension %d, expected %d", de.Subject, de.Got, de.Expected)
+}
+
+func (de *DimensionError) Is(target error) bool {
+	return target == ErrDimensionMismatch
+}
+
+// MilvusError is a request the Milvus server failed or rejected
+type MilvusError struct {
+	Op         string // e.g. "search collection"
+	Collection string
+	Err        error
+}
+
+func (me *MilvusError) Error() string {
+	if me.Collection == "" {
+		return fmt.Sprintf("failed to %s: %s", me.Op, message(me.Err))
 	}
-	return fe.Type == action
+	return fmt.Sprintf("failed to %s %s: %s", me.Op, me.Collection, message(me.Err))
+}
+
+func (me *MilvusError) Unwrap() error {
+	return me.Err
+}
+
+func (me *MilvusError) Is(target error) bool {
+	return target == ErrMilvus
+}
+
+// ValidationError is input rejected before it reached a model or Milvus
+type ValidationError struct {
+	Field   string // the offending field or parameter, if there is one
+	Message string
+}
+
+func (ve *ValidationError) Error() string {
+	return ve.Message
+}
+
+func (ve *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// TypeOf names the taxonomy type of err, or of the first error it wraps that has one: the Type
+// of a FileError or ModelError, or TrainingError, DimensionError, MilvusError or ValidationError.
+// It returns "" for errors outside the taxonomy.
+func TypeOf(err error) string {
+	for err != nil {
+		switch e := err.(type) {
+		case *FileError:
+			return e.Type
+		case *ModelError:
+			return e.Type
+		case *TrainingError:
+			return "TrainingError"
+		case *DimensionError:
+			return "DimensionError"
+		case *MilvusError:
+			return "MilvusError"
+		case *ValidationError:
+			return "ValidationError"
+		}
+		err = stdErrors.Unwrap(err)
+	}
+	return ""
+}
+
+func message(err error) string {
+	if err == nil {
+		return "unknown error"
+	}
+	return err.Error()
 }
 
 var (
 	FileNotFound = func(path string, err error) error {
-		return &FileError{Type: "FileNotFound", Path: path, Message: err.Error()}
+		return &FileError{Type: "FileNotFound", Path: path, Message: message(err), Err: err}
 	}
 	FileEmpty = func(path string, err error) error {
-		return &FileError{Type: "FileEmpty", Path: path, Message: err.Error()}
+		return &FileError{Type: "FileEmpty", Path: path, Message: message(err), Err: err}
 	}
 	FileCreationErr = func(path string, err error) error {
-		return &FileError{Type: "FileCreationError", Path: path, Message: err.Error()}
+		return &FileError{Type: "FileCreationError", Path: path, Message: message(err), Err: err}
 	}
 	FileLoadingError = func(path string, err error) error {
-		return &FileError{Type: "FileLoadingError", Path: path, Message: err.Error()}
+		return &FileError{Type: "FileLoadingError", Path: path, Message: message(err), Err: err}
+	}
+	FileFormatError = func(path string, err error) error {
+		return &FileError{Type: "FileFormatError", Path: path, Message: message(err), Err: err}
 	}
 
 	ModelSearchError = func(path string, err error) error {
-		return &FileError{Type: "FileLoadingError", Path: path, Message: err.Error()}
+		return &ModelError{Type: "ModelSearchError", Path: path, Message: message(err), Err: err}
 	}
 
 	ModelLoadingError = func(path string, err error) error {
-		return &FileError{Type: "FileLoadingError", Path: path, Message: err.Error()}
+		return &ModelError{Type: "ModelLoadingError", Path: path, Message: message(err), Err: err}
+	}
+
+	TrainingFailed = func(stage string, path string, err error) error {
+		return &TrainingError{Stage: stage, Path: path, Err: err}
+	}
+
+	DimensionMismatch = func(subject string, got int, expected int) error {
+		return &DimensionError{Subject: subject, Got: got, Expected: expected}
+	}
+
+	MilvusFailure = func(op string, collection string, err error) error {
+		return &MilvusError{Op: op, Collection: collection, Err: err}
+	}
+
+	Invalid = func(field string, format string, args ...interface{}) error {
+		return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
 	}
 )
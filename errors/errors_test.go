@@ -0,0 +1,121 @@
+package errors
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	cause := stdErrors.New("cause")
+	tests := []struct {
+		name     string
+		err      error
+		is       []error
+		isNot    []error
+		typeName string
+	}{
+		{
+			name:     "File not found",
+			err:      FileNotFound("model.txt", fs.ErrNotExist),
+			is:       []error{ErrFile, ErrFileNotFound, fs.ErrNotExist},
+			isNot:    []error{ErrFileEmpty, ErrModel},
+			typeName: "FileNotFound",
+		},
+		{
+			name:     "Wrapped file format error",
+			err:      fmt.Errorf("loading: %w", FileFormatError("model.txt", DimensionMismatch("word \"cat\"", 2, 3))),
+			is:       []error{ErrFile, ErrFileFormat, ErrDimensionMismatch},
+			isNot:    []error{ErrFileNotFound},
+			typeName: "FileFormatError",
+		},
+		{
+			name:     "Model search",
+			err:      ModelSearchError("model.txt", cause),
+			is:       []error{ErrModel, ErrModelSearch, cause},
+			isNot:    []error{ErrModelLoading, ErrFile, ErrFileLoading},
+			typeName: "ModelSearchError",
+		},
+		{
+			name:     "Model loading",
+			err:      ModelLoadingError("model.txt", cause),
+			is:       []error{ErrModel, ErrModelLoading},
+			isNot:    []error{ErrModelSearch},
+			typeName: "ModelLoadingError",
+		},
+		{
+			name:     "Training",
+			err:      TrainingFailed(StageSave, "out.txt", cause),
+			is:       []error{ErrTraining, cause},
+			isNot:    []error{ErrFile},
+			typeName: "TrainingError",
+		},
+		{
+			name:     "Milvus",
+			err:      MilvusFailure("search collection", "words", cause),
+			is:       []error{ErrMilvus, cause},
+			typeName: "MilvusError",
+		},
+		{
+			name:     "Validation",
+			err:      Invalid("k", "k must be positive"),
+			is:       []error{ErrValidation},
+			isNot:    []error{ErrMilvus},
+			typeName: "ValidationError",
+		},
+		{
+			name:     "Outside the taxonomy",
+			err:      cause,
+			isNot:    []error{ErrFile, ErrModel, ErrTraining, ErrDimensionMismatch, ErrMilvus, ErrValidation},
+			typeName: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, target := range tt.is {
+				if !stdErrors.Is(tt.err, target) {
+					t.Errorf("errors.Is(%v, %v) = false", tt.err, target)
+				}
+			}
+			for _, target := range tt.isNot {
+				if stdErrors.Is(tt.err, target) {
+					t.Errorf("errors.Is(%v, %v) = true", tt.err, target)
+				}
+			}
+			if got := TypeOf(tt.err); got != tt.typeName {
+				t.Errorf("TypeOf() = %q, want %q", got, tt.typeName)
+			}
+		})
+	}
+}
+
+func TestIsFileError(t *testing.T) {
+	err := FileEmpty("corpus.txt", stdErrors.New("Empty File"))
+	if !IsFileError(err, "FileEmpty") || !IsFileError(fmt.Errorf("train: %w", err), "FileEmpty") {
+		t.Error("IsFileError does not match a (wrapped) FileEmpty error")
+	}
+	if IsFileError(err, "FileNotFound") || IsFileError(ModelLoadingError("model.txt", err), "FileLoadingError") {
+		t.Error("IsFileError matched the wrong type")
+	}
+	if got := err.Error(); got != "FileEmpty: corpus.txt - Empty File" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestAs(t *testing.T) {
+	err := fmt.Errorf("insert: %w", DimensionMismatch("row 3: field embedding", 4, 3))
+	var de *DimensionError
+	if !stdErrors.As(err, &de) || de.Got != 4 || de.Expected != 3 {
+		t.Fatalf("errors.As did not find the DimensionError in %v", err)
+	}
+	if de.Error() != "row 3: field embedding has dimension 4, expected 3" {
+		t.Errorf("Error() = %q", de.Error())
+	}
+
+	var ve *ValidationError
+	if !stdErrors.As(Invalid("expr", "expr is required"), &ve) || ve.Field != "expr" {
+		t.Errorf("errors.As did not find the ValidationError")
+	}
+}
@@ -0,0 +1,83 @@
+package grpcserver
+
+import (
+	"fmt"
+
+	pb "milvus/api/vectorizepb"
+	"milvus/vectordb"
+)
+
+// toValue converts a field value as returned by vectordb.Rows or vectordb.Hits
+func toValue(v interface{}) (*pb.Value, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &pb.Value{Kind: &pb.Value_StringValue{StringValue: value}}, nil
+	case []byte: // JSON fields
+		return &pb.Value{Kind: &pb.Value_StringValue{StringValue: string(value)}}, nil
+	case int64:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: value}}, nil
+	case int32:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(value)}}, nil
+	case int16:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(value)}}, nil
+	case int8:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(value)}}, nil
+	case float32:
+		return &pb.Value{Kind: &pb.Value_DoubleValue{DoubleValue: float64(value)}}, nil
+	case float64:
+		return &pb.Value{Kind: &pb.Value_DoubleValue{DoubleValue: value}}, nil
+	case bool:
+		return &pb.Value{Kind: &pb.Value_BoolValue{BoolValue: value}}, nil
+	case []float32:
+		return &pb.Value{Kind: &pb.Value_VectorValue{VectorValue: &pb.FloatVector{Values: value}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported field value %T", v)
+}
+
+func toFields(fields map[string]interface{}) (map[string]*pb.Value, error) {
+	values := make(map[string]*pb.Value, len(fields))
+	for name, field := range fields {
+		value, err := toValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		if value != nil {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// fromRow converts a protobuf row to the map vectordb.ColumnsFromRows expects
+func fromRow(row *pb.Row) map[string]interface{} {
+	fields := make(map[string]interface{}, len(row.GetFields()))
+	for name, value := range row.GetFields() {
+		switch kind := value.GetKind().(type) {
+		case *pb.Value_StringValue:
+			fields[name] = kind.StringValue
+		case *pb.Value_IntValue:
+			fields[name] = kind.IntValue
+		case *pb.Value_DoubleValue:
+			fields[name] = kind.DoubleValue
+		case *pb.Value_BoolValue:
+			fields[name] = kind.BoolValue
+		case *pb.Value_VectorValue:
+			fields[name] = kind.VectorValue.GetValues()
+		}
+	}
+	return fields
+}
+
+func toHit(rank int, hit vectordb.Hit) (*pb.SearchHit, error) {
+	id, err := toValue(hit.ID)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := toFields(hit.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SearchHit{Rank: int32(rank), Id: id, Score: hit.Score, Fields: fields}, nil
+}
@@ -0,0 +1,529 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	pb "milvus/api/vectorizepb"
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/logging"
+	"milvus/tracing"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultK         = 10
+	maxK             = 1000
+	defaultBatchSize = 1000
+)
+
+// Server implements the Vectorize gRPC service. Like the REST server either backend may be nil:
+// without a Milvus client Search, Query, Insert and Upsert are Unavailable, without a vector
+// table Embed, SimilarWords and word/text searches are.
+type Server struct {
+	pb.UnimplementedVectorizeServer
+
+	milvusClient client.Client
+	table        *embeddings.Table
+	logger       *slog.Logger
+}
+
+func New(milvusClient client.Client, table *embeddings.Table) *Server {
+	return &Server{milvusClient: milvusClient, table: table, logger: logging.Discard}
+}
+
+// WithLogger logs every call, and what the vectordb layer logs during it, through logger
+func (s *Server) WithLogger(logger *slog.Logger) *Server {
+	s.logger = logging.OrDiscard(logger)
+	return s
+}
+
+// ListenAndServe serves on addr until ctx is done, then lets in-flight calls finish
+func (s *Server) ListenAndServe(addr string, ctx context.Context) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(lis, ctx)
+}
+
+func (s *Server) Serve(lis net.Listener, ctx context.Context) error {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), s.logUnary),
+		grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor(), s.logStream),
+	)
+	pb.RegisterVectorizeServer(grpcServer, s)
+
+	errs := make(chan error, 1)
+	go func() {
+		s.logger.Info("serving gRPC API", "addr", lis.Addr().String())
+		errs <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	s.logger.Info("shutting down gRPC API", "addr", lis.Addr().String())
+	grpcServer.GracefulStop()
+	return nil
+}
+
+func (s *Server) logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = callContext(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	s.logCall(ctx, start, err)
+	return resp, err
+}
+
+func (s *Server) logStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := callContext(ss.Context(), info.FullMethod)
+	err := handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+	s.logCall(ctx, start, err)
+	return err
+}
+
+// callContext tags everything logged during a call with its method and request id
+func callContext(ctx context.Context, method string) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	if requestID == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		requestID = hex.EncodeToString(b)
+	}
+	return logging.WithAttrs(ctx, slog.String("request_id", requestID), slog.String("rpc_method", method))
+}
+
+func (s *Server) logCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	switch code {
+	case codes.OK:
+		s.logger.InfoContext(ctx, "call served", "duration", time.Since(start))
+	case codes.Internal, codes.Unavailable, codes.Unknown, codes.DeadlineExceeded:
+		s.logger.ErrorContext(ctx, "call failed", "code", code.String(), "error", err, "duration", time.Since(start))
+	default:
+		s.logger.WarnContext(ctx, "call failed", "code", code.String(), "error", err, "duration", time.Since(start))
+	}
+}
+
+// loggedStream hands the tagged context to streaming handlers
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ls *loggedStream) Context() context.Context {
+	return ls.ctx
+}
+
+var (
+	errNoMilvus  = status.Error(codes.Unavailable, "the server was started without a Milvus connection")
+	errNoVectors = status.Error(codes.FailedPrecondition, "the server was started without a vector file")
+)
+
+// toStatus maps errors from the vectordb layer to gRPC status codes
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case stdErrors.Is(err, vectordb.ErrProtectedCollection):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case stdErrors.Is(err, errors.ErrValidation), stdErrors.Is(err, errors.ErrDimensionMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case stdErrors.Is(err, context.DeadlineExceeded), stdErrors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func invalid(format string, args ...interface{}) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+func (s *Server) Embed(ctx context.Context, req *pb.EmbedRequest) (*pb.EmbedResponse, error) {
+	if s.table == nil {
+		return nil, errNoVectors
+	}
+	if len(req.GetTexts()) == 0 {
+		return nil, invalid("texts is required")
+	}
+	_, span := tracing.Start(ctx, "embeddings.Embed", attribute.Int("embeddings.texts", len(req.GetTexts())))
+	defer span.End()
+	resp := &pb.EmbedResponse{Dim: int32(s.table.Dim())}
+	for i, text := range req.GetTexts() {
+		vector, oov, err := s.table.Embed(text)
+		if err != nil {
+			return nil, invalid("texts[%d]: %s", i, err)
+		}
+		resp.Embeddings = append(resp.Embeddings, &pb.Embedding{Text: text, Vector: &pb.FloatVector{Values: vector}, Oov: oov})
+	}
+	return resp, nil
+}
+
+func (s *Server) SimilarWords(ctx context.Context, req *pb.SimilarWordsRequest) (*pb.SimilarWordsResponse, error) {
+	if s.table == nil {
+		return nil, errNoVectors
+	}
+	k, err := topK(req.GetK())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		vector  []float32
+		exclude []string
+	)
+	switch query := req.GetQuery().(type) {
+	case *pb.SimilarWordsRequest_Word:
+		v, ok := s.table.Lookup(query.Word)
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "word %q is not in the vocabulary", query.Word)
+		}
+		vector, exclude = v, []string{query.Word}
+	case *pb.SimilarWordsRequest_Text:
+		v, _, err := s.table.Embed(query.Text)
+		if err != nil {
+			return nil, invalid("%s", err)
+		}
+		vector, exclude = v, embeddings.Tokenize(query.Text)
+	case *pb.SimilarWordsRequest_Vector:
+		vector = query.Vector.GetValues()
+	default:
+		return nil, invalid("one of word, text or vector is required")
+	}
+
+	_, span := tracing.Start(ctx, "embeddings.Nearest", tracing.TopK(k))
+	neighbors, err := s.table.Nearest(vector, k, exclude...)
+	tracing.End(span, &err)
+	if err != nil {
+		return nil, invalid("%s", err)
+	}
+	resp := &pb.SimilarWordsResponse{}
+	for _, n := range neighbors {
+		resp.Neighbors = append(resp.Neighbors, &pb.Neighbor{Word: n.Word, Similarity: n.Similarity})
+	}
+	return resp, nil
+}
+
+func (s *Server) Search(req *pb.SearchRequest, stream pb.Vectorize_SearchServer) error {
+	if s.milvusClient == nil {
+		return errNoMilvus
+	}
+	ctx := stream.Context()
+	if req.GetCollection() == "" {
+		return invalid("collection is required")
+	}
+	k, err := topK(req.GetTopK())
+	if err != nil {
+		return err
+	}
+
+	var vector []float32
+	switch query := req.GetQuery().(type) {
+	case *pb.SearchRequest_Vector:
+		vector = query.Vector.GetValues()
+	case *pb.SearchRequest_Word:
+		if s.table == nil {
+			return errNoVectors
+		}
+		v, ok := s.table.Lookup(query.Word)
+		if !ok {
+			return status.Errorf(codes.NotFound, "word %q is not in the vocabulary", query.Word)
+		}
+		vector = v
+	case *pb.SearchRequest_Text:
+		if s.table == nil {
+			return errNoVectors
+		}
+		if vector, _, err = s.table.Embed(query.Text); err != nil {
+			return invalid("%s", err)
+		}
+	default:
+		return invalid("one of vector, word or text is required")
+	}
+
+	metric := entity.MetricType(strings.ToUpper(orDefault(req.GetMetric(), "L2")))
+	params := make(map[string]int, len(req.GetParams()))
+	for key, value := range req.GetParams() {
+		params[key] = int(value)
+	}
+	_, sp, err := vectordb.NewIndex(orDefault(req.GetIndexType(), "FLAT"), metric, params)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	vectorField := req.GetVectorField()
+	if vectorField == "" {
+		info, err := vectordb.DescribeCollection(s.milvusClient, req.GetCollection(), ctx)
+		if err != nil {
+			return toStatus(err)
+		}
+		field, ok := info.VectorField()
+		if !ok {
+			return invalid("collection %s has no float vector field", req.GetCollection())
+		}
+		vectorField = field.Name
+	}
+
+	results, err := vectordb.Search(s.milvusClient, vectordb.SearchParams{
+		CollectionName: req.GetCollection(),
+		PartitionNames: req.GetPartitions(),
+		Expr:           req.GetExpr(),
+		OutputFields:   req.GetOutputFields(),
+		VectorField:    vectorField,
+		Vectors:        []entity.Vector{entity.FloatVector(vector)},
+		Metric:         metric,
+		TopK:           k,
+		SearchParam:    sp,
+	}, ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	for i, hit := range vectordb.Hits(results[0]) {
+		msg, err := toHit(i+1, hit)
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Query(req *pb.QueryRequest, stream pb.Vectorize_QueryServer) error {
+	if s.milvusClient == nil {
+		return errNoMilvus
+	}
+	if req.GetCollection() == "" || strings.TrimSpace(req.GetExpr()) == "" {
+		return invalid("collection and expr are required")
+	}
+	if req.GetBatchSize() < 0 || req.GetLimit() < 0 {
+		return invalid("batch_size and limit must not be negative")
+	}
+	batchSize := req.GetBatchSize()
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+
+	// Page through so large results never sit in memory at once. Milvus refuses offset+limit past
+	// 16384, so as in vectordb.ScanWords each page starts after the largest primary key of the last.
+	info, err := vectordb.DescribeCollection(s.milvusClient, req.GetCollection(), stream.Context())
+	if err != nil {
+		return toStatus(err)
+	}
+	pk, ok := info.PrimaryField()
+	if !ok {
+		return invalid("collection %s has no primary key", req.GetCollection())
+	}
+	outputFields, stripKey := req.GetOutputFields(), false
+	if len(outputFields) > 0 && !containsString(outputFields, pk.Name) && !containsString(outputFields, "*") {
+		outputFields = append(append([]string(nil), outputFields...), pk.Name)
+		stripKey = true
+	}
+
+	var sent int64
+	var last interface{}
+	for {
+		pageSize := batchSize
+		if limit := req.GetLimit(); limit > 0 && limit-sent < pageSize {
+			pageSize = limit - sent
+		}
+		if pageSize == 0 {
+			return nil
+		}
+		expr := req.GetExpr()
+		if last != nil {
+			after, err := keyAfter(pk.Name, last)
+			if err != nil {
+				return toStatus(err)
+			}
+			expr = fmt.Sprintf("(%s) && %s", expr, after)
+		}
+		columns, err := vectordb.Query(s.milvusClient, vectordb.QueryParams{
+			CollectionName: req.GetCollection(),
+			PartitionNames: req.GetPartitions(),
+			Expr:           expr,
+			OutputFields:   outputFields,
+			Limit:          pageSize,
+		}, stream.Context())
+		if err != nil {
+			return toStatus(err)
+		}
+		rows := vectordb.Rows(columns)
+		for _, row := range rows {
+			if key := row[pk.Name]; last == nil || keyGreater(key, last) {
+				last = key
+			}
+			if stripKey {
+				delete(row, pk.Name)
+			}
+			fields, err := toFields(row)
+			if err != nil {
+				return toStatus(err)
+			}
+			if err := stream.Send(&pb.Row{Fields: fields}); err != nil {
+				return err
+			}
+		}
+		sent += int64(len(rows))
+		if int64(len(rows)) < pageSize {
+			return nil
+		}
+	}
+}
+
+// keyAfter is the expression for the rows whose primary key, an Int64 or a VarChar, is past last
+func keyAfter(field string, last interface{}) (string, error) {
+	switch key := last.(type) {
+	case int64:
+		return fmt.Sprintf("%s > %d", field, key), nil
+	case string:
+		quoted, ok := vectordb.QuoteString(key)
+		if !ok {
+			return "", errors.Invalid(field, "primary key %q can't be written in a Milvus expression, the query can't go past it", key)
+		}
+		return fmt.Sprintf("%s > %s", field, quoted), nil
+	}
+	return "", errors.Invalid(field, "unsupported primary key %v", last)
+}
+
+// keyGreater compares two primary keys of the same type
+func keyGreater(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case int64:
+		b, _ := b.(int64)
+		return a > b
+	case string:
+		b, _ := b.(string)
+		return a > b
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) Insert(stream pb.Vectorize_InsertServer) error {
+	return s.write(stream, false)
+}
+
+func (s *Server) Upsert(stream pb.Vectorize_UpsertServer) error {
+	return s.write(stream, true)
+}
+
+// writeStream is what the Insert and Upsert streams have in common
+type writeStream interface {
+	Recv() (*pb.WriteRequest, error)
+	SendAndClose(*pb.WriteResponse) error
+	Context() context.Context
+}
+
+// write inserts every received message as one batch, against the schema of the first message's collection
+func (s *Server) write(stream writeStream, upsert bool) error {
+	if s.milvusClient == nil {
+		return errNoMilvus
+	}
+	ctx := stream.Context()
+	var (
+		collection string
+		info       *vectordb.CollectionInfo
+		resp       = &pb.WriteResponse{}
+	)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			if info != nil {
+				if err := s.milvusClient.Flush(ctx, collection, false); err != nil {
+					return toStatus(fmt.Errorf("failed to flush %s: %w", collection, err))
+				}
+			}
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		if info == nil {
+			if collection = req.GetCollection(); collection == "" {
+				return invalid("the first message must name the collection")
+			}
+			if info, err = vectordb.DescribeCollection(s.milvusClient, collection, ctx); err != nil {
+				return toStatus(err)
+			}
+		} else if req.GetCollection() != "" && req.GetCollection() != collection {
+			return invalid("a stream can only write to one collection, got %s after %s", req.GetCollection(), collection)
+		}
+		if len(req.GetRows()) == 0 {
+			continue
+		}
+
+		rows := make([]map[string]interface{}, len(req.GetRows()))
+		for i, row := range req.GetRows() {
+			rows[i] = fromRow(row)
+		}
+		columns, err := vectordb.ColumnsFromRows(info, rows)
+		if err != nil {
+			return invalid("batch %d: %s", resp.Batches+1, err)
+		}
+		if err := vectordb.Write(s.milvusClient, collection, req.GetPartition(), upsert, columns, ctx); err != nil {
+			return toStatus(fmt.Errorf("failed to write batch %d to %s: %w", resp.Batches+1, collection, err))
+		}
+		resp.Count += int64(len(rows))
+		resp.Batches++
+	}
+}
+
+func topK(k int32) (int, error) {
+	if k == 0 {
+		return defaultK, nil
+	}
+	if k < 0 || k > maxK {
+		return 0, invalid("k must be between 1 and %d", maxK)
+	}
+	return int(k), nil
+}
+
+func orDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
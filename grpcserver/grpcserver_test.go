@@ -0,0 +1,147 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "milvus/api/vectorizepb"
+	"milvus/embeddings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const validModelPath = "../tests/mockdata/word_vector.txt"
+
+// newTestClient serves a Server without Milvus over an in-memory connection
+func newTestClient(t *testing.T) pb.VectorizeClient {
+	t.Helper()
+	table, err := embeddings.LoadTable(validModelPath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(nil, table).Serve(lis, ctx) }()
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	})
+	return pb.NewVectorizeClient(conn)
+}
+
+func TestEmbed(t *testing.T) {
+	c := newTestClient(t)
+
+	resp, err := c.Embed(context.Background(), &pb.EmbedRequest{Texts: []string{"cat dog", "cat zzzunknown"}})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(resp.GetEmbeddings()) != 2 {
+		t.Fatalf("got %d embeddings, want 2", len(resp.GetEmbeddings()))
+	}
+	if got := len(resp.GetEmbeddings()[0].GetVector().GetValues()); got != int(resp.GetDim()) {
+		t.Errorf("vector has %d values, dim is %d", got, resp.GetDim())
+	}
+	if oov := resp.GetEmbeddings()[1].GetOov(); len(oov) != 1 || oov[0] != "zzzunknown" {
+		t.Errorf("oov = %v, want [zzzunknown]", oov)
+	}
+
+	_, err = c.Embed(context.Background(), &pb.EmbedRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty request: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestSimilarWords(t *testing.T) {
+	c := newTestClient(t)
+
+	tests := []struct {
+		name  string
+		req   *pb.SimilarWordsRequest
+		code  codes.Code
+		count int
+	}{
+		{name: "Word", req: &pb.SimilarWordsRequest{Query: &pb.SimilarWordsRequest_Word{Word: "cat"}, K: 3}, code: codes.OK, count: 3},
+		{name: "Text", req: &pb.SimilarWordsRequest{Query: &pb.SimilarWordsRequest_Text{Text: "cat dog"}}, code: codes.OK, count: 10},
+		{name: "Unknown word", req: &pb.SimilarWordsRequest{Query: &pb.SimilarWordsRequest_Word{Word: "zzzunknown"}}, code: codes.NotFound},
+		{name: "Wrong dimension", req: &pb.SimilarWordsRequest{Query: &pb.SimilarWordsRequest_Vector{Vector: &pb.FloatVector{Values: []float32{1, 2}}}}, code: codes.InvalidArgument},
+		{name: "No query", req: &pb.SimilarWordsRequest{}, code: codes.InvalidArgument},
+		{name: "K too large", req: &pb.SimilarWordsRequest{Query: &pb.SimilarWordsRequest_Word{Word: "cat"}, K: 5000}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := c.SimilarWords(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Fatalf("got %v, want %v", err, tt.code)
+			}
+			if err == nil && len(resp.GetNeighbors()) != tt.count {
+				t.Errorf("got %d neighbours, want %d", len(resp.GetNeighbors()), tt.count)
+			}
+		})
+	}
+}
+
+func TestWithoutMilvus(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	search, err := c.Search(ctx, &pb.SearchRequest{Collection: "words", Query: &pb.SearchRequest_Word{Word: "cat"}})
+	if err == nil {
+		_, err = search.Recv()
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Search: got %v, want Unavailable", err)
+	}
+
+	insert, err := c.Insert(ctx)
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	insert.Send(&pb.WriteRequest{Collection: "words"})
+	if _, err := insert.CloseAndRecv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Insert: got %v, want Unavailable", err)
+	}
+}
+
+func TestRowRoundTrip(t *testing.T) {
+	fields := map[string]interface{}{
+		"word":      "cat",
+		"count":     int64(3),
+		"score":     0.5,
+		"seen":      true,
+		"embedding": []float32{0.1, 0.2},
+	}
+	values, err := toFields(fields)
+	if err != nil {
+		t.Fatalf("toFields: %v", err)
+	}
+	back := fromRow(&pb.Row{Fields: values})
+	if back["word"] != "cat" || back["count"] != int64(3) || back["score"] != 0.5 || back["seen"] != true {
+		t.Errorf("scalars did not round trip: %v", back)
+	}
+	if v := back["embedding"].([]float32); len(v) != 2 || v[1] != 0.2 {
+		t.Errorf("vector did not round trip: %v", back["embedding"])
+	}
+	if _, err := toValue(struct{}{}); err == nil {
+		t.Error("expected an error for an unsupported value")
+	}
+}
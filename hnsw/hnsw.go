@@ -0,0 +1,414 @@
+package hnsw
+
+import (
+	"container/heap"
+	stdErrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+/*
+	Hierarchical Navigable Small World graph - https://arxiv.org/abs/1603.09320
+
+	A local, pure Go approximate nearest neighbour index for when Milvus isn't available.
+	Same knobs as Milvus' HNSW index:
+
+	- M               max neighbours per node on the upper layers (2*M on layer 0)
+	- EfConstruction  candidate list size while inserting, higher = better graph, slower build
+	- EfSearch        candidate list size while searching, higher = better recall, slower queries
+*/
+
+type Metric string
+
+const (
+	L2     Metric = "L2"
+	IP     Metric = "IP"
+	Cosine Metric = "COSINE"
+)
+
+type Config struct {
+	M              int
+	EfConstruction int
+	EfSearch       int
+	Metric         Metric
+	Seed           int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Metric:         L2,
+		Seed:           42,
+	}
+}
+
+// Score follows Milvus: squared distance for L2 (lower is closer), similarity for IP and COSINE (higher is closer)
+type Result struct {
+	ID    int
+	Word  string
+	Score float32
+}
+
+type node struct {
+	friends [][]uint32 // friends[level] = neighbour ids on that level
+}
+
+type Index struct {
+	mu       sync.RWMutex
+	config   Config
+	dim      int
+	levelMul float64
+	rng      *rand.Rand
+
+	words    []string
+	wordIDs  map[string]int
+	vectors  [][]float32
+	nodes    []node
+	entry    int
+	maxLevel int
+}
+
+func New(dim int, config Config) (*Index, error) {
+	if dim <= 0 {
+		return nil, errors.Invalid("dim", "invalid dimension %d", dim)
+	}
+	if config.M < 2 {
+		return nil, errors.Invalid("M", "M must be at least 2, got %d", config.M)
+	}
+	if config.EfConstruction < config.M {
+		config.EfConstruction = config.M
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = DefaultConfig().EfSearch
+	}
+	switch config.Metric {
+	case "":
+		config.Metric = L2
+	case L2, IP, Cosine:
+	default:
+		return nil, errors.Invalid("metric", "unsupported metric %q", config.Metric)
+	}
+
+	return &Index{
+		config:   config,
+		dim:      dim,
+		levelMul: 1 / math.Log(float64(config.M)),
+		rng:      rand.New(rand.NewSource(config.Seed)),
+		wordIDs:  make(map[string]int),
+		entry:    -1,
+	}, nil
+}
+
+// Build creates an index over all embeddings
+func Build(embs []embeddings.Embedding, config Config) (*Index, error) {
+	dim, err := embeddings.Dim(embs)
+	if err != nil {
+		return nil, err
+	}
+	if len(embs) == 0 {
+		return nil, stdErrors.New("no embeddings to index")
+	}
+	idx, err := New(dim, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range embs {
+		if err := idx.Add(e.Word, e.Vector); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// BuildFromFile creates an index from a vector file in any format the embeddings package reads
+func BuildFromFile(path string, config Config) (*Index, error) {
+	embs, err := embeddings.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return Build(embs, config)
+}
+
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.vectors)
+}
+
+func (idx *Index) Dim() int {
+	return idx.dim
+}
+
+func (idx *Index) Config() Config {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.config
+}
+
+// SetEfSearch changes the search time candidate list size
+func (idx *Index) SetEfSearch(ef int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if ef > 0 {
+		idx.config.EfSearch = ef
+	}
+}
+
+// Add inserts a word and its vector into the graph
+func (idx *Index) Add(word string, vector []float32) error {
+	if len(vector) != idx.dim {
+		return errors.DimensionMismatch(fmt.Sprintf("word %q", word), len(vector), idx.dim)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.wordIDs[word]; ok {
+		return fmt.Errorf("word %q is already indexed", word)
+	}
+	vector = idx.prepare(vector)
+
+	id := len(idx.vectors)
+	level := int(math.Floor(-math.Log(1-idx.rng.Float64()) * idx.levelMul))
+	idx.words = append(idx.words, word)
+	idx.wordIDs[word] = id
+	idx.vectors = append(idx.vectors, vector)
+	idx.nodes = append(idx.nodes, node{friends: make([][]uint32, level+1)})
+
+	if idx.entry == -1 {
+		idx.entry, idx.maxLevel = id, level
+		return nil
+	}
+
+	// Greedy descent through the layers above the new node's level
+	current := idx.entry
+	currentDist := idx.distance(vector, idx.vectors[current])
+	for l := idx.maxLevel; l > level; l-- {
+		current, currentDist = idx.greedyClosest(vector, current, currentDist, l)
+	}
+
+	for l := minInt(level, idx.maxLevel); l >= 0; l-- {
+		candidates := idx.searchLayer(vector, []candidate{{id: current, dist: currentDist}}, idx.config.EfConstruction, l)
+		neighbours := idx.selectNeighbours(candidates, idx.config.M)
+
+		idx.nodes[id].friends[l] = make([]uint32, 0, len(neighbours))
+		for _, n := range neighbours {
+			idx.nodes[id].friends[l] = append(idx.nodes[id].friends[l], uint32(n.id))
+			idx.link(n.id, id, l)
+		}
+		current, currentDist = candidates[0].id, candidates[0].dist
+	}
+
+	if level > idx.maxLevel {
+		idx.entry, idx.maxLevel = id, level
+	}
+	return nil
+}
+
+// link adds id to the friends of n on level l, pruning n's list if it grew too long
+func (idx *Index) link(n int, id int, l int) {
+	friends := append(idx.nodes[n].friends[l], uint32(id))
+	limit := idx.config.M
+	if l == 0 {
+		limit = 2 * idx.config.M
+	}
+	if len(friends) > limit {
+		candidates := make([]candidate, len(friends))
+		for i, f := range friends {
+			candidates[i] = candidate{id: int(f), dist: idx.distance(idx.vectors[n], idx.vectors[f])}
+		}
+		sortCandidates(candidates)
+		kept := idx.selectNeighbours(candidates, limit)
+		friends = friends[:0]
+		for _, c := range kept {
+			friends = append(friends, uint32(c.id))
+		}
+	}
+	idx.nodes[n].friends[l] = friends
+}
+
+// selectNeighbours implements the diversity heuristic from the paper: a candidate is kept only if it is
+// closer to the query than to any neighbour already kept. Candidates must be sorted by distance.
+func (idx *Index) selectNeighbours(candidates []candidate, m int) []candidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+	selected := make([]candidate, 0, m)
+	var skipped []candidate
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if idx.distance(idx.vectors[c.id], idx.vectors[s.id]) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+	// Fill up with the closest discarded candidates so nodes keep a full neighbour list
+	for _, c := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+func (idx *Index) greedyClosest(vector []float32, current int, currentDist float32, level int) (int, float32) {
+	for changed := true; changed; {
+		changed = false
+		for _, f := range idx.nodes[current].friends[level] {
+			if d := idx.distance(vector, idx.vectors[f]); d < currentDist {
+				current, currentDist, changed = int(f), d, true
+			}
+		}
+	}
+	return current, currentDist
+}
+
+// searchLayer returns up to ef closest nodes found on level, sorted by distance
+func (idx *Index) searchLayer(vector []float32, entries []candidate, ef int, level int) []candidate {
+	visited := make(map[int]struct{}, ef*4)
+	toVisit := &minHeap{}
+	found := &maxHeap{}
+	for _, e := range entries {
+		visited[e.id] = struct{}{}
+		heap.Push(toVisit, e)
+		heap.Push(found, e)
+	}
+
+	for toVisit.Len() > 0 {
+		closest := heap.Pop(toVisit).(candidate)
+		if found.Len() >= ef && closest.dist > (*found)[0].dist {
+			break
+		}
+		for _, f := range idx.nodes[closest.id].friends[level] {
+			if _, seen := visited[int(f)]; seen {
+				continue
+			}
+			visited[int(f)] = struct{}{}
+			d := idx.distance(vector, idx.vectors[f])
+			if found.Len() < ef || d < (*found)[0].dist {
+				heap.Push(toVisit, candidate{id: int(f), dist: d})
+				heap.Push(found, candidate{id: int(f), dist: d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	results := make([]candidate, found.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(found).(candidate)
+	}
+	return results
+}
+
+// Search returns the k nearest neighbours of query, closest first
+func (idx *Index) Search(query []float32, k int) ([]Result, error) {
+	if len(query) != idx.dim {
+		return nil, errors.DimensionMismatch("query", len(query), idx.dim)
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.search(idx.prepare(query), k, -1), nil
+}
+
+// SearchWord returns the k nearest neighbours of an indexed word, excluding the word itself
+func (idx *Index) SearchWord(word string, k int) ([]Result, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, ok := idx.wordIDs[word]
+	if !ok {
+		return nil, fmt.Errorf("word %q is not indexed", word)
+	}
+	return idx.search(idx.vectors[id], k, id), nil
+}
+
+func (idx *Index) search(query []float32, k int, exclude int) []Result {
+	if idx.entry == -1 || k <= 0 {
+		return nil
+	}
+	current := idx.entry
+	currentDist := idx.distance(query, idx.vectors[current])
+	for l := idx.maxLevel; l > 0; l-- {
+		current, currentDist = idx.greedyClosest(query, current, currentDist, l)
+	}
+
+	ef := idx.config.EfSearch
+	if ef < k+1 {
+		ef = k + 1
+	}
+	candidates := idx.searchLayer(query, []candidate{{id: current, dist: currentDist}}, ef, 0)
+
+	results := make([]Result, 0, k)
+	for _, c := range candidates {
+		if c.id == exclude {
+			continue
+		}
+		if len(results) == k {
+			break
+		}
+		results = append(results, Result{ID: c.id, Word: idx.words[c.id], Score: idx.score(c.dist)})
+	}
+	return results
+}
+
+// Vector returns the stored (normalised for cosine) vector of a word
+func (idx *Index) Vector(word string) ([]float32, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, ok := idx.wordIDs[word]
+	if !ok {
+		return nil, false
+	}
+	return idx.vectors[id], true
+}
+
+func (idx *Index) prepare(vector []float32) []float32 {
+	prepared := append([]float32(nil), vector...)
+	if idx.config.Metric == Cosine {
+		vecmath.Normalize(prepared)
+	}
+	return prepared
+}
+
+// distance is always "smaller is closer", score converts it back to what the metric reports
+func (idx *Index) distance(a []float32, b []float32) float32 {
+	switch idx.config.Metric {
+	case IP, Cosine:
+		return -vecmath.Dot(a, b)
+	}
+	return vecmath.SquaredL2(a, b)
+}
+
+func (idx *Index) score(dist float32) float32 {
+	if idx.config.Metric == L2 {
+		return dist
+	}
+	return -dist
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,192 @@
+package hnsw
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+const (
+	validModelPath = "../tests/mockdata/word_vector.txt"
+	unknownPath    = "imaginary/path/to/invalid/index.hnsw"
+)
+
+func randomVectors(n int, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()
+		}
+	}
+	return vectors
+}
+
+func bruteForce(vectors [][]float32, query []float32, k int) []int {
+	ids := make([]int, len(vectors))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return vecmath.SquaredL2(query, vectors[ids[a]]) < vecmath.SquaredL2(query, vectors[ids[b]])
+	})
+	return ids[:k]
+}
+
+func TestRecall(t *testing.T) {
+	const (
+		n   = 2000
+		dim = 16
+		k   = 10
+	)
+	vectors := randomVectors(n, dim, 1)
+	queries := randomVectors(100, dim, 2)
+
+	tests := []struct {
+		name      string
+		efSearch  int
+		minRecall float64
+	}{
+		{name: "ef=16", efSearch: 16, minRecall: 0.7},
+		{name: "ef=128", efSearch: 128, minRecall: 0.95},
+	}
+
+	idx, err := New(dim, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vectors {
+		if err := idx.Add(fmt.Sprint(i), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx.SetEfSearch(tt.efSearch)
+			hits := 0
+			for _, q := range queries {
+				truth := make(map[int]bool)
+				for _, id := range bruteForce(vectors, q, k) {
+					truth[id] = true
+				}
+				results, err := idx.Search(q, k)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, r := range results {
+					if truth[r.ID] {
+						hits++
+					}
+				}
+			}
+			recall := float64(hits) / float64(len(queries)*k)
+			if recall < tt.minRecall {
+				t.Errorf("recall@%d = %.3f, expected at least %.2f", k, recall, tt.minRecall)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	idx, err := BuildFromFile(validModelPath, Config{M: 4, EfConstruction: 16, EfSearch: 16, Metric: Cosine})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	before, err := idx.SearchWord("cat", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before) != 5 {
+		t.Fatalf("expected 5 neighbours, got %d", len(before))
+	}
+	for _, r := range before {
+		if r.Word == "cat" {
+			t.Errorf("search for a word returned the word itself")
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "words.hnsw")
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	after, err := loaded.SearchWord("cat", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("result %d changed after reload: %+v vs %+v", i, before[i], after[i])
+		}
+	}
+
+	if _, err := Load(unknownPath); !errors.IsFileError(err, "FileNotFound") {
+		t.Errorf("expected FileNotFound, got %v", err)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	idx, err := BuildFromFile(validModelPath, Config{M: 4, EfConstruction: 16, EfSearch: 16, Metric: L2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotOf := func() snapshot {
+		snap := snapshot{Version: formatVersion, Config: idx.config, Dim: idx.dim, Words: idx.words,
+			Vectors: append([][]float32(nil), idx.vectors...), Friends: make([][][]uint32, len(idx.nodes)),
+			Entry: idx.entry, MaxLevel: idx.maxLevel}
+		for i, n := range idx.nodes {
+			snap.Friends[i] = append([][]uint32(nil), n.friends...)
+		}
+		return snap
+	}
+	// a node on level 0 only, other than the entry point
+	low := 0
+	for low == idx.entry || len(idx.nodes[low].friends) != 1 {
+		low++
+	}
+
+	tests := map[string]func(s *snapshot){
+		"M below 2":           func(s *snapshot) { s.Config.M = 1 },
+		"no metric":           func(s *snapshot) { s.Config.Metric = "" },
+		"entry past the end":  func(s *snapshot) { s.Entry = len(s.Words) },
+		"no entry":            func(s *snapshot) { s.Entry = -1 },
+		"short vector":        func(s *snapshot) { s.Vectors[3] = s.Vectors[3][:1] },
+		"missing node":        func(s *snapshot) { s.Friends = s.Friends[1:] },
+		"max level":           func(s *snapshot) { s.MaxLevel++ },
+		"node above max":      func(s *snapshot) { s.Friends[low] = make([][]uint32, s.MaxLevel+2) },
+		"node without levels": func(s *snapshot) { s.Friends[low] = nil },
+		"friend past the end": func(s *snapshot) { s.Friends[low] = [][]uint32{{uint32(len(s.Words))}} },
+		"friend below the level": func(s *snapshot) {
+			s.Friends[s.Entry] = append([][]uint32(nil), s.Friends[s.Entry]...)
+			s.Friends[s.Entry][s.MaxLevel] = []uint32{uint32(low)}
+		},
+	}
+	for name, corrupt := range tests {
+		snap := snapshotOf()
+		corrupt(&snap)
+		path := filepath.Join(t.TempDir(), "words.hnsw")
+		output, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gob.NewEncoder(output).Encode(&snap); err != nil {
+			t.Fatal(err)
+		}
+		output.Close()
+		if _, err := Load(path); !errors.IsFileError(err, "FileFormatError") {
+			t.Errorf("%s: expected a FileFormatError, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package hnsw
+
+import (
+	"bufio"
+	"encoding/gob"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+
+	"milvus/atomicfile"
+	"milvus/errors"
+)
+
+const formatVersion = 1
+
+// snapshot is the on-disk form of an Index
+type snapshot struct {
+	Version  int
+	Config   Config
+	Dim      int
+	Words    []string
+	Vectors  [][]float32
+	Friends  [][][]uint32
+	Entry    int
+	MaxLevel int
+}
+
+// Save persists the index, including the graph, so it can be reloaded without rebuilding
+func (idx *Index) Save(path string) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	snap := snapshot{
+		Version:  formatVersion,
+		Config:   idx.config,
+		Dim:      idx.dim,
+		Words:    idx.words,
+		Vectors:  idx.vectors,
+		Friends:  make([][][]uint32, len(idx.nodes)),
+		Entry:    idx.entry,
+		MaxLevel: idx.maxLevel,
+	}
+	for i, n := range idx.nodes {
+		snap.Friends[i] = n.friends
+	}
+
+	return atomicfile.Write(path, func(w io.Writer) error {
+		writer := bufio.NewWriter(w)
+		if err := gob.NewEncoder(writer).Encode(&snap); err != nil {
+			return err
+		}
+		return writer.Flush()
+	})
+}
+
+// Load reads an index written by Save
+func Load(path string) (*Index, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer input.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(input)).Decode(&snap); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	if snap.Version != formatVersion {
+		return nil, errors.FileFormatError(path, fmt.Errorf("unsupported index version %d", snap.Version))
+	}
+	if err := snap.check(); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+
+	idx := &Index{
+		config:   snap.Config,
+		dim:      snap.Dim,
+		levelMul: 1 / math.Log(float64(snap.Config.M)),
+		rng:      rand.New(rand.NewSource(snap.Config.Seed + int64(len(snap.Words)))),
+		words:    snap.Words,
+		wordIDs:  make(map[string]int, len(snap.Words)),
+		vectors:  snap.Vectors,
+		nodes:    make([]node, len(snap.Friends)),
+		entry:    snap.Entry,
+		maxLevel: snap.MaxLevel,
+	}
+	for i, word := range snap.Words {
+		idx.wordIDs[word] = i
+		idx.nodes[i] = node{friends: snap.Friends[i]}
+	}
+	return idx, nil
+}
+
+// check validates a decoded snapshot against what searching and adding to it index, so a corrupt
+// file is an error here rather than a panic later
+func (snap *snapshot) check() error {
+	if snap.Config.M < 2 || snap.Config.EfConstruction < 1 || snap.Config.EfSearch < 1 {
+		return fmt.Errorf("invalid parameters M=%d efConstruction=%d efSearch=%d", snap.Config.M, snap.Config.EfConstruction, snap.Config.EfSearch)
+	}
+	switch snap.Config.Metric {
+	case L2, IP, Cosine:
+	default:
+		return fmt.Errorf("unsupported metric %q", snap.Config.Metric)
+	}
+	if snap.Dim <= 0 {
+		return fmt.Errorf("invalid dimension %d", snap.Dim)
+	}
+	n := len(snap.Words)
+	if len(snap.Vectors) != n || len(snap.Friends) != n {
+		return fmt.Errorf("%d words, %d vectors and %d nodes", n, len(snap.Vectors), len(snap.Friends))
+	}
+	if n == 0 {
+		if snap.Entry != -1 {
+			return fmt.Errorf("entry point %d in an empty index", snap.Entry)
+		}
+		return nil
+	}
+	if snap.Entry < 0 || snap.Entry >= n {
+		return fmt.Errorf("entry point %d out of %d nodes", snap.Entry, n)
+	}
+	if snap.MaxLevel < 0 || len(snap.Friends[snap.Entry]) != snap.MaxLevel+1 {
+		return fmt.Errorf("entry point has %d levels but the index has %d", len(snap.Friends[snap.Entry]), snap.MaxLevel+1)
+	}
+
+	for i, levels := range snap.Friends {
+		if len(snap.Vectors[i]) != snap.Dim {
+			return fmt.Errorf("vector %d has %d dimensions, want %d", i, len(snap.Vectors[i]), snap.Dim)
+		}
+		if len(levels) == 0 || len(levels) > snap.MaxLevel+1 {
+			return fmt.Errorf("node %d has %d levels, want 1 to %d", i, len(levels), snap.MaxLevel+1)
+		}
+		// searching level l goes on to the friends' own lists of that level
+		for l, friends := range levels {
+			for _, f := range friends {
+				if int(f) >= n || len(snap.Friends[f]) <= l {
+					return fmt.Errorf("node %d links to node %d on level %d, which isn't there", i, f, l)
+				}
+			}
+		}
+	}
+	return nil
+}
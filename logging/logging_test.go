@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		level   string
+		wantErr bool
+		want    string // substring of the output after logging one info and one debug record
+	}{
+		{name: "Text", format: "text", level: "info", want: `level=INFO msg="created collection" collection=words`},
+		{name: "JSON", format: "json", level: "info", want: `"msg":"created collection","collection":"words"`},
+		{name: "Debug level", format: "text", level: "debug", want: "msg=detail"},
+		{name: "Level filters", format: "text", level: "error", want: ""},
+		{name: "Invalid level", format: "text", level: "loud", wantErr: true},
+		{name: "Invalid format", format: "xml", level: "info", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := New(&buf, tt.format, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			logger.Info("created collection", "collection", "words")
+			logger.Debug("detail")
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output %q does not contain %q", buf.String(), tt.want)
+			}
+			if tt.want == "" && buf.Len() > 0 {
+				t.Errorf("expected no output, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "json", "info")
+
+	ctx := WithAttrs(context.Background(), slog.String("request_id", "abc"))
+	ctx = WithAttrs(ctx, slog.String("path", "/v1/embed"))
+	logger.With("component", "vectordb").InfoContext(ctx, "searched collection")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"request_id": "abc", "path": "/v1/embed", "component": "vectordb"} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %q", key, record[key], want)
+		}
+	}
+	if attrs := Attrs(context.Background()); attrs != nil {
+		t.Errorf("plain context has attrs %v", attrs)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	if Discard.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Discard is enabled")
+	}
+	if OrDiscard(nil) != Discard {
+		t.Error("OrDiscard(nil) is not Discard")
+	}
+}
@@ -0,0 +1,135 @@
+// Package metrics instruments the vectordb and vectorize packages with Prometheus counters and
+// histograms. Everything is registered on Registry, which Handler serves in the text exposition
+// format for a /metrics endpoint.
+package metrics
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"time"
+
+	"milvus/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "vectorize"
+
+// Subsystems, the first label of every operation metric
+const (
+	VectorDB  = "vectordb"
+	Vectorize = "vectorize"
+)
+
+var (
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Operations started, by subsystem and operation.",
+	}, []string{"subsystem", "operation"})
+
+	operationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_errors_total",
+		Help:      "Operations that returned an error, by subsystem, operation and error type.",
+	}, []string{"subsystem", "operation", "type"})
+
+	operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of operations, successful or not.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"subsystem", "operation"})
+
+	rowsInserted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rows_inserted_total",
+		Help:      "Rows inserted or upserted into Milvus, by collection.",
+	}, []string{"collection"})
+
+	resultCount = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "result_count",
+		Help:      "Results returned per search or query.",
+		Buckets:   []float64{0, 1, 5, 10, 20, 50, 100, 500, 1000, 10000},
+	}, []string{"subsystem", "operation"})
+
+	vectorDimension = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vector_dimension",
+		Help:      "Dimension of the vectors searched or inserted.",
+		Buckets:   []float64{2, 16, 32, 64, 100, 128, 256, 300, 384, 512, 768, 1024, 1536, 4096},
+	}, []string{"subsystem", "operation"})
+)
+
+// Registry holds the metrics of this package plus the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		operations, operationErrors, operationDuration, rowsInserted, resultCount, vectorDimension,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves Registry for Prometheus to scrape
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Track counts a call and returns a func that records its latency and, if *err is set, its error:
+//
+//	func Search(...) (results []client.SearchResult, err error) {
+//		defer metrics.Track(metrics.VectorDB, "search")(&err)
+//
+// Functions without an error result pass nil.
+func Track(subsystem string, operation string) func(err *error) {
+	start := time.Now()
+	operations.WithLabelValues(subsystem, operation).Inc()
+	return func(err *error) {
+		operationDuration.WithLabelValues(subsystem, operation).Observe(time.Since(start).Seconds())
+		if err != nil && *err != nil {
+			operationErrors.WithLabelValues(subsystem, operation, ErrorType(*err)).Inc()
+		}
+	}
+}
+
+// RowsInserted adds n rows written to collection
+func RowsInserted(collection string, n int) {
+	rowsInserted.WithLabelValues(collection).Add(float64(n))
+}
+
+// Results records how many results an operation returned
+func Results(subsystem string, operation string, n int) {
+	resultCount.WithLabelValues(subsystem, operation).Observe(float64(n))
+}
+
+// Dimension records the dimension of the vectors an operation worked on
+func Dimension(subsystem string, operation string, dim int) {
+	if dim > 0 {
+		vectorDimension.WithLabelValues(subsystem, operation).Observe(float64(dim))
+	}
+}
+
+// ErrorType is the type label of an error: context errors, the gRPC code of a Milvus error,
+// the taxonomy type from the errors package, or "other"
+func ErrorType(err error) string {
+	switch {
+	case stdErrors.Is(err, context.Canceled):
+		return "canceled"
+	case stdErrors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != 0 {
+		return s.Code().String()
+	}
+	if t := errors.TypeOf(err); t != "" {
+		return t
+	}
+	return "other"
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"milvus/errors"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTrack(t *testing.T) {
+	ok := func() (err error) {
+		defer Track(VectorDB, "test_ok")(&err)
+		return nil
+	}
+	failing := func() (err error) {
+		defer Track(VectorDB, "test_fail")(&err)
+		return fmt.Errorf("fail to search: %w", context.DeadlineExceeded)
+	}
+	ok()
+	ok()
+	failing()
+	Track(Vectorize, "test_no_error")(nil)
+
+	if got := testutil.ToFloat64(operations.WithLabelValues(VectorDB, "test_ok")); got != 2 {
+		t.Errorf("operations_total = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(operationErrors.WithLabelValues(VectorDB, "test_ok", "deadline_exceeded")); got != 0 {
+		t.Errorf("errors counted for a successful call: %v", got)
+	}
+	if got := testutil.ToFloat64(operationErrors.WithLabelValues(VectorDB, "test_fail", "deadline_exceeded")); got != 1 {
+		t.Errorf("operation_errors_total = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(operationDuration); got < 3 {
+		t.Errorf("got %d duration series, want at least 3", got)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "Canceled", err: fmt.Errorf("query: %w", context.Canceled), want: "canceled"},
+		{name: "Deadline", err: context.DeadlineExceeded, want: "deadline_exceeded"},
+		{name: "File error", err: errors.FileNotFound("model.txt", stdErrors.New("no such file")), want: "FileNotFound"},
+		{name: "Milvus status", err: status.Error(codes.Unavailable, "connection refused"), want: "Unavailable"},
+		{name: "Other", err: stdErrors.New("boom"), want: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorType(tt.err); got != tt.want {
+				t.Errorf("ErrorType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	RowsInserted("test_words", 20)
+	Results(VectorDB, "search", 10)
+	Dimension(VectorDB, "search", 300)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`vectorize_rows_inserted_total{collection="test_words"} 20`,
+		`vectorize_result_count_bucket{operation="search",subsystem="vectordb",le="10"} 1`,
+		`vectorize_vector_dimension_sum{operation="search",subsystem="vectordb"} 300`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics is missing %s", want)
+		}
+	}
+}
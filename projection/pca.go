@@ -0,0 +1,296 @@
+package projection
+
+import (
+	"math"
+	"math/rand"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+/*
+	Dimensionality reduction for looking at embeddings.
+
+	- PCA   linear, fast, keeps the global layout; fine for hundreds of thousands of words
+	- TSNE  non-linear, keeps neighbourhoods; exact O(n²) so meant for a few thousand selected words
+
+	Both take the vectors as read by the embeddings package and return one row of float64
+	coordinates per vector, in the same order.
+*/
+
+const (
+	pcaMaxIterations = 300
+	pcaTolerance     = 1e-10
+	pcaOversampling  = 5 // extra directions iterated so the last requested one converges quickly
+)
+
+// PCAResult is a fitted principal component analysis
+type PCAResult struct {
+	Points     [][]float64 // the vectors projected onto the components
+	Components [][]float64 // unit length principal axes, by decreasing variance
+	Mean       []float64
+	// ExplainedVariance is the fraction of the total variance along each component
+	ExplainedVariance []float64
+}
+
+// PCA projects vectors onto their dims principal components
+func PCA(vectors [][]float32, dims int) (*PCAResult, error) {
+	dim, err := checkVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+	if dims < 1 || dims > dim {
+		return nil, errors.Invalid("dims", "can reduce %d dimensional vectors to 1 to %d dimensions, not %d", dim, dim, dims)
+	}
+	n := len(vectors)
+
+	mean := make([]float64, dim)
+	for _, v := range vectors {
+		for j, x := range v {
+			mean[j] += float64(x)
+		}
+	}
+	var totalVariance float64
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+	for _, v := range vectors {
+		for j, x := range v {
+			d := float64(x) - mean[j]
+			totalVariance += d * d
+		}
+	}
+
+	// Orthogonal iteration on the covariance, applied as Xᵀ(Xq) so it's never materialized
+	k := min(dims+pcaOversampling, dim)
+	rng := rand.New(rand.NewSource(1))
+	basis := make([][]float64, k)
+	for i := range basis {
+		basis[i] = make([]float64, dim)
+		for j := range basis[i] {
+			basis[i][j] = rng.NormFloat64()
+		}
+	}
+	orthonormalize(basis)
+	for iteration := 0; iteration < pcaMaxIterations; iteration++ {
+		next := make([][]float64, k)
+		for i, q := range basis {
+			next[i] = covarianceTimes(vectors, mean, q)
+		}
+		orthonormalize(next)
+		converged := true
+		for i := 0; i < dims; i++ {
+			if 1-math.Abs(vecmath.Dot64(basis[i], next[i])) > pcaTolerance {
+				converged = false
+				break
+			}
+		}
+		basis = next
+		if converged {
+			break
+		}
+	}
+
+	// Rayleigh-Ritz: diagonalize the covariance within the subspace to get the axes in order
+	projected := make([][]float64, k)
+	for i := range projected {
+		projected[i] = covarianceTimes(vectors, mean, basis[i])
+	}
+	small := make([][]float64, k)
+	for i := range small {
+		small[i] = make([]float64, k)
+		for j := range small[i] {
+			small[i][j] = vecmath.Dot64(basis[i], projected[j])
+		}
+	}
+	eigenvalues, eigenvectors := jacobiEigen(small)
+
+	result := &PCAResult{Mean: mean}
+	for c := 0; c < dims; c++ {
+		component := make([]float64, dim)
+		for i, q := range basis {
+			for j := range component {
+				component[j] += eigenvectors[i][c] * q[j]
+			}
+		}
+		normalizeSign(component)
+		result.Components = append(result.Components, component)
+		if totalVariance > 0 {
+			result.ExplainedVariance = append(result.ExplainedVariance, math.Max(eigenvalues[c], 0)*float64(n)/totalVariance)
+		} else {
+			result.ExplainedVariance = append(result.ExplainedVariance, 0)
+		}
+	}
+	result.Points = result.Transform(vectors)
+	return result, nil
+}
+
+// Transform projects vectors (of the dimension PCA was fitted on) onto the components
+func (r *PCAResult) Transform(vectors [][]float32) [][]float64 {
+	points := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		points[i] = make([]float64, len(r.Components))
+		for c, component := range r.Components {
+			var sum float64
+			for j, x := range v {
+				sum += (float64(x) - r.Mean[j]) * component[j]
+			}
+			points[i][c] = sum
+		}
+	}
+	return points
+}
+
+func checkVectors(vectors [][]float32) (int, error) {
+	if len(vectors) == 0 {
+		return 0, errors.Invalid("vectors", "no vectors to reduce")
+	}
+	dim := len(vectors[0])
+	if dim == 0 {
+		return 0, errors.Invalid("vectors", "vectors have no dimensions")
+	}
+	for _, v := range vectors {
+		if len(v) != dim {
+			return 0, errors.DimensionMismatch("vector", len(v), dim)
+		}
+	}
+	return dim, nil
+}
+
+// covarianceTimes returns C·q for the covariance C of vectors, computed as Xcᵀ(Xc·q)/n
+func covarianceTimes(vectors [][]float32, mean []float64, q []float64) []float64 {
+	var offset float64
+	for j, m := range mean {
+		offset += m * q[j]
+	}
+	result := make([]float64, len(q))
+	for _, v := range vectors {
+		var p float64
+		for j, x := range v {
+			p += float64(x) * q[j]
+		}
+		p -= offset
+		for j, x := range v {
+			result[j] += p * (float64(x) - mean[j])
+		}
+	}
+	for j := range result {
+		result[j] /= float64(len(vectors))
+	}
+	return result
+}
+
+// orthonormalize runs modified Gram-Schmidt over the rows in place. A row that collapses to zero
+// is replaced by a unit vector orthogonal to the ones before it.
+func orthonormalize(rows [][]float64) {
+	for i := range rows {
+		for attempt := 0; ; attempt++ {
+			for j := 0; j < i; j++ {
+				p := vecmath.Dot64(rows[i], rows[j])
+				for k := range rows[i] {
+					rows[i][k] -= p * rows[j][k]
+				}
+			}
+			norm := math.Sqrt(vecmath.Dot64(rows[i], rows[i]))
+			if norm > 1e-12 || attempt == len(rows[i]) {
+				if norm > 0 {
+					for k := range rows[i] {
+						rows[i][k] /= norm
+					}
+				}
+				break
+			}
+			for k := range rows[i] {
+				rows[i][k] = 0
+			}
+			rows[i][(i+attempt)%len(rows[i])] = 1
+		}
+	}
+}
+
+// jacobiEigen diagonalizes the symmetric matrix a. It returns the eigenvalues in decreasing order
+// and the eigenvectors as the columns of the second result.
+func jacobiEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range m {
+		m[i] = append([]float64(nil), a[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		var off float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(m[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = c*mkp-s*mkq, s*mkp+c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = c*mpk-s*mqk, s*mpk+c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	// selection sort, n is a handful of components
+	for i := range order {
+		for j := i + 1; j < n; j++ {
+			if m[order[j]][order[j]] > m[order[i]][order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+	values := make([]float64, n)
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, n)
+	}
+	for c, o := range order {
+		values[c] = m[o][o]
+		for i := 0; i < n; i++ {
+			vectors[i][c] = v[i][o]
+		}
+	}
+	return values, vectors
+}
+
+// normalizeSign flips v so its largest coordinate is positive, making PCA deterministic
+func normalizeSign(v []float64) {
+	largest := 0
+	for i := range v {
+		if math.Abs(v[i]) > math.Abs(v[largest]) {
+			largest = i
+		}
+	}
+	if v[largest] < 0 {
+		for i := range v {
+			v[i] = -v[i]
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package projection
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+)
+
+// Point is a labelled word in a 2D scatter plot
+type Point struct {
+	Label string
+	X     float64
+	Y     float64
+}
+
+type PlotOptions struct {
+	Title  string
+	Width  int // pixels, 900 by default
+	Height int // pixels, 700 by default
+}
+
+const plotMargin = 40
+
+// Points pairs labels with the first two coordinates of each row, as returned by PCA or TSNE
+func Points(labels []string, coordinates [][]float64) []Point {
+	points := make([]Point, len(labels))
+	for i, label := range labels {
+		points[i].Label = label
+		if len(coordinates[i]) > 0 {
+			points[i].X = coordinates[i][0]
+		}
+		if len(coordinates[i]) > 1 {
+			points[i].Y = coordinates[i][1]
+		}
+	}
+	return points
+}
+
+// WriteSVG draws points as a labelled scatter plot scaled to fit the image
+func WriteSVG(w io.Writer, points []Point, opts PlotOptions) error {
+	writer := bufio.NewWriter(w)
+	writeSVG(writer, points, opts)
+	return writer.Flush()
+}
+
+// WriteHTML writes a standalone page with the SVG plot and a search box that highlights the
+// matching words
+func WriteHTML(w io.Writer, points []Point, opts PlotOptions) error {
+	writer := bufio.NewWriter(w)
+	title := html.EscapeString(opts.Title)
+	fmt.Fprintf(writer, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.point text { font-size: 11px; fill: #333; }
+.point circle { fill: #4878d0; fill-opacity: 0.7; }
+.point:hover text, .point.match text { font-weight: bold; fill: #d62728; }
+.point:hover circle, .point.match circle { fill: #d62728; fill-opacity: 1; }
+.dimmed { opacity: 0.15; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p><input id="search" type="search" placeholder="highlight words" autofocus> <span id="count">%d words</span></p>
+`, title, title, len(points))
+	writeSVG(writer, points, opts)
+	writer.WriteString(`
+<script>
+const points = document.querySelectorAll(".point");
+const count = document.getElementById("count");
+document.getElementById("search").addEventListener("input", event => {
+  const query = event.target.value.trim().toLowerCase();
+  let matches = 0;
+  points.forEach(point => {
+    const match = query !== "" && point.dataset.word.toLowerCase().includes(query);
+    point.classList.toggle("match", match);
+    point.classList.toggle("dimmed", query !== "" && !match);
+    if (match) matches++;
+  });
+  count.textContent = query === "" ? points.length + " words" : matches + " of " + points.length + " words";
+});
+</script>
+</body>
+</html>
+`)
+	return writer.Flush()
+}
+
+func writeSVG(w *bufio.Writer, points []Point, opts PlotOptions) {
+	if opts.Width <= 0 {
+		opts.Width = 900
+	}
+	if opts.Height <= 0 {
+		opts.Height = 700
+	}
+	minX, maxX, minY, maxY := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	scale := func(v, lo, hi float64, size int) float64 {
+		if hi <= lo {
+			return float64(size) / 2
+		}
+		return plotMargin + (v-lo)/(hi-lo)*float64(size-2*plotMargin)
+	}
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		opts.Width, opts.Height, opts.Width, opts.Height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white" stroke="#ccc"/>`+"\n")
+	if opts.Title != "" {
+		fmt.Fprintf(w, `<text x="%d" y="20" font-size="14" text-anchor="middle">%s</text>`+"\n", opts.Width/2, html.EscapeString(opts.Title))
+	}
+	for _, p := range points {
+		x := scale(p.X, minX, maxX, opts.Width)
+		// SVG's y axis points down
+		y := float64(opts.Height) - scale(p.Y, minY, maxY, opts.Height)
+		label := html.EscapeString(p.Label)
+		fmt.Fprintf(w, `<g class="point" data-word="%s"><title>%s (%.4g, %.4g)</title>`+
+			`<circle cx="%.1f" cy="%.1f" r="3" fill="#4878d0"/>`+
+			`<text x="%.1f" y="%.1f" font-size="11">%s</text></g>`+"\n",
+			label, label, p.X, p.Y, x, y, x+5, y-4, label)
+	}
+	w.WriteString("</svg>\n")
+}
@@ -0,0 +1,168 @@
+package projection
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+const validModelPath = "../tests/mockdata/word_vector.txt"
+
+func TestPCA(t *testing.T) {
+	// Points spread along (3, 4, 0) a lot, along (0, 0, 1) a little and not at all otherwise
+	rng := rand.New(rand.NewSource(7))
+	vectors := make([][]float32, 200)
+	for i := range vectors {
+		a, b := rng.NormFloat64()*10, rng.NormFloat64()
+		vectors[i] = []float32{float32(1 + 0.6*a), float32(2 + 0.8*a), float32(3 + b), 5}
+	}
+	result, err := PCA(vectors, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, second := result.Components[0], result.Components[1]
+	if math.Abs(first[0]-0.6) > 1e-3 || math.Abs(first[1]-0.8) > 1e-3 || math.Abs(second[2]-1) > 1e-3 {
+		t.Errorf("components = %v", result.Components)
+	}
+	if math.Abs(vecmath.Dot64(first, second)) > 1e-9 {
+		t.Errorf("components are not orthogonal: %v", result.Components)
+	}
+	if total := result.ExplainedVariance[0] + result.ExplainedVariance[1]; math.Abs(total-1) > 1e-9 || result.ExplainedVariance[0] < 0.95 {
+		t.Errorf("explained variance = %v", result.ExplainedVariance)
+	}
+	if len(result.Points) != len(vectors) || len(result.Points[0]) != 2 {
+		t.Fatalf("points have shape %dx%d", len(result.Points), len(result.Points[0]))
+	}
+
+	embs, err := embeddings.Load(validModelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := make([][]float32, len(embs))
+	for i, e := range embs {
+		fixture[i] = e.Vector
+	}
+	result, err = PCA(fixture, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 1; i < 3; i++ {
+		if result.ExplainedVariance[i] > result.ExplainedVariance[i-1] {
+			t.Errorf("explained variance is not decreasing: %v", result.ExplainedVariance)
+		}
+	}
+
+	if _, err := PCA(fixture, 11); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for too many dimensions, got %v", err)
+	}
+	if _, err := PCA([][]float32{{1, 2}, {1}}, 1); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+}
+
+func TestTSNE(t *testing.T) {
+	// Two well separated clusters in 20 dimensions should stay separated
+	rng := rand.New(rand.NewSource(3))
+	vectors := make([][]float32, 40)
+	for i := range vectors {
+		vectors[i] = make([]float32, 20)
+		for j := range vectors[i] {
+			vectors[i][j] = float32(rng.NormFloat64())
+		}
+		if i >= 20 {
+			vectors[i][0] += 20
+		}
+	}
+	points, err := TSNE(vectors, TSNEOptions{Iterations: 500}, context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	distance := func(i, j int) float64 {
+		return math.Hypot(points[i][0]-points[j][0], points[i][1]-points[j][1])
+	}
+	var within, between float64
+	for i := 0; i < 40; i++ {
+		for j := i + 1; j < 40; j++ {
+			if (i < 20) == (j < 20) {
+				within = math.Max(within, distance(i, j))
+			} else if between == 0 || distance(i, j) < between {
+				between = distance(i, j)
+			}
+		}
+	}
+	if between <= within {
+		t.Errorf("clusters overlap: closest pair across %f, furthest pair within %f", between, within)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TSNE(vectors, TSNEOptions{}, ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := TSNE(vectors[:1], TSNEOptions{}, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for a single point, got %v", err)
+	}
+}
+
+func TestWriteProjector(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "projector")
+	embs := []embeddings.Embedding{{Word: "cat", Vector: []float32{1, 0.5}}, {Word: "dog", Vector: []float32{-1, 2}}}
+	if err := WriteProjector(dir, "animals", embs, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for file, expected := range map[string]string{
+		ProjectorVectors:  "1\t0.5\n-1\t2\n",
+		ProjectorMetadata: "cat\ndog\n",
+		ProjectorConfig:   "embeddings {\n  tensor_name: \"animals\"\n  tensor_path: \"vectors.tsv\"\n  metadata_path: \"metadata.tsv\"\n}\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || string(content) != expected {
+			t.Errorf("%s = %q, %v, want %q", file, content, err, expected)
+		}
+	}
+
+	if err := WriteProjector(dir, "animals", embs, map[string][]string{"rank": {"1", "2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, ProjectorMetadata)); string(content) != "word\trank\ncat\t1\ndog\t2\n" {
+		t.Errorf("metadata with columns = %q", content)
+	}
+	if err := WriteProjector(dir, "animals", embs, map[string][]string{"rank": {"1"}}); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for a short column, got %v", err)
+	}
+}
+
+func TestPlot(t *testing.T) {
+	points := Points([]string{"cat", "<dog>"}, [][]float64{{0, 0, 9}, {1, 2, 9}})
+	if points[1].X != 1 || points[1].Y != 2 {
+		t.Errorf("points = %+v", points)
+	}
+
+	var svg bytes.Buffer
+	if err := WriteSVG(&svg, points, PlotOptions{Title: "animals", Width: 200, Height: 100}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`width="200" height="100"`, ">cat</text>", "&lt;dog&gt;", `cx="40.0" cy="60.0"`, `cx="160.0" cy="40.0"`} {
+		if !strings.Contains(svg.String(), expected) {
+			t.Errorf("svg does not contain %q:\n%s", expected, svg.String())
+		}
+	}
+
+	var page bytes.Buffer
+	if err := WriteHTML(&page, points, PlotOptions{Title: "animals"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(page.String(), "<!DOCTYPE html>") || !strings.Contains(page.String(), "<svg") || !strings.Contains(page.String(), "2 words") {
+		t.Errorf("unexpected html:\n%s", page.String())
+	}
+}
@@ -0,0 +1,110 @@
+package projection
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+// Files written by WriteProjector, as the TensorBoard Embedding Projector expects them
+const (
+	ProjectorVectors  = "vectors.tsv"
+	ProjectorMetadata = "metadata.tsv"
+	ProjectorConfig   = "projector_config.pbtxt"
+)
+
+// WriteProjector writes embs to dir in the Embedding Projector format: vectors.tsv with one
+// tab separated vector per line, metadata.tsv with the matching words and projector_config.pbtxt
+// pointing TensorBoard at both. The two TSV files can also be loaded into
+// https://projector.tensorflow.org directly. Columns, if any, are extra metadata columns keyed by
+// header, one value per embedding.
+func WriteProjector(dir string, name string, embs []embeddings.Embedding, columns map[string][]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.FileCreationErr(dir, err)
+	}
+	headers := make([]string, 0, len(columns))
+	for header, values := range columns {
+		if len(values) != len(embs) {
+			return errors.Invalid("columns", "metadata column %q has %d values for %d embeddings", header, len(values), len(embs))
+		}
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+
+	err := writeFile(filepath.Join(dir, ProjectorVectors), func(w io.Writer) error {
+		return WriteVectorsTSV(w, embs)
+	})
+	if err != nil {
+		return err
+	}
+	err = writeFile(filepath.Join(dir, ProjectorMetadata), func(w io.Writer) error {
+		return writeMetadataTSV(w, embs, headers, columns)
+	})
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, ProjectorConfig), func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "embeddings {\n  tensor_name: %q\n  tensor_path: %q\n  metadata_path: %q\n}\n",
+			name, ProjectorVectors, ProjectorMetadata)
+		return err
+	})
+}
+
+// WriteVectorsTSV writes one tab separated vector per line, without a header
+func WriteVectorsTSV(w io.Writer, embs []embeddings.Embedding) error {
+	writer := bufio.NewWriter(w)
+	for _, e := range embs {
+		for i, x := range e.Vector {
+			if i > 0 {
+				writer.WriteByte('\t')
+			}
+			writer.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+		}
+		writer.WriteByte('\n')
+	}
+	return writer.Flush()
+}
+
+// writeMetadataTSV writes the words. With a single column the projector expects no header line.
+func writeMetadataTSV(w io.Writer, embs []embeddings.Embedding, headers []string, columns map[string][]string) error {
+	writer := bufio.NewWriter(w)
+	if len(headers) > 0 {
+		writer.WriteString("word\t" + strings.Join(headers, "\t") + "\n")
+	}
+	for i, e := range embs {
+		writer.WriteString(tsvField(e.Word))
+		for _, header := range headers {
+			writer.WriteString("\t" + tsvField(columns[header][i]))
+		}
+		writer.WriteByte('\n')
+	}
+	return writer.Flush()
+}
+
+// tsvField keeps tabs and newlines in a value from breaking the row
+func tsvField(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	if err := write(output); err != nil {
+		output.Close()
+		return errors.FileCreationErr(path, err)
+	}
+	if err := output.Close(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"milvus/errors"
+)
+
+// MaxTSNEPoints bounds TSNE, which keeps two n×n matrices in memory
+const MaxTSNEPoints = 5000
+
+const (
+	exaggeration           = 12.0
+	exaggerationIterations = 250
+	perplexityTolerance    = 1e-5
+)
+
+// TSNEOptions configures TSNE (van der Maaten and Hinton, 2008). Zero fields take the defaults.
+type TSNEOptions struct {
+	Dims         int     // output dimensions, 2 by default
+	Perplexity   float64 // roughly the number of neighbours each point keeps close, 30 by default
+	Iterations   int     // gradient descent steps, 1000 by default
+	LearningRate float64 // 200 by default
+	InitialDims  int     // PCA reduces the input to this many dimensions first, 50 by default
+	Seed         int64
+}
+
+func DefaultTSNEOptions() TSNEOptions {
+	return TSNEOptions{Dims: 2, Perplexity: 30, Iterations: 1000, LearningRate: 200, InitialDims: 50, Seed: 1}
+}
+
+// TSNE embeds vectors in opts.Dims dimensions so that words close in the original space stay close.
+// The perplexity is lowered for small inputs. If ctx is cancelled TSNE returns ctx.Err().
+func TSNE(vectors [][]float32, opts TSNEOptions, ctx context.Context) ([][]float64, error) {
+	defaults := DefaultTSNEOptions()
+	if opts.Dims == 0 {
+		opts.Dims = defaults.Dims
+	}
+	if opts.Perplexity == 0 {
+		opts.Perplexity = defaults.Perplexity
+	}
+	if opts.Iterations == 0 {
+		opts.Iterations = defaults.Iterations
+	}
+	if opts.LearningRate == 0 {
+		opts.LearningRate = defaults.LearningRate
+	}
+	if opts.InitialDims == 0 {
+		opts.InitialDims = defaults.InitialDims
+	}
+	dim, err := checkVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+	n := len(vectors)
+	switch {
+	case n > MaxTSNEPoints:
+		return nil, errors.Invalid("vectors", "t-SNE is limited to %d points, got %d; select fewer words or use PCA", MaxTSNEPoints, n)
+	case n < 2:
+		return nil, errors.Invalid("vectors", "t-SNE needs at least 2 points")
+	case opts.Dims < 1:
+		return nil, errors.Invalid("dims", "invalid dimensions %d", opts.Dims)
+	case opts.Perplexity < 0:
+		return nil, errors.Invalid("perplexity", "perplexity must be positive, got %g", opts.Perplexity)
+	case opts.Iterations < 0 || opts.LearningRate < 0:
+		return nil, errors.Invalid("iterations", "iterations and learning rate must be positive")
+	}
+	if limit := float64(n-1) / 3; opts.Perplexity > limit {
+		opts.Perplexity = math.Max(limit, 1)
+	}
+
+	var input [][]float64
+	if dim > opts.InitialDims && opts.InitialDims > 0 && n > opts.InitialDims {
+		pca, err := PCA(vectors, opts.InitialDims)
+		if err != nil {
+			return nil, err
+		}
+		input = pca.Points
+	} else {
+		input = make([][]float64, n)
+		for i, v := range vectors {
+			input[i] = make([]float64, dim)
+			for j, x := range v {
+				input[i][j] = float64(x)
+			}
+		}
+	}
+
+	p := affinities(squaredDistances(input), opts.Perplexity)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	y := make([][]float64, n)
+	update := make([][]float64, n)
+	gains := make([][]float64, n)
+	for i := range y {
+		y[i] = make([]float64, opts.Dims)
+		update[i] = make([]float64, opts.Dims)
+		gains[i] = make([]float64, opts.Dims)
+		for d := range y[i] {
+			y[i][d] = rng.NormFloat64() * 1e-4
+			gains[i][d] = 1
+		}
+	}
+
+	num := make([][]float64, n)
+	for i := range num {
+		num[i] = make([]float64, n)
+	}
+	gradient := make([]float64, opts.Dims)
+	for iteration := 0; iteration < opts.Iterations; iteration++ {
+		if iteration%10 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		scale, momentum := exaggeration, 0.5
+		if iteration >= exaggerationIterations {
+			scale, momentum = 1, 0.8
+		}
+
+		// Student-t similarities in the embedding
+		var sum float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				var d float64
+				for k := range y[i] {
+					diff := y[i][k] - y[j][k]
+					d += diff * diff
+				}
+				q := 1 / (1 + d)
+				num[i][j], num[j][i] = q, q
+				sum += 2 * q
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			for k := range gradient {
+				gradient[k] = 0
+			}
+			for j := 0; j < n; j++ {
+				if i == j {
+					continue
+				}
+				force := (scale*p[i][j] - num[i][j]/sum) * num[i][j]
+				for k := range gradient {
+					gradient[k] += 4 * force * (y[i][k] - y[j][k])
+				}
+			}
+			for k, g := range gradient {
+				if (g > 0) != (update[i][k] > 0) {
+					gains[i][k] += 0.2
+				} else {
+					gains[i][k] = math.Max(gains[i][k]*0.8, 0.01)
+				}
+				update[i][k] = momentum*update[i][k] - opts.LearningRate*gains[i][k]*g
+			}
+		}
+		for i := range y {
+			for k := range y[i] {
+				y[i][k] += update[i][k]
+			}
+		}
+		center(y)
+	}
+	return y, nil
+}
+
+func squaredDistances(points [][]float64) [][]float64 {
+	distances := make([][]float64, len(points))
+	for i := range distances {
+		distances[i] = make([]float64, len(points))
+	}
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			var d float64
+			for k := range points[i] {
+				diff := points[i][k] - points[j][k]
+				d += diff * diff
+			}
+			distances[i][j], distances[j][i] = d, d
+		}
+	}
+	return distances
+}
+
+// affinities turns squared distances into the symmetric joint probabilities P, finding for each
+// point the Gaussian width whose conditional distribution has the given perplexity. It reuses
+// the distance matrix for P.
+func affinities(distances [][]float64, perplexity float64) [][]float64 {
+	n := len(distances)
+	target := math.Log(perplexity)
+	row := make([]float64, n)
+	for i := 0; i < n; i++ {
+		beta, lo, hi := 1.0, 0.0, math.Inf(1)
+		for attempt := 0; attempt < 64; attempt++ {
+			var sum, weighted float64
+			for j, d := range distances[i] {
+				if j == i {
+					row[j] = 0
+					continue
+				}
+				row[j] = math.Exp(-d * beta)
+				sum += row[j]
+				weighted += d * row[j]
+			}
+			if sum == 0 {
+				// every neighbour is far away relative to beta; widen the Gaussian
+				hi = beta
+				beta = (lo + hi) / 2
+				continue
+			}
+			entropy := math.Log(sum) + beta*weighted/sum
+			for j := range row {
+				row[j] /= sum
+			}
+			if diff := entropy - target; math.Abs(diff) < perplexityTolerance {
+				break
+			} else if diff > 0 {
+				lo = beta
+				if math.IsInf(hi, 1) {
+					beta *= 2
+				} else {
+					beta = (lo + hi) / 2
+				}
+			} else {
+				hi = beta
+				beta = (lo + hi) / 2
+			}
+		}
+		// row i of distances is not needed again once its conditional probabilities are known
+		copy(distances[i], row)
+	}
+
+	p := distances
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			v := math.Max((p[i][j]+p[j][i])/float64(2*n), 1e-12)
+			p[i][j], p[j][i] = v, v
+		}
+	}
+	return p
+}
+
+func center(points [][]float64) {
+	if len(points) == 0 {
+		return
+	}
+	mean := make([]float64, len(points[0]))
+	for _, p := range points {
+		for k, x := range p {
+			mean[k] += x
+		}
+	}
+	for _, p := range points {
+		for k := range p {
+			p[k] -= mean[k] / float64(len(points))
+		}
+	}
+}
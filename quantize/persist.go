@@ -0,0 +1,137 @@
+package quantize
+
+import (
+	"bufio"
+	"encoding/gob"
+	stdErrors "errors"
+	"fmt"
+	"os"
+
+	"milvus/errors"
+)
+
+const formatVersion = 1
+
+// snapshot is the on-disk form of an Index; exactly one of Scalar and Product is set
+type snapshot struct {
+	Version int
+	Config  Config
+	Dim     int
+	Words   []string
+	Codes   []byte
+	Scalar  *scalar
+	Product *product
+}
+
+// Save writes the codes, words and quantizer parameters to path
+func (idx *Index) Save(path string) error {
+	snap := snapshot{Version: formatVersion, Config: idx.config, Dim: idx.dim, Words: idx.words, Codes: idx.codes}
+	switch q := idx.q.(type) {
+	case *scalar:
+		snap.Scalar = q
+	case *product:
+		snap.Product = q
+	}
+
+	output, err := os.Create(path)
+	if err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	defer output.Close()
+
+	writer := bufio.NewWriter(output)
+	if err := gob.NewEncoder(writer).Encode(&snap); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.FileCreationErr(path, err)
+	}
+	return output.Close()
+}
+
+// Load reads an index written by Save
+func Load(path string) (*Index, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer input.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(input)).Decode(&snap); err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	if snap.Version != formatVersion {
+		return nil, errors.FileFormatError(path, fmt.Errorf("unsupported quantized file version %d", snap.Version))
+	}
+
+	q, err := snap.check()
+	if err != nil {
+		return nil, errors.FileFormatError(path, err)
+	}
+	idx := &Index{config: snap.Config, dim: snap.Dim, words: snap.Words, codes: snap.Codes, q: q, wordIDs: make(map[string]int, len(snap.Words))}
+	for i, word := range snap.Words {
+		idx.wordIDs[word] = i
+	}
+	return idx, nil
+}
+
+// check validates a decoded snapshot against what searching it indexes, so a corrupt file is an
+// error here rather than a panic later, and returns its quantizer
+func (snap *snapshot) check() (quantizer, error) {
+	switch snap.Config.Metric {
+	case L2, IP, Cosine:
+	default:
+		return nil, fmt.Errorf("unsupported metric %q", snap.Config.Metric)
+	}
+	if snap.Dim <= 0 {
+		return nil, fmt.Errorf("invalid dimension %d", snap.Dim)
+	}
+
+	var q quantizer
+	switch {
+	case snap.Config.Method == Scalar && snap.Scalar != nil && snap.Product == nil:
+		if len(snap.Scalar.Min) != snap.Dim || len(snap.Scalar.Step) != snap.Dim {
+			return nil, fmt.Errorf("sq8 parameters of %d and %d dimensions, want %d", len(snap.Scalar.Min), len(snap.Scalar.Step), snap.Dim)
+		}
+		q = snap.Scalar
+	case snap.Config.Method == Product && snap.Product != nil && snap.Scalar == nil:
+		p := snap.Product
+		if snap.Config.Bits < 1 || snap.Config.Bits > 8 {
+			return nil, fmt.Errorf("invalid code width of %d bits", snap.Config.Bits)
+		}
+		if len(p.Codebooks) == 0 || len(p.Codebooks) != snap.Config.Subspaces || p.SubDim*len(p.Codebooks) != snap.Dim {
+			return nil, fmt.Errorf("%d codebooks of %d dimensions for %d subspaces of a %d dimension index",
+				len(p.Codebooks), p.SubDim, snap.Config.Subspaces, snap.Dim)
+		}
+		for m, codebook := range p.Codebooks {
+			if len(codebook) == 0 || len(codebook) > 1<<snap.Config.Bits {
+				return nil, fmt.Errorf("codebook %d has %d centroids, want 1 to %d", m, len(codebook), 1<<snap.Config.Bits)
+			}
+			for _, centroid := range codebook {
+				if len(centroid) != p.SubDim {
+					return nil, fmt.Errorf("codebook %d has a centroid of %d dimensions, want %d", m, len(centroid), p.SubDim)
+				}
+			}
+		}
+		q = p
+	default:
+		return nil, stdErrors.New("missing or corrupt quantizer")
+	}
+
+	if len(snap.Codes) != len(snap.Words)*q.codeSize() {
+		return nil, stdErrors.New("corrupt codes")
+	}
+	if p, ok := q.(*product); ok {
+		// every code byte indexes a centroid of its subspace's codebook
+		for i, c := range snap.Codes {
+			if m := i % len(p.Codebooks); int(c) >= len(p.Codebooks[m]) {
+				return nil, fmt.Errorf("code of word %d refers to centroid %d of codebook %d, which has %d", i/len(p.Codebooks), c, m, len(p.Codebooks[m]))
+			}
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,132 @@
+package quantize
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"milvus/cluster"
+	"milvus/errors"
+)
+
+const defaultTrainSize = 65536
+
+// product splits vectors into len(Codebooks) parts and stores the nearest centroid of each part
+type product struct {
+	SubDim    int
+	Codebooks [][][]float32 // [subspace][centroid][SubDim]
+}
+
+func trainProduct(vectors [][]float32, config *Config) (*product, error) {
+	dim := len(vectors[0])
+	if config.Subspaces == 0 {
+		config.Subspaces = 1
+		for m := dim / 4; m > 1; m-- {
+			if dim%m == 0 {
+				config.Subspaces = m
+				break
+			}
+		}
+	}
+	if config.Subspaces < 1 || dim%config.Subspaces != 0 {
+		return nil, errors.Invalid("subspaces", "subspaces must divide the dimension %d, got %d", dim, config.Subspaces)
+	}
+	if config.Bits == 0 {
+		config.Bits = 8
+	}
+	if config.Bits < 1 || config.Bits > 8 {
+		return nil, errors.Invalid("bits", "bits must be between 1 and 8, got %d", config.Bits)
+	}
+	if config.TrainSize == 0 {
+		config.TrainSize = defaultTrainSize
+	}
+
+	train := vectors
+	if config.TrainSize > 0 && config.TrainSize < len(vectors) {
+		rng := rand.New(rand.NewSource(config.Seed))
+		train = make([][]float32, config.TrainSize)
+		for i, j := range rng.Perm(len(vectors))[:config.TrainSize] {
+			train[i] = vectors[j]
+		}
+	}
+	centroids := min(1<<config.Bits, len(train))
+
+	p := &product{SubDim: dim / config.Subspaces, Codebooks: make([][][]float32, config.Subspaces)}
+	parts := make([][]float32, len(train))
+	for m := range p.Codebooks {
+		for i, v := range train {
+			parts[i] = v[m*p.SubDim : (m+1)*p.SubDim]
+		}
+		opts := cluster.Options{K: centroids, MaxIterations: 25, Seed: config.Seed + int64(m)}
+		if len(parts) > 16*centroids {
+			opts.BatchSize, opts.MaxIterations = 4*centroids, 200
+		}
+		result, err := cluster.KMeans(parts, opts, context.Background())
+		if err != nil {
+			return nil, err
+		}
+		p.Codebooks[m] = result.Centroids
+	}
+	return p, nil
+}
+
+func (p *product) codeSize() int {
+	return len(p.Codebooks)
+}
+
+func (p *product) codebookSize() int {
+	return 4 * len(p.Codebooks) * len(p.Codebooks[0]) * p.SubDim
+}
+
+func (p *product) encode(v []float32, code []byte) {
+	for m, codebook := range p.Codebooks {
+		part := v[m*p.SubDim : (m+1)*p.SubDim]
+		best, bestDistance := 0, float32(math.Inf(1))
+		for c, centroid := range codebook {
+			var d float32
+			for j, x := range part {
+				diff := x - centroid[j]
+				d += diff * diff
+			}
+			if d < bestDistance {
+				best, bestDistance = c, d
+			}
+		}
+		code[m] = byte(best)
+	}
+}
+
+func (p *product) decode(code []byte, v []float32) {
+	for m, c := range code {
+		copy(v[m*p.SubDim:(m+1)*p.SubDim], p.Codebooks[m][c])
+	}
+}
+
+// scorer precomputes the score of every query part against every centroid, so scoring a code
+// is one table lookup per subspace
+func (p *product) scorer(query []float32, metric Metric) func(code []byte) float32 {
+	table := make([][]float32, len(p.Codebooks))
+	for m, codebook := range p.Codebooks {
+		part := query[m*p.SubDim : (m+1)*p.SubDim]
+		table[m] = make([]float32, len(codebook))
+		for c, centroid := range codebook {
+			var s float32
+			for j, x := range part {
+				if metric == L2 {
+					diff := x - centroid[j]
+					s += diff * diff
+				} else {
+					s += x * centroid[j]
+				}
+			}
+			table[m][c] = s
+		}
+	}
+	return func(code []byte) float32 {
+		var sum float32
+		for m, c := range code {
+			sum += table[m][c]
+		}
+		return sum
+	}
+}
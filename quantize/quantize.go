@@ -0,0 +1,229 @@
+package quantize
+
+import (
+	"fmt"
+	"sort"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+/*
+	Compressed word vectors for memory constrained services
+
+	- sq8  scalar quantization: every component becomes one byte, scaled between the minimum and
+	       maximum of its dimension; 4x smaller than float32
+	- pq   product quantization (Jégou et al., 2011): the vector is split into Subspaces parts
+	       and each part is replaced by the id of the closest of 2^Bits centroids learnt by
+	       k-means; Subspaces bytes per vector
+
+	Search scans all codes with asymmetric distance computation (ADC): the query stays float32
+	and is compared with the reconstructed vectors, for PQ through a per-query table of the
+	distances between each query part and each centroid.
+*/
+
+type Method string
+
+const (
+	Scalar  Method = "sq8"
+	Product Method = "pq"
+)
+
+// Metric has the same meaning as in the hnsw package
+type Metric string
+
+const (
+	L2     Metric = "L2"
+	IP     Metric = "IP"
+	Cosine Metric = "COSINE"
+)
+
+type Config struct {
+	Method    Method // sq8 by default
+	Metric    Metric // L2 by default; Cosine normalizes the vectors before encoding them
+	Subspaces int    // pq: parts per vector, must divide the dimension; by default the largest divisor up to dim/4
+	Bits      int    // pq: bits per part code, 1 to 8, 8 by default
+	TrainSize int    // pq: vectors k-means is trained on, 65536 by default (0 or more than there are = all)
+	Seed      int64
+}
+
+// Result follows hnsw.Result: squared distance for L2 (lower is closer), similarity for IP and
+// COSINE (higher is closer)
+type Result struct {
+	ID    int
+	Word  string
+	Score float32
+}
+
+// quantizer encodes vectors into fixed size codes
+type quantizer interface {
+	codeSize() int
+	encode(v []float32, code []byte)
+	decode(code []byte, v []float32)
+	// scorer returns the ADC score of query against a code, as Result.Score defines it
+	scorer(query []float32, metric Metric) func(code []byte) float32
+	// codebookSize is the bytes the quantizer's own parameters take
+	codebookSize() int
+}
+
+// Index holds the codes of a vocabulary and the quantizer that decodes them
+type Index struct {
+	config  Config
+	dim     int
+	words   []string
+	wordIDs map[string]int
+	codes   []byte
+	q       quantizer
+}
+
+// Train fits a quantizer on embs and encodes them all
+func Train(embs []embeddings.Embedding, config Config) (*Index, error) {
+	if len(embs) == 0 {
+		return nil, errors.Invalid("embeddings", "no vectors to quantize")
+	}
+	switch config.Metric {
+	case "":
+		config.Metric = L2
+	case L2, IP, Cosine:
+	default:
+		return nil, errors.Invalid("metric", "unsupported metric %q", config.Metric)
+	}
+	dim := len(embs[0].Vector)
+	vectors := make([][]float32, len(embs))
+	for i, e := range embs {
+		if len(e.Vector) != dim {
+			return nil, errors.DimensionMismatch(fmt.Sprintf("vector of %q", e.Word), len(e.Vector), dim)
+		}
+		vectors[i] = e.Vector
+		if config.Metric == Cosine {
+			vectors[i] = vecmath.Normalized(e.Vector)
+		}
+	}
+
+	var q quantizer
+	var err error
+	switch config.Method {
+	case "", Scalar:
+		config.Method = Scalar
+		q = trainScalar(vectors)
+	case Product:
+		q, err = trainProduct(vectors, &config)
+	default:
+		return nil, errors.Invalid("method", "unsupported method %q, expected sq8 or pq", config.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{config: config, dim: dim, words: make([]string, len(embs)), wordIDs: make(map[string]int, len(embs)), q: q}
+	idx.codes = make([]byte, len(embs)*q.codeSize())
+	for i, e := range embs {
+		idx.words[i] = e.Word
+		idx.wordIDs[e.Word] = i
+		q.encode(vectors[i], idx.code(i))
+	}
+	return idx, nil
+}
+
+func (idx *Index) Len() int {
+	return len(idx.words)
+}
+
+func (idx *Index) Dim() int {
+	return idx.dim
+}
+
+func (idx *Index) Config() Config {
+	return idx.config
+}
+
+// CodeSize is the bytes stored per vector
+func (idx *Index) CodeSize() int {
+	return idx.q.codeSize()
+}
+
+// Bytes is the memory the codes and the quantizer parameters take, without the words
+func (idx *Index) Bytes() int64 {
+	return int64(len(idx.codes)) + int64(idx.q.codebookSize())
+}
+
+func (idx *Index) code(id int) []byte {
+	size := idx.q.codeSize()
+	return idx.codes[id*size : (id+1)*size]
+}
+
+// Vector reconstructs the vector of a word (unit length for Cosine)
+func (idx *Index) Vector(word string) ([]float32, bool) {
+	id, ok := idx.wordIDs[word]
+	if !ok {
+		return nil, false
+	}
+	v := make([]float32, idx.dim)
+	idx.q.decode(idx.code(id), v)
+	return v, true
+}
+
+// Decode reconstructs all vectors, e.g. to save them in another format
+func (idx *Index) Decode() []embeddings.Embedding {
+	embs := make([]embeddings.Embedding, len(idx.words))
+	for i, word := range idx.words {
+		embs[i] = embeddings.Embedding{Word: word, Vector: make([]float32, idx.dim)}
+		idx.q.decode(idx.code(i), embs[i].Vector)
+	}
+	return embs
+}
+
+// Search scans every code for the k best scores against query
+func (idx *Index) Search(query []float32, k int) ([]Result, error) {
+	if len(query) != idx.dim {
+		return nil, errors.DimensionMismatch("query", len(query), idx.dim)
+	}
+	if idx.config.Metric == Cosine {
+		query = vecmath.Normalized(query)
+	}
+	return idx.search(query, k, -1), nil
+}
+
+// SearchWord finds the k nearest neighbours of an indexed word, excluding the word itself
+func (idx *Index) SearchWord(word string, k int) ([]Result, error) {
+	id, ok := idx.wordIDs[word]
+	if !ok {
+		return nil, fmt.Errorf("word %q is not indexed", word)
+	}
+	query := make([]float32, idx.dim)
+	idx.q.decode(idx.code(id), query)
+	return idx.search(query, k, id), nil
+}
+
+func (idx *Index) search(query []float32, k int, exclude int) []Result {
+	if k <= 0 {
+		return nil
+	}
+	score := idx.q.scorer(query, idx.config.Metric)
+	better := func(a, b float32) bool {
+		if idx.config.Metric == L2 {
+			return a < b
+		}
+		return a > b
+	}
+	results := make([]Result, 0, k+1)
+	for id := range idx.words {
+		if id == exclude {
+			continue
+		}
+		s := score(idx.code(id))
+		if len(results) == k && !better(s, results[k-1].Score) {
+			continue
+		}
+		// insert in order, dropping the worst once there are k
+		pos := sort.Search(len(results), func(i int) bool { return better(s, results[i].Score) })
+		results = append(results, Result{})
+		copy(results[pos+1:], results[pos:])
+		results[pos] = Result{ID: id, Word: idx.words[id], Score: s}
+		if len(results) > k {
+			results = results[:k]
+		}
+	}
+	return results
+}
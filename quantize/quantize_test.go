@@ -0,0 +1,218 @@
+package quantize
+
+import (
+	"encoding/gob"
+	stdErrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+const validModelPath = "../tests/mockdata/word_vector.txt"
+
+// randomEmbeddings returns n words with vectors clustered around a few centers, like real embeddings
+func randomEmbeddings(n int, dim int, seed int64) []embeddings.Embedding {
+	rng := rand.New(rand.NewSource(seed))
+	centers := make([][]float32, 8)
+	for c := range centers {
+		centers[c] = make([]float32, dim)
+		for j := range centers[c] {
+			centers[c][j] = float32(rng.NormFloat64())
+		}
+	}
+	embs := make([]embeddings.Embedding, n)
+	for i := range embs {
+		center := centers[rng.Intn(len(centers))]
+		embs[i] = embeddings.Embedding{Word: fmt.Sprintf("w%d", i), Vector: make([]float32, dim)}
+		for j := range center {
+			embs[i].Vector[j] = center[j] + float32(rng.NormFloat64()*0.3)
+		}
+	}
+	return embs
+}
+
+func TestTrain(t *testing.T) {
+	embs := randomEmbeddings(1000, 16, 1)
+	tests := []struct {
+		name      string
+		config    Config
+		codeSize  int
+		maxError  float64
+		minRecall float64
+	}{
+		{name: "Scalar L2", config: Config{}, codeSize: 16, maxError: 0.02, minRecall: 0.9},
+		{name: "Scalar cosine", config: Config{Metric: Cosine}, codeSize: 16, maxError: 0.02, minRecall: 0.9},
+		{name: "Scalar IP", config: Config{Metric: IP}, codeSize: 16, maxError: 0.02, minRecall: 0.9},
+		{name: "PQ L2", config: Config{Method: Product}, codeSize: 4, maxError: 0.4, minRecall: 0.3},
+		{name: "PQ cosine", config: Config{Method: Product, Metric: Cosine, Subspaces: 8}, codeSize: 8, maxError: 0.4, minRecall: 0.4},
+		{name: "PQ 4 bits", config: Config{Method: Product, Subspaces: 8, Bits: 4}, codeSize: 8, maxError: 0.5, minRecall: 0.2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idx, err := Train(embs, test.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if idx.CodeSize() != test.codeSize {
+				t.Errorf("code size = %d, want %d", idx.CodeSize(), test.codeSize)
+			}
+			report, err := Evaluate(embs, idx, 10, 100, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report.RelativeError > test.maxError || report.Recall < test.minRecall || report.Ratio <= 1 {
+				t.Errorf("relative error %f, recall %f, ratio %f", report.RelativeError, report.Recall, report.Ratio)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	embs, err := embeddings.Load(validModelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := Train(embs, Config{Metric: Cosine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := idx.Search(embs[3].Vector, 3)
+	if err != nil || len(results) != 3 || results[0].Word != embs[3].Word || results[0].Score < 0.99 {
+		t.Errorf("results = %+v, %v", results, err)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("results are not sorted: %+v", results)
+		}
+	}
+	neighbours, err := idx.SearchWord(embs[3].Word, 3)
+	if err != nil || len(neighbours) != 3 || neighbours[0].Word == embs[3].Word {
+		t.Errorf("neighbours = %+v, %v", neighbours, err)
+	}
+	if _, err := idx.SearchWord("unicorn", 3); err == nil {
+		t.Error("expected an error for an unknown word")
+	}
+	if _, err := idx.Search([]float32{1}, 3); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+	if v, ok := idx.Vector(embs[0].Word); !ok || len(v) != 10 || math.Abs(float64(v[0]-vecmath.Normalized(embs[0].Vector)[0])) > 0.01 {
+		t.Errorf("vector = %v", v)
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	embs := randomEmbeddings(50, 10, 2)
+	for name, config := range map[string]Config{
+		"unknown method":        {Method: "opq"},
+		"unknown metric":        {Metric: "HAMMING"},
+		"subspaces not divisor": {Method: Product, Subspaces: 3},
+		"too many bits":         {Method: Product, Bits: 9},
+	} {
+		if _, err := Train(embs, config); !stdErrors.Is(err, errors.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+	if _, err := Train(nil, Config{}); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for no vectors, got %v", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	embs := randomEmbeddings(300, 12, 3)
+	for _, method := range []Method{Scalar, Product} {
+		idx, err := Train(embs, Config{Method: method, Metric: IP})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "vectors.q")
+		if err := idx.Save(path); err != nil {
+			t.Fatalf("unexpected error saving: %v", err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error loading: %v", err)
+		}
+		if loaded.Len() != idx.Len() || loaded.Config() != idx.Config() || loaded.Bytes() != idx.Bytes() {
+			t.Errorf("%s: loaded %d vectors with %+v", method, loaded.Len(), loaded.Config())
+		}
+		want, _ := idx.SearchWord(embs[5].Word, 5)
+		got, _ := loaded.SearchWord(embs[5].Word, 5)
+		for i := range want {
+			if want[i] != got[i] {
+				t.Errorf("%s: loaded index returns %+v, want %+v", method, got, want)
+				break
+			}
+		}
+	}
+	if _, err := Load(validModelPath); !stdErrors.Is(err, errors.ErrFileFormat) {
+		t.Errorf("expected a file format error for a text vector file, got %v", err)
+	}
+	if _, err := Load("imaginary/path.q"); !stdErrors.Is(err, errors.ErrFileNotFound) {
+		t.Errorf("expected a file not found error, got %v", err)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	embs := randomEmbeddings(50, 8, 4)
+	sq, err := Train(embs, Config{Method: Scalar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pq, err := Train(embs, Config{Method: Product, Subspaces: 2, Bits: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotOf := func(idx *Index) snapshot {
+		snap := snapshot{Version: formatVersion, Config: idx.config, Dim: idx.dim, Words: idx.words, Codes: append([]byte(nil), idx.codes...)}
+		switch q := idx.q.(type) {
+		case *scalar:
+			snap.Scalar = &scalar{Min: append([]float32(nil), q.Min...), Step: append([]float32(nil), q.Step...)}
+		case *product:
+			snap.Product = &product{SubDim: q.SubDim, Codebooks: append([][][]float32(nil), q.Codebooks...)}
+		}
+		return snap
+	}
+
+	tests := map[string]func() snapshot{
+		"short sq8 steps": func() snapshot { s := snapshotOf(sq); s.Scalar.Step = s.Scalar.Step[:3]; return s },
+		"no metric":       func() snapshot { s := snapshotOf(sq); s.Config.Metric = ""; return s },
+		"wrong method":    func() snapshot { s := snapshotOf(sq); s.Config.Method = Product; return s },
+		"missing codes":   func() snapshot { s := snapshotOf(sq); s.Codes = s.Codes[1:]; return s },
+		"code width":      func() snapshot { s := snapshotOf(pq); s.Config.Bits = 9; return s },
+		"subspaces":       func() snapshot { s := snapshotOf(pq); s.Config.Subspaces = 4; return s },
+		"empty codebook":  func() snapshot { s := snapshotOf(pq); s.Product.Codebooks[1] = nil; return s },
+		"short centroid": func() snapshot {
+			s := snapshotOf(pq)
+			s.Product.Codebooks[0] = append([][]float32{{1}}, s.Product.Codebooks[0][1:]...)
+			return s
+		},
+		"code past the codebook": func() snapshot {
+			s := snapshotOf(pq)
+			s.Product.Codebooks[1] = s.Product.Codebooks[1][:1]
+			s.Codes[1] = 1
+			return s
+		},
+	}
+	for name, corrupt := range tests {
+		path := filepath.Join(t.TempDir(), "vectors.q")
+		output, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snap := corrupt()
+		if err := gob.NewEncoder(output).Encode(&snap); err != nil {
+			t.Fatal(err)
+		}
+		output.Close()
+		if _, err := Load(path); !stdErrors.Is(err, errors.ErrFileFormat) {
+			t.Errorf("%s: expected a file format error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package quantize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+// Report compares an Index with the float32 vectors it was trained on
+type Report struct {
+	Method          Method  `json:"method"`
+	Metric          Metric  `json:"metric"`
+	Vectors         int     `json:"vectors"`
+	Dim             int     `json:"dim"`
+	CodeSize        int     `json:"code_size"`        // bytes per vector
+	OriginalBytes   int64   `json:"original_bytes"`   // float32 vectors
+	CompressedBytes int64   `json:"compressed_bytes"` // codes plus quantizer parameters
+	Ratio           float64 `json:"compression_ratio"`
+	// MSE is the mean squared error per component of the reconstructed vectors, RelativeError the
+	// mean of |x - x̂| / |x|. Both are measured on unit vectors for Cosine.
+	MSE           float64 `json:"mse"`
+	RelativeError float64 `json:"relative_error"`
+	K             int     `json:"k"`
+	Queries       int     `json:"queries"`
+	// Recall is the fraction of the exact float32 k nearest neighbours of the query words that ADC
+	// search with the float32 query vector also returns
+	Recall float64 `json:"recall"`
+}
+
+// Evaluate measures the reconstruction error over all of embs and the recall@k of ADC search for
+// queries words sampled from them (all words if queries <= 0 or more than there are)
+func Evaluate(embs []embeddings.Embedding, idx *Index, k int, queries int, seed int64) (*Report, error) {
+	if len(embs) != idx.Len() {
+		return nil, errors.Invalid("embeddings", "index has %d vectors, got %d embeddings", idx.Len(), len(embs))
+	}
+	if k <= 0 {
+		return nil, errors.Invalid("k", "k must be positive, got %d", k)
+	}
+	report := &Report{
+		Method:          idx.config.Method,
+		Metric:          idx.config.Metric,
+		Vectors:         idx.Len(),
+		Dim:             idx.dim,
+		CodeSize:        idx.CodeSize(),
+		OriginalBytes:   int64(idx.Len()) * int64(idx.dim) * 4,
+		CompressedBytes: idx.Bytes(),
+		K:               k,
+	}
+	report.Ratio = float64(report.OriginalBytes) / float64(report.CompressedBytes)
+
+	originals := make([][]float32, len(embs))
+	decoded := make([]float32, idx.dim)
+	var squared, relative float64
+	for i, e := range embs {
+		if len(e.Vector) != idx.dim {
+			return nil, errors.DimensionMismatch("vector", len(e.Vector), idx.dim)
+		}
+		originals[i] = e.Vector
+		if idx.config.Metric == Cosine {
+			originals[i] = vecmath.Normalized(e.Vector)
+		}
+		idx.q.decode(idx.code(i), decoded)
+		var errNorm, norm float64
+		for j, x := range originals[i] {
+			d := float64(x - decoded[j])
+			errNorm += d * d
+			norm += float64(x) * float64(x)
+		}
+		squared += errNorm
+		if norm > 0 {
+			relative += math.Sqrt(errNorm / norm)
+		}
+	}
+	report.MSE = squared / float64(len(embs)*idx.dim)
+	report.RelativeError = relative / float64(len(embs))
+
+	ids := rand.New(rand.NewSource(seed)).Perm(len(embs))
+	if queries > 0 && queries < len(ids) {
+		ids = ids[:queries]
+	}
+	report.Queries = len(ids)
+	var found int
+	for _, id := range ids {
+		exact := exactNeighbours(originals, id, k, idx.config.Metric)
+		// ADC: the float32 query against the codes
+		results := idx.search(originals[id], k, id)
+		approximate := make(map[int]bool, len(results))
+		for _, r := range results {
+			approximate[r.ID] = true
+		}
+		for _, n := range exact {
+			if approximate[n] {
+				found++
+			}
+		}
+		report.Recall += float64(len(exact))
+	}
+	if report.Recall > 0 {
+		report.Recall = float64(found) / report.Recall
+	}
+	return report, nil
+}
+
+// exactNeighbours brute forces the k nearest neighbours of vectors[id], excluding id
+func exactNeighbours(vectors [][]float32, id int, k int, metric Metric) []int {
+	type scored struct {
+		id    int
+		score float64
+	}
+	candidates := make([]scored, 0, len(vectors)-1)
+	query := vectors[id]
+	for i, v := range vectors {
+		if i == id {
+			continue
+		}
+		var s float64
+		for j, x := range v {
+			if metric == L2 {
+				d := float64(query[j] - x)
+				s += d * d
+			} else {
+				s += float64(query[j]) * float64(x)
+			}
+		}
+		candidates = append(candidates, scored{id: i, score: s})
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if metric == L2 {
+			return candidates[a].score < candidates[b].score
+		}
+		return candidates[a].score > candidates[b].score
+	})
+	neighbours := make([]int, 0, k)
+	for _, c := range candidates[:min(k, len(candidates))] {
+		neighbours = append(neighbours, c.id)
+	}
+	return neighbours
+}
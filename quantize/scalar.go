@@ -0,0 +1,83 @@
+package quantize
+
+import "math"
+
+// scalar maps each component to 256 levels between the minimum and maximum of its dimension
+type scalar struct {
+	Min  []float32
+	Step []float32 // (max - min) / 255, 0 for a constant dimension
+}
+
+func trainScalar(vectors [][]float32) *scalar {
+	dim := len(vectors[0])
+	s := &scalar{Min: make([]float32, dim), Step: make([]float32, dim)}
+	max := make([]float32, dim)
+	copy(s.Min, vectors[0])
+	copy(max, vectors[0])
+	for _, v := range vectors {
+		for j, x := range v {
+			if x < s.Min[j] {
+				s.Min[j] = x
+			}
+			if x > max[j] {
+				max[j] = x
+			}
+		}
+	}
+	for j := range s.Step {
+		s.Step[j] = (max[j] - s.Min[j]) / 255
+	}
+	return s
+}
+
+func (s *scalar) codeSize() int {
+	return len(s.Min)
+}
+
+func (s *scalar) codebookSize() int {
+	return 8 * len(s.Min)
+}
+
+func (s *scalar) encode(v []float32, code []byte) {
+	for j, x := range v {
+		if s.Step[j] == 0 {
+			code[j] = 0
+			continue
+		}
+		level := math.Round(float64((x - s.Min[j]) / s.Step[j]))
+		code[j] = byte(math.Max(0, math.Min(255, level)))
+	}
+}
+
+func (s *scalar) decode(code []byte, v []float32) {
+	for j, c := range code {
+		v[j] = s.Min[j] + float32(c)*s.Step[j]
+	}
+}
+
+func (s *scalar) scorer(query []float32, metric Metric) func(code []byte) float32 {
+	if metric == L2 {
+		return func(code []byte) float32 {
+			var sum float32
+			for j, c := range code {
+				d := query[j] - (s.Min[j] + float32(c)*s.Step[j])
+				sum += d * d
+			}
+			return sum
+		}
+	}
+	// q·x = Σ q_j min_j + Σ (q_j step_j) c_j
+	var offset float32
+	scaled := make([]float32, len(query))
+	for j, x := range query {
+		offset += x * s.Min[j]
+		scaled[j] = x * s.Step[j]
+	}
+	return func(code []byte) float32 {
+		sum := offset
+		for j, c := range code {
+			sum += scaled[j] * float32(c)
+		}
+		return sum
+	}
+}
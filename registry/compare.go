@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Difference is a manifest field that differs between two versions
+type Difference struct {
+	Field string
+	A     string
+	B     string
+}
+
+// Compare lists the fields in which a and b differ: algorithm, hyperparameters, corpus, size,
+// training time and scores. Hyperparameters and scores are reported per key.
+func Compare(a Manifest, b Manifest) []Difference {
+	var diffs []Difference
+	add := func(field string, va string, vb string) {
+		if va != vb {
+			diffs = append(diffs, Difference{Field: field, A: va, B: vb})
+		}
+	}
+
+	add("algorithm", a.Algorithm, b.Algorithm)
+	for _, k := range unionKeys(keys(a.Hyperparameters), keys(b.Hyperparameters)) {
+		add("hyperparameters."+k, a.Hyperparameters[k], b.Hyperparameters[k])
+	}
+	add("corpus", a.Corpus, b.Corpus)
+	add("corpus_sha256", a.CorpusSHA256, b.CorpusSHA256)
+	add("parent", a.Parent, b.Parent)
+	add("vocab_size", strconv.Itoa(a.VocabSize), strconv.Itoa(b.VocabSize))
+	add("dimension", strconv.Itoa(a.Dimension), strconv.Itoa(b.Dimension))
+	add("training_seconds", fmt.Sprintf("%.1f", a.TrainingSeconds), fmt.Sprintf("%.1f", b.TrainingSeconds))
+	var scoreKeysA, scoreKeysB []string
+	for k := range a.Scores {
+		scoreKeysA = append(scoreKeysA, k)
+	}
+	for k := range b.Scores {
+		scoreKeysB = append(scoreKeysB, k)
+	}
+	for _, k := range unionKeys(scoreKeysA, scoreKeysB) {
+		add("scores."+k, formatScore(a.Scores, k), formatScore(b.Scores, k))
+	}
+	return diffs
+}
+
+func formatScore(scores map[string]float64, key string) string {
+	v, ok := scores[key]
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func keys(m map[string]string) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+// unionKeys returns the keys in either list, sorted and without duplicates
+func unionKeys(a []string, b []string) []string {
+	set := map[string]bool{}
+	for _, k := range append(append([]string(nil), a...), b...) {
+		set[k] = true
+	}
+	union := make([]string, 0, len(set))
+	for k := range set {
+		union = append(union, k)
+	}
+	sort.Strings(union)
+	return union
+}
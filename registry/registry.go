@@ -0,0 +1,433 @@
+// Package registry is a local store of trained word vector models. Every model name has numbered
+// versions, each a directory holding the vector file and a manifest of how it was produced:
+//
+//	models/words/1/manifest.json
+//	models/words/1/vectors.txt
+//	models/words/2/...
+//	models/words/aliases.json    {"production": 1}
+//
+// Models are referenced as "name" (the production version if there is one, else the latest),
+// "name@2", "name@latest" or "name@<alias>".
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"milvus/atomicfile"
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+// Production is the alias Promote uses by default, and the version a bare model name resolves to
+const Production = "production"
+
+const (
+	manifestFile = "manifest.json"
+	aliasesFile  = "aliases.json"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Manifest records how a model version was produced
+type Manifest struct {
+	Name            string             `json:"name"`
+	Version         int                `json:"version"`
+	Algorithm       string             `json:"algorithm"`
+	Hyperparameters map[string]string  `json:"hyperparameters,omitempty"`
+	Corpus          string             `json:"corpus,omitempty"`
+	CorpusSHA256    string             `json:"corpus_sha256,omitempty"`
+	Parent          string             `json:"parent,omitempty"` // model training continued from, if any
+	VocabSize       int                `json:"vocab_size"`
+	Dimension       int                `json:"dimension"`
+	TrainedAt       time.Time          `json:"trained_at"`
+	TrainingSeconds float64            `json:"training_seconds"`
+	Scores          map[string]float64 `json:"scores,omitempty"` // evaluation scores, e.g. analogy accuracy
+	File            string             `json:"file"`             // vector file, relative to the version directory
+}
+
+// Ref is name@version
+func (m Manifest) Ref() string {
+	return fmt.Sprintf("%s@%d", m.Name, m.Version)
+}
+
+type Registry struct {
+	root string
+}
+
+// Open returns the registry rooted at dir, creating dir if needed
+func Open(dir string) (*Registry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.FileCreationErr(dir, err)
+	}
+	return &Registry{root: dir}, nil
+}
+
+func (r *Registry) Root() string {
+	return r.root
+}
+
+// ParseRef splits "name@version" into its parts; version is "" for a bare name
+func ParseRef(ref string) (name string, version string, err error) {
+	name, version, _ = strings.Cut(ref, "@")
+	if !validName.MatchString(name) {
+		return "", "", errors.Invalid("model", "invalid model name %q, expected letters, digits, '.', '_' or '-'", name)
+	}
+	if strings.Contains(ref, "@") && !validName.MatchString(version) {
+		return "", "", errors.Invalid("model", "invalid version %q in %q", version, ref)
+	}
+	return name, version, nil
+}
+
+// Register stores a copy of the vector file at vectorsPath, and of its vocabulary file for a .npy
+// matrix, as the next version of m.Name and returns its manifest with Version and File filled in
+func (r *Registry) Register(m Manifest, vectorsPath string) (Manifest, error) {
+	if _, _, err := ParseRef(m.Name); err != nil {
+		return Manifest{}, err
+	}
+	if m.TrainedAt.IsZero() {
+		m.TrainedAt = time.Now().UTC()
+	}
+	m.File = "vectors" + filepath.Ext(vectorsPath)
+	if m.File == "vectors" {
+		m.File = "vectors.txt"
+	}
+
+	versions, err := r.versionNumbers(m.Name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m.Version = 1
+	if len(versions) > 0 {
+		m.Version = versions[len(versions)-1] + 1
+	}
+	if err := os.MkdirAll(filepath.Join(r.root, m.Name), 0755); err != nil {
+		return Manifest{}, errors.FileCreationErr(filepath.Join(r.root, m.Name), err)
+	}
+	// Mkdir fails if a concurrent Register took the number first, so try the next one
+	for {
+		err := os.Mkdir(r.versionDir(m.Name, m.Version), 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return Manifest{}, errors.FileCreationErr(r.versionDir(m.Name, m.Version), err)
+		}
+		m.Version++
+	}
+
+	dir := r.versionDir(m.Name, m.Version)
+	if err := copyFile(vectorsPath, filepath.Join(dir, m.File)); err != nil {
+		os.RemoveAll(dir)
+		return Manifest{}, err
+	}
+	// A .npy matrix keeps its words in a sidecar file, which goes along under the name
+	// embeddings.Load looks for next to the copy
+	if format, err := embeddings.DetectFormat(vectorsPath); err == nil && format == embeddings.FormatNumPy {
+		if err := copyFile(embeddings.VocabPath(vectorsPath), embeddings.VocabPath(filepath.Join(dir, m.File))); err != nil {
+			os.RemoveAll(dir)
+			return Manifest{}, err
+		}
+	}
+	// The manifest goes last: a version directory without one is an unfinished Register
+	if err := r.writeManifest(m); err != nil {
+		os.RemoveAll(dir)
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+// Models lists the registered model names
+func (r *Registry) Models() ([]string, error) {
+	entries, err := os.ReadDir(r.root)
+	if err != nil {
+		return nil, errors.FileLoadingError(r.root, err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || !validName.MatchString(e.Name()) {
+			continue
+		}
+		if versions, err := r.versionNumbers(e.Name()); err == nil && len(versions) > 0 {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Versions returns the manifests of every version of name, oldest first
+func (r *Registry) Versions(name string) ([]Manifest, error) {
+	if _, _, err := ParseRef(name); err != nil {
+		return nil, err
+	}
+	numbers, err := r.versionNumbers(name)
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]Manifest, 0, len(numbers))
+	for _, v := range numbers {
+		m, err := r.readManifest(name, v)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// Get resolves ref to the manifest of a version
+func (r *Registry) Get(ref string) (Manifest, error) {
+	name, version, err := ParseRef(ref)
+	if err != nil {
+		return Manifest{}, err
+	}
+	numbers, err := r.versionNumbers(name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if len(numbers) == 0 {
+		return Manifest{}, errors.ModelLoadingError(ref, fmt.Errorf("no model named %s in %s", name, r.root))
+	}
+
+	aliases, err := r.Aliases(name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var number int
+	switch {
+	case version == "" && aliases[Production] != 0:
+		number = aliases[Production]
+	case version == "" || version == "latest":
+		number = numbers[len(numbers)-1]
+	case aliases[version] != 0:
+		number = aliases[version]
+	default:
+		if number, err = strconv.Atoi(strings.TrimPrefix(version, "v")); err != nil {
+			return Manifest{}, errors.ModelLoadingError(ref, fmt.Errorf("model %s has no version or alias %q", name, version))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(r.versionDir(name, number), manifestFile)); err != nil {
+		return Manifest{}, errors.ModelLoadingError(ref, fmt.Errorf("model %s has no version %d", name, number))
+	}
+	return r.readManifest(name, number)
+}
+
+// Path resolves ref to its vector file, ready for embeddings.Load, vectorize.QueryVector and the like
+func (r *Registry) Path(ref string) (string, error) {
+	m, err := r.Get(ref)
+	if err != nil {
+		return "", err
+	}
+	return r.FilePath(m), nil
+}
+
+// FilePath is the vector file of a version
+func (r *Registry) FilePath(m Manifest) string {
+	return filepath.Join(r.versionDir(m.Name, m.Version), m.File)
+}
+
+// Aliases returns the aliases of name and the versions they point to
+func (r *Registry) Aliases(name string) (map[string]int, error) {
+	aliases := map[string]int{}
+	data, err := os.ReadFile(filepath.Join(r.root, name, aliasesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return aliases, nil
+		}
+		return nil, errors.FileLoadingError(filepath.Join(r.root, name, aliasesFile), err)
+	}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, errors.FileFormatError(filepath.Join(r.root, name, aliasesFile), err)
+	}
+	return aliases, nil
+}
+
+// Promote points alias (Production if empty) at the version ref resolves to, and returns it
+func (r *Registry) Promote(ref string, alias string) (Manifest, error) {
+	if alias == "" {
+		alias = Production
+	}
+	if !validName.MatchString(alias) || alias == "latest" {
+		return Manifest{}, errors.Invalid("alias", "invalid alias %q", alias)
+	}
+	if _, err := strconv.Atoi(strings.TrimPrefix(alias, "v")); err == nil {
+		return Manifest{}, errors.Invalid("alias", "alias %q would shadow a version number", alias)
+	}
+	m, err := r.Get(ref)
+	if err != nil {
+		return Manifest{}, err
+	}
+	aliases, err := r.Aliases(m.Name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	aliases[alias] = m.Version
+	return m, r.writeAliases(m.Name, aliases)
+}
+
+// Delete removes the version ref resolves to. A version an alias points to is only deleted with
+// force, which drops the alias too.
+func (r *Registry) Delete(ref string, force bool) (Manifest, error) {
+	m, err := r.Get(ref)
+	if err != nil {
+		return Manifest{}, err
+	}
+	aliases, err := r.Aliases(m.Name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var pointing []string
+	for alias, v := range aliases {
+		if v == m.Version {
+			pointing = append(pointing, alias)
+		}
+	}
+	sort.Strings(pointing)
+	if len(pointing) > 0 && !force {
+		return Manifest{}, errors.Invalid("model", "%s is %s, promote another version first or force the delete", m.Ref(), strings.Join(pointing, ", "))
+	}
+	for _, alias := range pointing {
+		delete(aliases, alias)
+	}
+	if len(pointing) > 0 {
+		if err := r.writeAliases(m.Name, aliases); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	// Remove the manifest first so a half deleted version no longer resolves
+	dir := r.versionDir(m.Name, m.Version)
+	if err := os.Remove(filepath.Join(dir, manifestFile)); err != nil {
+		return Manifest{}, errors.FileLoadingError(dir, err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return Manifest{}, errors.FileLoadingError(dir, err)
+	}
+	if versions, err := r.versionNumbers(m.Name); err == nil && len(versions) == 0 {
+		os.RemoveAll(filepath.Join(r.root, m.Name))
+	}
+	return m, nil
+}
+
+// Score merges evaluation scores into the manifest of the version ref resolves to
+func (r *Registry) Score(ref string, scores map[string]float64) (Manifest, error) {
+	m, err := r.Get(ref)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if m.Scores == nil {
+		m.Scores = map[string]float64{}
+	}
+	for k, v := range scores {
+		m.Scores[k] = v
+	}
+	return m, r.writeManifest(m)
+}
+
+// HashFile returns the hex SHA-256 of a file, used to record which corpus a model was trained on
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.FileLoadingError(path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.FileLoadingError(path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (r *Registry) versionDir(name string, version int) string {
+	return filepath.Join(r.root, name, strconv.Itoa(version))
+}
+
+// versionNumbers lists the complete versions of name in ascending order
+func (r *Registry) versionNumbers(name string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(r.root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.FileLoadingError(filepath.Join(r.root, name), err)
+	}
+	var numbers []int
+	for _, e := range entries {
+		v, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() || v <= 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(r.root, name, e.Name(), manifestFile)); err == nil {
+			numbers = append(numbers, v)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+func (r *Registry) readManifest(name string, version int) (Manifest, error) {
+	path := filepath.Join(r.versionDir(name, version), manifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, errors.FileLoadingError(path, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, errors.FileFormatError(path, err)
+	}
+	return m, nil
+}
+
+func (r *Registry) writeManifest(m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteBytes(filepath.Join(r.versionDir(m.Name, m.Version), manifestFile), data)
+}
+
+func (r *Registry) writeAliases(name string, aliases map[string]int) error {
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteBytes(filepath.Join(r.root, name, aliasesFile), data)
+}
+
+func copyFile(src string, dst string) error {
+	input, err := os.Open(src)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return errors.FileNotFound(src, err)
+		}
+		return errors.FileLoadingError(src, err)
+	}
+	defer input.Close()
+	output, err := os.Create(dst)
+	if err != nil {
+		return errors.FileCreationErr(dst, err)
+	}
+	if _, err := io.Copy(output, input); err != nil {
+		output.Close()
+		return errors.FileCreationErr(dst, err)
+	}
+	if err := output.Sync(); err != nil {
+		output.Close()
+		return errors.FileCreationErr(dst, err)
+	}
+	if err := output.Close(); err != nil {
+		return errors.FileCreationErr(dst, err)
+	}
+	return nil
+}
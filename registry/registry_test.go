@@ -0,0 +1,191 @@
+package registry
+
+import (
+	stdErrors "errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"milvus/embeddings"
+	"milvus/errors"
+)
+
+const validModelPath = "../tests/mockdata/word_vector.txt"
+
+func register(t *testing.T, reg *Registry, m Manifest) Manifest {
+	t.Helper()
+	m, err := reg.Register(m, validModelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRegistry(t *testing.T) {
+	reg, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := register(t, reg, Manifest{Name: "words", Algorithm: "word2vec", VocabSize: 20, Dimension: 10, Hyperparameters: map[string]string{"dim": "10", "window": "5"}})
+	v2 := register(t, reg, Manifest{Name: "words", Algorithm: "word2vec", VocabSize: 20, Dimension: 10, Hyperparameters: map[string]string{"dim": "10", "window": "8"}})
+	register(t, reg, Manifest{Name: "glove", Algorithm: "glove", VocabSize: 20, Dimension: 10})
+	if v1.Version != 1 || v2.Version != 2 || v1.File != "vectors.txt" || v1.TrainedAt.IsZero() {
+		t.Fatalf("registered %+v and %+v", v1, v2)
+	}
+	if data, err := os.ReadFile(reg.FilePath(v2)); err != nil || len(data) == 0 {
+		t.Fatalf("vector file was not copied: %v", err)
+	}
+
+	if names, err := reg.Models(); err != nil || !reflect.DeepEqual(names, []string{"glove", "words"}) {
+		t.Errorf("Models() = %v, %v", names, err)
+	}
+	if versions, err := reg.Versions("words"); err != nil || len(versions) != 2 || versions[1].Version != 2 {
+		t.Errorf("Versions(words) = %v, %v", versions, err)
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		version int
+		setup   func()
+	}{
+		{name: "Bare name is the latest version", ref: "words", version: 2},
+		{name: "Version number", ref: "words@1", version: 1},
+		{name: "Version with v prefix", ref: "words@v1", version: 1},
+		{name: "Latest", ref: "words@latest", version: 2},
+		{
+			name:    "Bare name is production once promoted",
+			ref:     "words",
+			version: 1,
+			setup: func() {
+				if _, err := reg.Promote("words@1", ""); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{name: "Latest ignores production", ref: "words@latest", version: 2},
+		{
+			name:    "Custom alias",
+			ref:     "words@staging",
+			version: 2,
+			setup: func() {
+				if _, err := reg.Promote("words@latest", "staging"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			m, err := reg.Get(tt.ref)
+			if err != nil || m.Version != tt.version {
+				t.Errorf("Get(%q) = version %d, %v, want %d", tt.ref, m.Version, err, tt.version)
+			}
+		})
+	}
+
+	for _, ref := range []string{"words@3", "words@beta", "sentences"} {
+		if _, err := reg.Get(ref); !stdErrors.Is(err, errors.ErrModelLoading) {
+			t.Errorf("Get(%q): expected a model loading error, got %v", ref, err)
+		}
+	}
+	for _, ref := range []string{"", "../words", "words@", "words@1/2"} {
+		if _, err := reg.Get(ref); !stdErrors.Is(err, errors.ErrValidation) {
+			t.Errorf("Get(%q): expected a validation error, got %v", ref, err)
+		}
+	}
+	if _, err := reg.Promote("words@1", "7"); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("an alias that looks like a version was accepted: %v", err)
+	}
+}
+
+func TestRegisterNumPy(t *testing.T) {
+	embs, err := embeddings.Load(validModelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "words.npy")
+	if err := embeddings.Save(path, embs, embeddings.FormatNumPy); err != nil {
+		t.Fatal(err)
+	}
+	reg, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := reg.Register(Manifest{Name: "words"}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := embeddings.Load(reg.FilePath(m))
+	if err != nil {
+		t.Fatalf("registered .npy model doesn't load: %v", err)
+	}
+	if len(loaded) != len(embs) || loaded[0].Word != embs[0].Word {
+		t.Errorf("loaded %d words starting with %q, want %d starting with %q", len(loaded), loaded[0].Word, len(embs), embs[0].Word)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	reg, _ := Open(t.TempDir())
+	register(t, reg, Manifest{Name: "words"})
+	register(t, reg, Manifest{Name: "words"})
+	reg.Promote("words@2", Production)
+
+	if _, err := reg.Delete("words@2", false); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Fatalf("deleted the production version without force: %v", err)
+	}
+	if _, err := reg.Delete("words@1", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Get("words@1"); err == nil {
+		t.Error("deleted version still resolves")
+	}
+
+	if _, err := reg.Delete("words@2", true); err != nil {
+		t.Fatal(err)
+	}
+	if aliases, _ := reg.Aliases("words"); len(aliases) != 0 {
+		t.Errorf("alias of a deleted version was kept: %v", aliases)
+	}
+	if names, _ := reg.Models(); len(names) != 0 {
+		t.Errorf("model without versions is still listed: %v", names)
+	}
+	if _, err := os.Stat(filepath.Join(reg.Root(), "words")); !os.IsNotExist(err) {
+		t.Errorf("empty model directory was kept: %v", err)
+	}
+}
+
+func TestScoreAndCompare(t *testing.T) {
+	reg, _ := Open(t.TempDir())
+	a := register(t, reg, Manifest{Name: "words", Algorithm: "word2vec", VocabSize: 20, Dimension: 10, CorpusSHA256: "abc",
+		Hyperparameters: map[string]string{"dim": "10", "window": "5"}})
+	b := register(t, reg, Manifest{Name: "words", Algorithm: "word2vec", VocabSize: 25, Dimension: 10, CorpusSHA256: "def",
+		Hyperparameters: map[string]string{"dim": "10", "iter": "5"}})
+
+	if _, err := reg.Score("words@1", map[string]float64{"analogy": 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := reg.Score("words@1", map[string]float64{"similarity": 0.7})
+	if err != nil || !reflect.DeepEqual(a.Scores, map[string]float64{"analogy": 0.5, "similarity": 0.7}) {
+		t.Fatalf("scores = %v, %v", a.Scores, err)
+	}
+
+	expected := []Difference{
+		{Field: "hyperparameters.iter", A: "", B: "5"},
+		{Field: "hyperparameters.window", A: "5", B: ""},
+		{Field: "corpus_sha256", A: "abc", B: "def"},
+		{Field: "vocab_size", A: "20", B: "25"},
+		{Field: "scores.analogy", A: "0.5", B: ""},
+		{Field: "scores.similarity", A: "0.7", B: ""},
+	}
+	if diffs := Compare(a, b); !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("Compare() = %+v\nwant %+v", diffs, expected)
+	}
+	if diffs := Compare(b, b); len(diffs) != 0 {
+		t.Errorf("a version differs from itself: %+v", diffs)
+	}
+}
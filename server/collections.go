@@ -0,0 +1,226 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+type createCollectionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Dim         int    `json:"dim"`
+	IDField     string `json:"id_field"`
+	IDType      string `json:"id_type"` // varchar or int64
+	MaxLength   int    `json:"max_length"`
+	AutoID      bool   `json:"auto_id"`
+	VectorField string `json:"vector_field"`
+	Shards      int32  `json:"shards"`
+	Dynamic     bool   `json:"dynamic"`
+}
+
+func (req *createCollectionRequest) validate() error {
+	if req.Name == "" {
+		return badRequest("name is required")
+	}
+	if req.Dim <= 0 {
+		return badRequest("dim must be positive")
+	}
+	if req.IDField == "" {
+		req.IDField = "word"
+	}
+	if req.IDType == "" {
+		req.IDType = "varchar"
+	}
+	if req.MaxLength == 0 {
+		req.MaxLength = 100
+	}
+	if req.VectorField == "" {
+		req.VectorField = "embedding"
+	}
+	if req.Shards == 0 {
+		req.Shards = 2
+	}
+	if req.AutoID && req.IDType != "int64" {
+		return badRequest("auto_id needs an int64 id_type")
+	}
+	return nil
+}
+
+type collectionSummary struct {
+	Name   string `json:"name"`
+	ID     int64  `json:"id"`
+	Loaded bool   `json:"loaded"`
+}
+
+// collections lists (GET, paged) or creates (POST) collections
+func (s *Server) collections(w http.ResponseWriter, r *http.Request) error {
+	if err := allow(r, http.MethodGet, http.MethodPost); err != nil {
+		return err
+	}
+	if err := s.requireMilvus(); err != nil {
+		return err
+	}
+
+	if r.Method == http.MethodGet {
+		offset, limit, err := page(r)
+		if err != nil {
+			return err
+		}
+		collections, err := vectordb.ListCollections(s.milvusClient, r.Context())
+		if err != nil {
+			return err
+		}
+		sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+		total := len(collections)
+		if offset > total {
+			offset = total
+		}
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		summaries := make([]collectionSummary, 0, end-offset)
+		for _, c := range collections[offset:end] {
+			summaries = append(summaries, collectionSummary{Name: c.Name, ID: c.ID, Loaded: c.Loaded})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"collections": summaries,
+			"offset":      offset,
+			"limit":       limit,
+			"total":       total,
+		})
+		return nil
+	}
+
+	var req createCollectionRequest
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	var pk *entity.Field
+	switch strings.ToLower(req.IDType) {
+	case "varchar":
+		pk = vectordb.NewFieldVarChar(req.IDField, req.MaxLength, true, false)
+	case "int64":
+		pk = vectordb.NewFieldInt64(req.IDField, true, req.AutoID)
+	default:
+		return badRequest("id_type must be varchar or int64")
+	}
+
+	exists, err := s.milvusClient.HasCollection(r.Context(), req.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &requestError{status: http.StatusConflict, message: fmt.Sprintf("collection %s already exists", req.Name)}
+	}
+	err = vectordb.NewCollectionBuilder().
+		WithName(req.Name).
+		WithDescription(req.Description).
+		WithShardNum(req.Shards).
+		WithDynamicField(req.Dynamic).
+		WithFields(pk, vectordb.NewFieldFloatVector(req.VectorField, req.Dim)).
+		Create(s.milvusClient, r.Context())
+	if err != nil {
+		return err
+	}
+	return s.describe(w, r, req.Name, http.StatusCreated)
+}
+
+// collection routes /v1/collections/{name}[/{action}]
+func (s *Server) collection(w http.ResponseWriter, r *http.Request) error {
+	if err := s.requireMilvus(); err != nil {
+		return err
+	}
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/collections/"), "/")
+	if name == "" {
+		return notFound("no collection in path %s", r.URL.Path)
+	}
+	if err := s.exists(r, name); err != nil {
+		return err
+	}
+
+	switch action {
+	case "":
+		if err := allow(r, http.MethodGet, http.MethodDelete); err != nil {
+			return err
+		}
+		if r.Method == http.MethodDelete {
+			return s.drop(w, r, name)
+		}
+		return s.describe(w, r, name, http.StatusOK)
+	case "load", "release":
+		if err := allow(r, http.MethodPost); err != nil {
+			return err
+		}
+		do := vectordb.LoadCollection
+		if action == "release" {
+			do = vectordb.ReleaseCollection
+		}
+		if err := do(s.milvusClient, name, r.Context()); err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": action + "ed " + name})
+		return nil
+	case "entities":
+		return s.write(w, r, name)
+	case "delete":
+		return s.deleteEntities(w, r, name)
+	case "query":
+		return s.query(w, r, name)
+	case "search":
+		return s.search(w, r, name)
+	}
+	return notFound("unknown collection action %q", action)
+}
+
+func (s *Server) exists(r *http.Request, name string) error {
+	exists, err := s.milvusClient.HasCollection(r.Context(), name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return notFound("collection %s does not exist", name)
+	}
+	return nil
+}
+
+func (s *Server) describe(w http.ResponseWriter, r *http.Request, name string, status int) error {
+	info, err := vectordb.DescribeCollection(s.milvusClient, name, r.Context())
+	if err != nil {
+		return err
+	}
+	writeJSON(w, status, info)
+	return nil
+}
+
+// drop needs ?confirm=<name> like the CLI's typed confirmation, ?dry_run=true only reports what would go
+func (s *Server) drop(w http.ResponseWriter, r *http.Request, name string) error {
+	if vectordb.IsProtected(name) {
+		return fmt.Errorf("%w: %s", vectordb.ErrProtectedCollection, name)
+	}
+	if r.URL.Query().Get("dry_run") == "true" {
+		info, err := vectordb.DescribeCollection(s.milvusClient, name, r.Context())
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"would_drop": name, "row_count": info.RowCount})
+		return nil
+	}
+	if r.URL.Query().Get("confirm") != name {
+		return badRequest("dropping %s is permanent, repeat the name as ?confirm=%s", name, name)
+	}
+	if err := vectordb.DeleteCollection(s.milvusClient, name, r.Context()); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "dropped " + name})
+	return nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"milvus/embeddings"
+	"milvus/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type embedRequest struct {
+	Text  string   `json:"text"`
+	Texts []string `json:"texts"`
+}
+
+type embedding struct {
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+	OOV    []string  `json:"oov,omitempty"`
+}
+
+// embed averages the word vectors of one text, or a batch of texts
+func (s *Server) embed(w http.ResponseWriter, r *http.Request) error {
+	if err := allow(r, http.MethodPost); err != nil {
+		return err
+	}
+	if s.table == nil {
+		return errNoVectors
+	}
+	var req embedRequest
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	texts := req.Texts
+	if req.Text != "" {
+		texts = append([]string{req.Text}, texts...)
+	}
+	if len(texts) == 0 {
+		return badRequest("text or texts is required")
+	}
+	if len(texts) > maxLimit {
+		return badRequest("at most %d texts per request", maxLimit)
+	}
+
+	_, span := tracing.Start(r.Context(), "embeddings.Embed", attribute.Int("embeddings.texts", len(texts)))
+	results := make([]embedding, len(texts))
+	for i, text := range texts {
+		vector, oov, err := s.table.Embed(text)
+		if err != nil {
+			span.End()
+			return badRequest("texts[%d]: %s", i, err)
+		}
+		results[i] = embedding{Text: text, Vector: vector, OOV: oov}
+	}
+	span.End()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"dim": s.table.Dim(), "embeddings": results})
+	return nil
+}
+
+type neighborsRequest struct {
+	Word   string    `json:"word"`
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+	K      int       `json:"k"`
+}
+
+// neighbors finds the words closest to a word, a text or a raw vector
+func (s *Server) neighbors(w http.ResponseWriter, r *http.Request) error {
+	if err := allow(r, http.MethodGet, http.MethodPost); err != nil {
+		return err
+	}
+	if s.table == nil {
+		return errNoVectors
+	}
+	req := neighborsRequest{K: 10}
+	if r.Method == http.MethodGet {
+		req.Word = r.URL.Query().Get("word")
+		if v := r.URL.Query().Get("k"); v != "" {
+			k, err := strconv.Atoi(v)
+			if err != nil {
+				return badRequest("k must be an integer")
+			}
+			req.K = k
+		}
+	} else if err := decode(r, &req); err != nil {
+		return err
+	}
+	if req.K <= 0 {
+		return badRequest("k must be positive")
+	}
+	if err := checkLimit(req.K); err != nil {
+		return err
+	}
+
+	if !exactlyOne(req.Word != "", req.Text != "", req.Vector != nil) {
+		return badRequest("exactly one of word, text or vector is required")
+	}
+
+	var (
+		vector  []float32
+		exclude []string
+	)
+	switch {
+	case req.Word != "":
+		v, ok := s.table.Lookup(req.Word)
+		if !ok {
+			return notFound("word %q is not in the vocabulary", req.Word)
+		}
+		vector, exclude = v, []string{req.Word}
+	case req.Text != "":
+		v, _, err := s.table.Embed(req.Text)
+		if err != nil {
+			return badRequest("%s", err)
+		}
+		vector, exclude = v, embeddings.Tokenize(req.Text)
+	default:
+		vector = req.Vector
+	}
+
+	_, span := tracing.Start(r.Context(), "embeddings.Nearest", tracing.TopK(req.K))
+	neighbors, err := s.table.Nearest(vector, req.K, exclude...)
+	tracing.End(span, &err)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"neighbors": neighbors})
+	return nil
+}
+
+func exactlyOne(given ...bool) bool {
+	n := 0
+	for _, g := range given {
+		if g {
+			n++
+		}
+	}
+	return n == 1
+}
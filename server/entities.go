@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"milvus/tracing"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type writeRequest struct {
+	Partition string                   `json:"partition"`
+	Rows      []map[string]interface{} `json:"rows"`
+}
+
+// write inserts (POST) or upserts (PUT) rows, validated against the collection schema
+func (s *Server) write(w http.ResponseWriter, r *http.Request, name string) error {
+	if err := allow(r, http.MethodPost, http.MethodPut); err != nil {
+		return err
+	}
+	var req writeRequest
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	if len(req.Rows) == 0 {
+		return badRequest("rows is required")
+	}
+
+	info, err := vectordb.DescribeCollection(s.milvusClient, name, r.Context())
+	if err != nil {
+		return err
+	}
+	columns, err := vectordb.ColumnsFromRows(info, req.Rows)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+
+	upsert := r.Method == http.MethodPut
+	if err := vectordb.Write(s.milvusClient, name, req.Partition, upsert, columns, r.Context()); err != nil {
+		return err
+	}
+	if upsert {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"upserted": len(req.Rows)})
+		return nil
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"inserted": len(req.Rows)})
+	return nil
+}
+
+type deleteRequest struct {
+	Expr      string `json:"expr"`
+	Partition string `json:"partition"`
+	DryRun    bool   `json:"dry_run"`
+	Confirm   string `json:"confirm"` // must repeat the collection name unless dry_run is set
+}
+
+// deleteEntities removes the entities matching an expression, listing their primary keys on a dry run
+func (s *Server) deleteEntities(w http.ResponseWriter, r *http.Request, name string) error {
+	if err := allow(r, http.MethodPost); err != nil {
+		return err
+	}
+	var req deleteRequest
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Expr) == "" {
+		return badRequest("expr is required")
+	}
+	if vectordb.IsProtected(name) {
+		return fmt.Errorf("%w: %s", vectordb.ErrProtectedCollection, name)
+	}
+
+	if req.DryRun {
+		info, err := vectordb.DescribeCollection(s.milvusClient, name, r.Context())
+		if err != nil {
+			return err
+		}
+		pk, ok := info.PrimaryField()
+		if !ok {
+			return fmt.Errorf("collection %s has no primary key", name)
+		}
+		params := vectordb.QueryParams{CollectionName: name, Expr: req.Expr, OutputFields: []string{pk.Name}}
+		if req.Partition != "" {
+			params.PartitionNames = []string{req.Partition}
+		}
+		columns, err := vectordb.Query(s.milvusClient, params, r.Context())
+		if err != nil {
+			return err
+		}
+		rows := vectordb.Rows(columns)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"would_delete": len(rows), "rows": rows})
+		return nil
+	}
+
+	if req.Confirm != name {
+		return badRequest("deleting from %s is permanent, set confirm to %q", name, name)
+	}
+	if err := vectordb.DeleteByExpr(s.milvusClient, name, req.Partition, req.Expr, r.Context()); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted " + req.Expr})
+	return nil
+}
+
+type queryRequest struct {
+	Expr         string   `json:"expr"`
+	OutputFields []string `json:"output_fields"`
+	Partitions   []string `json:"partitions"`
+	Offset       int64    `json:"offset"`
+	Limit        int64    `json:"limit"`
+}
+
+// query runs a filter expression, one page at a time
+func (s *Server) query(w http.ResponseWriter, r *http.Request, name string) error {
+	if err := allow(r, http.MethodPost); err != nil {
+		return err
+	}
+	var req queryRequest
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Expr) == "" {
+		return badRequest("expr is required")
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return badRequest("offset and limit must not be negative")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+	if err := checkLimit(int(req.Limit)); err != nil {
+		return err
+	}
+
+	columns, err := vectordb.Query(s.milvusClient, vectordb.QueryParams{
+		CollectionName: name,
+		PartitionNames: req.Partitions,
+		Expr:           req.Expr,
+		OutputFields:   req.OutputFields,
+		Offset:         req.Offset,
+		Limit:          req.Limit,
+	}, r.Context())
+	if err != nil {
+		return err
+	}
+	rows := vectordb.Rows(columns)
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	response := map[string]interface{}{"rows": rows, "offset": req.Offset, "limit": req.Limit}
+	if int64(len(rows)) == req.Limit {
+		response["next_offset"] = req.Offset + req.Limit
+	}
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+type searchRequest struct {
+	Vector       []float32      `json:"vector"`
+	Word         string         `json:"word"`
+	Text         string         `json:"text"`
+	VectorField  string         `json:"vector_field"`
+	TopK         int            `json:"top_k"`
+	Expr         string         `json:"expr"`
+	OutputFields []string       `json:"output_fields"`
+	Partitions   []string       `json:"partitions"`
+	Metric       string         `json:"metric"`
+	IndexType    string         `json:"index_type"`
+	Params       map[string]int `json:"params"` // search parameters, e.g. {"nprobe": 16} or {"ef": 64}
+}
+
+// search runs a vector search with a raw vector, or a word or text embedded with the loaded vectors
+func (s *Server) search(w http.ResponseWriter, r *http.Request, name string) error {
+	if err := allow(r, http.MethodPost); err != nil {
+		return err
+	}
+	req := searchRequest{TopK: 10, Metric: "L2", IndexType: "FLAT"}
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+	if req.TopK <= 0 {
+		return badRequest("top_k must be positive")
+	}
+	if err := checkLimit(req.TopK); err != nil {
+		return err
+	}
+
+	if !exactlyOne(req.Vector != nil, req.Word != "", req.Text != "") {
+		return badRequest("exactly one of vector, word or text is required")
+	}
+	vector := req.Vector
+	if req.Vector == nil {
+		v, err := s.queryVector(r.Context(), req.Word, req.Text)
+		if err != nil {
+			return err
+		}
+		vector = v
+	}
+
+	metric := entity.MetricType(strings.ToUpper(req.Metric))
+	_, sp, err := vectordb.NewIndex(req.IndexType, metric, req.Params)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+	if req.VectorField == "" {
+		info, err := vectordb.DescribeCollection(s.milvusClient, name, r.Context())
+		if err != nil {
+			return err
+		}
+		field, ok := info.VectorField()
+		if !ok {
+			return badRequest("collection %s has no float vector field", name)
+		}
+		req.VectorField = field.Name
+	}
+
+	results, err := vectordb.Search(s.milvusClient, vectordb.SearchParams{
+		CollectionName: name,
+		PartitionNames: req.Partitions,
+		Expr:           req.Expr,
+		OutputFields:   req.OutputFields,
+		VectorField:    req.VectorField,
+		Vectors:        []entity.Vector{entity.FloatVector(vector)},
+		Metric:         metric,
+		TopK:           req.TopK,
+		SearchParam:    sp,
+	}, r.Context())
+	if err != nil {
+		return err
+	}
+	hits := []vectordb.Hit{}
+	if len(results) > 0 {
+		hits = vectordb.Hits(results[0])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"hits": hits})
+	return nil
+}
+
+// queryVector looks up a word, or embeds a text, with the loaded vectors
+func (s *Server) queryVector(ctx context.Context, word string, text string) (vector []float32, err error) {
+	if s.table == nil {
+		return nil, errNoVectors
+	}
+	_, span := tracing.Start(ctx, "embeddings.Embed", attribute.String("embeddings.word", word))
+	defer tracing.End(span, &err)
+	if word != "" {
+		v, ok := s.table.Lookup(word)
+		if !ok {
+			return nil, notFound("word %q is not in the vocabulary", word)
+		}
+		return v, nil
+	}
+	v, _, err := s.table.Embed(text)
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	return v, nil
+}
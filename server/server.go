@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/logging"
+	"milvus/metrics"
+	"milvus/tracing"
+	"milvus/vectordb"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+)
+
+type Config struct {
+	Addr            string
+	RequestTimeout  time.Duration // deadline of the context handed to every request, 0 = none
+	ShutdownTimeout time.Duration // how long in-flight requests get to finish on shutdown
+	MaxBodyBytes    int64
+	Logger          *slog.Logger // access and error log, nil logs nothing
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:            "localhost:8080",
+		RequestTimeout:  30 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+		MaxBodyBytes:    32 << 20,
+	}
+}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Server exposes the embeddings and vectordb packages over a JSON REST API. Either backend is
+// optional: without a Milvus client only /v1/embed and /v1/neighbors are served, without a
+// vector table only the collection endpoints are.
+type Server struct {
+	milvusClient client.Client
+	table        *embeddings.Table
+	config       Config
+	mux          *http.ServeMux
+	logger       *slog.Logger
+}
+
+func New(milvusClient client.Client, table *embeddings.Table, config Config) *Server {
+	s := &Server{milvusClient: milvusClient, table: table, config: config, mux: http.NewServeMux(), logger: logging.OrDiscard(config.Logger)}
+	s.mux.HandleFunc("/healthz", s.handle(s.health))
+	s.mux.Handle("/metrics", metrics.Handler())
+	s.route("/v1/embed", s.embed)
+	s.route("/v1/neighbors", s.neighbors)
+	s.route("/v1/collections", s.collections)
+	s.route("/v1/collections/", s.collection)
+	return s
+}
+
+// route registers an API handler, traced with one server span per request
+func (s *Server) route(pattern string, h handlerFunc) {
+	s.mux.Handle(pattern, tracing.HTTPHandler(pattern, s.handle(h)))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves until ctx is done, then stops accepting connections and waits
+// up to Config.ShutdownTimeout for in-flight requests
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.config.Addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		s.logger.Info("serving REST API", "addr", s.config.Addr)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("shutting down REST API", "addr", s.config.Addr)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down cleanly: %w", err)
+	}
+	return nil
+}
+
+// requestError is a problem with the request itself, reported with its HTTP status
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &requestError{status: http.StatusNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+var errNoMilvus = &requestError{status: http.StatusServiceUnavailable, message: "the server was started without a Milvus connection"}
+
+var errNoVectors = &requestError{status: http.StatusServiceUnavailable, message: "the server was started without a vector file"}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// handle applies the request timeout, tags everything logged for the request with its id and
+// turns returned errors into JSON error responses
+func (s *Server) handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := logging.WithAttrs(r.Context(),
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		if s.config.RequestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.config.RequestTimeout)
+			defer cancel()
+		}
+		r = r.WithContext(ctx)
+		if s.config.MaxBodyBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+		}
+
+		err := h(w, r)
+		if err == nil {
+			s.logger.InfoContext(ctx, "request served", "duration", time.Since(start))
+			return
+		}
+		status := errorStatus(err)
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		level := slog.LevelWarn
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.logger.Log(ctx, level, "request failed", "status", status, "error", err, "duration", time.Since(start))
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func errorStatus(err error) int {
+	var reqErr *requestError
+	switch {
+	case stdErrors.As(err, &reqErr):
+		return reqErr.status
+	case stdErrors.Is(err, vectordb.ErrProtectedCollection):
+		return http.StatusForbidden
+	case stdErrors.Is(err, errors.ErrValidation), stdErrors.Is(err, errors.ErrDimensionMismatch):
+		return http.StatusBadRequest
+	case stdErrors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case stdErrors.Is(err, context.Canceled):
+		return 499 // client closed the request
+	case stdErrors.Is(err, errors.ErrMilvus):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// decode reads a JSON body into v, rejecting unknown fields and trailing data
+func decode(r *http.Request, v interface{}) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		return &requestError{status: http.StatusUnsupportedMediaType, message: "expected Content-Type application/json"}
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	// rows are decoded into maps, keep their integers exact for Int64 fields
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if stdErrors.As(err, &tooLarge) {
+			return &requestError{status: http.StatusRequestEntityTooLarge, message: err.Error()}
+		}
+		if err == io.EOF {
+			return badRequest("request body is empty")
+		}
+		return badRequest("invalid JSON body: %s", err)
+	}
+	if decoder.More() {
+		return badRequest("invalid JSON body: unexpected data after the object")
+	}
+	return nil
+}
+
+func allow(r *http.Request, methods ...string) error {
+	for _, method := range methods {
+		if r.Method == method {
+			return nil
+		}
+	}
+	return &requestError{status: http.StatusMethodNotAllowed, message: fmt.Sprintf("%s not allowed, use %s", r.Method, strings.Join(methods, " or "))}
+}
+
+// page reads ?offset=&limit= query parameters
+func page(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultLimit
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, badRequest("offset must be a non-negative integer")
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, badRequest("limit must be a positive integer")
+		}
+		limit = n
+	}
+	return offset, limit, checkLimit(limit)
+}
+
+func checkLimit(limit int) error {
+	if limit > maxLimit {
+		return badRequest("limit can be at most %d", maxLimit)
+	}
+	return nil
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) error {
+	status := map[string]interface{}{"status": "ok", "milvus": s.milvusClient != nil}
+	if s.table != nil {
+		status["vectors"] = s.table.Len()
+	}
+	writeJSON(w, http.StatusOK, status)
+	return nil
+}
+
+func (s *Server) requireMilvus() error {
+	if s.milvusClient == nil {
+		return errNoMilvus
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"milvus/embeddings"
+	"milvus/logging"
+	"milvus/vectordb"
+)
+
+const validModelPath = "../tests/mockdata/word_vector.txt"
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	table, err := embeddings.LoadTable(validModelPath)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	return New(nil, table, DefaultConfig())
+}
+
+func TestHandlers(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		expect string // substring of the response body
+	}{
+		{name: "Health", method: http.MethodGet, path: "/healthz", status: http.StatusOK, expect: `"vectors":20`},
+		{name: "Embed text", method: http.MethodPost, path: "/v1/embed", body: `{"text": "cat dog"}`, status: http.StatusOK, expect: `"embeddings"`},
+		{name: "Embed reports unknown words", method: http.MethodPost, path: "/v1/embed", body: `{"texts": ["cat zzzunknown"]}`, status: http.StatusOK, expect: `"oov":["zzzunknown"]`},
+		{name: "Embed only unknown words", method: http.MethodPost, path: "/v1/embed", body: `{"text": "zzzunknown"}`, status: http.StatusBadRequest},
+		{name: "Embed without text", method: http.MethodPost, path: "/v1/embed", body: `{}`, status: http.StatusBadRequest},
+		{name: "Embed unknown field", method: http.MethodPost, path: "/v1/embed", body: `{"txt": "cat"}`, status: http.StatusBadRequest, expect: "unknown field"},
+		{name: "Embed malformed JSON", method: http.MethodPost, path: "/v1/embed", body: `{"text": `, status: http.StatusBadRequest},
+		{name: "Embed empty body", method: http.MethodPost, path: "/v1/embed", status: http.StatusBadRequest, expect: "empty"},
+		{name: "Embed with GET", method: http.MethodGet, path: "/v1/embed", status: http.StatusMethodNotAllowed},
+		{name: "Neighbours of a word", method: http.MethodGet, path: "/v1/neighbors?word=cat&k=3", status: http.StatusOK, expect: `"similarity"`},
+		{name: "Neighbours of a text", method: http.MethodPost, path: "/v1/neighbors", body: `{"text": "cat dog", "k": 2}`, status: http.StatusOK},
+		{name: "Neighbours of an unknown word", method: http.MethodGet, path: "/v1/neighbors?word=zzzunknown", status: http.StatusNotFound},
+		{name: "Neighbours with bad k", method: http.MethodGet, path: "/v1/neighbors?word=cat&k=many", status: http.StatusBadRequest},
+		{name: "Neighbours k over the limit", method: http.MethodGet, path: "/v1/neighbors?word=cat&k=5000", status: http.StatusBadRequest},
+		{name: "Neighbours word and vector", method: http.MethodPost, path: "/v1/neighbors", body: `{"word": "cat", "vector": [1, 2]}`, status: http.StatusBadRequest},
+		{name: "Neighbours wrong dimension", method: http.MethodPost, path: "/v1/neighbors", body: `{"vector": [1, 2]}`, status: http.StatusBadRequest},
+		{name: "Collections without Milvus", method: http.MethodGet, path: "/v1/collections", status: http.StatusServiceUnavailable},
+		{name: "Collection without Milvus", method: http.MethodPost, path: "/v1/collections/words/search", body: `{"word": "cat"}`, status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Errorf("body %s does not contain %s", rec.Body.String(), tt.expect)
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Errorf("body is not JSON: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNeighborsExcludeQuery(t *testing.T) {
+	s := newTestServer(t)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/neighbors?word=cat&k=5", nil))
+
+	var resp struct {
+		Neighbors []embeddings.Neighbor `json:"neighbors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(resp.Neighbors) != 5 {
+		t.Fatalf("got %d neighbours, want 5", len(resp.Neighbors))
+	}
+	for _, n := range resp.Neighbors {
+		if n.Word == "cat" {
+			t.Error("query word returned as its own neighbour")
+		}
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	s := New(nil, nil, Config{RequestTimeout: 10 * time.Millisecond})
+	var deadline time.Time
+	handler := s.handle(func(w http.ResponseWriter, r *http.Request) error {
+		deadline, _ = r.Context().Deadline()
+		<-r.Context().Done()
+		return r.Context().Err()
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if deadline.IsZero() {
+		t.Error("request context has no deadline")
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t)
+	s.logger = logger
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/neighbors?word=unknownword", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "req-42" {
+		t.Errorf("X-Request-ID = %q, want the caller's id", got)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("log is not one JSON record: %v\n%s", err, logs.String())
+	}
+	if record["level"] != "WARN" || record["request_id"] != "req-42" || record["path"] != "/v1/neighbors" || record["status"] != float64(http.StatusNotFound) {
+		t.Errorf("unexpected log record %v", record)
+	}
+}
+
+func TestMaxBody(t *testing.T) {
+	s := newTestServer(t)
+	s.config.MaxBodyBytes = 16
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/embed", strings.NewReader(`{"text": "cat dog cat dog cat dog"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	if status := errorStatus(vectordb.ErrProtectedCollection); status != http.StatusForbidden {
+		t.Errorf("protected collection status = %d, want %d", status, http.StatusForbidden)
+	}
+	if status := errorStatus(context.DeadlineExceeded); status != http.StatusGatewayTimeout {
+		t.Errorf("deadline status = %d, want %d", status, http.StatusGatewayTimeout)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	s := New(nil, nil, Config{Addr: "127.0.0.1:0", ShutdownTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServe() = %v, want nil after shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
@@ -8,7 +8,11 @@ import (
 
 func BenchmarkConnect(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		tools.ConnectVectorDB()
+		milvusClient, err := tools.ConnectVectorDB()
+		if err != nil {
+			b.Fatal(err)
+		}
+		milvusClient.Close()
 	}
 }
 
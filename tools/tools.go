@@ -4,57 +4,68 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"sync"
 	"time"
 
+	"milvus/logging"
+	"milvus/metrics"
+
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 )
 
 var pFlag = flag.Bool("perf", false, "Start the performance server")
 
+var metricsOnce sync.Once
+
+var logger = logging.Discard
+
+// SetLogger makes tools log through l, nil silences it again (the default)
+func SetLogger(l *slog.Logger) {
+	logger = logging.OrDiscard(l)
+}
+
 func EnablePerformanceServerIfFlag() {
 	flag.Parse()
 
 	if *pFlag {
-		go func() {
-			log.Println("Starting performance server on localhost:6060")
-			log.Println(http.ListenAndServe("localhost:6060", nil))
-		}()
+		StartPerformanceServer("localhost:6060")
 	}
 }
 
-func ConnectVectorDB() client.Client {
-	log.Print("Connecting to VectorDB...")
-	var err error
-	done := make(chan bool)
-
+// StartPerformanceServer serves the pprof handlers registered on http.DefaultServeMux, and /metrics
+func StartPerformanceServer(addr string) {
+	metricsOnce.Do(func() { http.Handle("/metrics", metrics.Handler()) })
 	go func() {
-		time.Sleep(10 * time.Second)
-		select {
-		case <-done:
-			return
-		default:
-			// Panic if connection unsuccessful after 5s
-			log.Print("failed to connect to Milvus. Make sure to run\ndocker-compose up\n")
-			os.Exit(1)
+		logger.Info("starting performance server", "addr", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			logger.Error("performance server stopped", "addr", addr, "error", err)
 		}
 	}()
+}
 
-	milvusClient, err := client.NewGrpcClient( // Max 65,536 connections
-		context.Background(), // ctx
-		"localhost:19530",    // addr
-	)
-	close(done)
+// ConnectVectorDB connects to a local Milvus for benchmarks, giving up after 10s
+func ConnectVectorDB() (client.Client, error) {
+	logger.Info("connecting to Milvus", "addr", "localhost:19530")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	milvusClient, err := NewVectorDBClient("localhost:19530", ctx)
 	if err != nil {
-		log.Fatal("failed to connect to Milvus:", err.Error())
+		return nil, fmt.Errorf("failed to connect to Milvus, make sure to run docker-compose up: %w", err)
 	}
-	fmt.Println("Successfully connected to Milvus")
-	return milvusClient
+	logger.Info("connected to Milvus", "addr", "localhost:19530")
+	return milvusClient, nil
+}
+
+// NewVectorDBClient connects to Milvus at addr, giving up when ctx is done instead of exiting the process
+func NewVectorDBClient(addr string, ctx context.Context) (client.Client, error) {
+	return client.NewGrpcClient( // Max 65,536 connections
+		ctx,  // ctx
+		addr, // addr
+	)
 }
 
 func LogTime(startTime time.Time, functionName string) {
-	elapsedTime := time.Since(startTime)
-	fmt.Printf("Function %s took %s\n\n", functionName, elapsedTime)
+	logger.Info("timed function", "function", functionName, "elapsed", time.Since(startTime))
 }
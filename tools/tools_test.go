@@ -6,7 +6,11 @@ import (
 
 func BenchmarkConnect(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		ConnectVectorDB()
+		milvusClient, err := ConnectVectorDB()
+		if err != nil {
+			b.Fatal(err)
+		}
+		milvusClient.Close()
 	}
 }
 
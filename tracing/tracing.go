@@ -0,0 +1,217 @@
+// Package tracing creates OpenTelemetry spans for training, embedding lookups, Milvus operations
+// and HTTP handlers. Spans go to the global tracer provider, which does nothing until Setup (OTLP)
+// or SetupExporter (any exporter, e.g. an in-memory one in tests) installs a real one.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentationName = "milvus"
+
+type Config struct {
+	Endpoint    string  // OTLP gRPC collector, e.g. localhost:4317
+	Insecure    bool    // plain text instead of TLS to the collector
+	ServiceName string  // defaults to vectorize
+	SampleRatio float64 // fraction of new traces to keep, 0 keeps all
+}
+
+// Setup installs a tracer provider that batches spans to an OTLP collector and returns its
+// shutdown func, which flushes the spans still buffered
+func Setup(config Config, ctx context.Context) (func(context.Context) error, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter for %s: %w", config.Endpoint, err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(newResource(config.ServiceName)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	install(provider)
+	return provider.Shutdown, nil
+}
+
+// SetupExporter installs a tracer provider that hands every span to exporter as soon as it ends,
+// e.g. tracetest.NewInMemoryExporter() to inspect spans in tests
+func SetupExporter(exporter sdktrace.SpanExporter, serviceName string) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(newResource(serviceName)),
+	)
+	install(provider)
+	return provider.Shutdown
+}
+
+func install(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+func newResource(serviceName string) *resource.Resource {
+	if serviceName == "" {
+		serviceName = "vectorize"
+	}
+	return resource.NewSchemaless(semconv.ServiceName(serviceName))
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed if *err is set:
+//
+//	ctx, span := tracing.Start(ctx, "vectordb.Search", tracing.Collection(name))
+//	defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// Attributes shared by the vectordb spans
+func Collection(name string) attribute.KeyValue {
+	return attribute.String("db.collection.name", name)
+}
+
+func Milvus() attribute.KeyValue {
+	return attribute.String("db.system", "milvus")
+}
+
+func TopK(k int) attribute.KeyValue {
+	return attribute.Int("vectordb.top_k", k)
+}
+
+func Expr(expr string) attribute.KeyValue {
+	return attribute.String("vectordb.expr", expr)
+}
+
+func Rows(n int) attribute.KeyValue {
+	return attribute.Int("vectordb.rows", n)
+}
+
+// HTTPHandler wraps h in a server span per request, continuing any trace propagated in the request headers
+func HTTPHandler(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// UnaryServerInterceptor and StreamServerInterceptor start a server span per gRPC call,
+// continuing any trace propagated in the request metadata
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx, span := startRPC(ctx, info.FullMethod)
+		defer endRPC(span, &err)
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, span := startRPC(ss.Context(), info.FullMethod)
+		defer endRPC(span, &err)
+		return handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func startRPC(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, attribute.String("rpc.method", fullMethod)),
+	)
+}
+
+func endRPC(span trace.Span, err *error) {
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(*err))))
+	End(span, err)
+}
+
+// tracedStream hands the span's context to streaming handlers
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ts *tracedStream) Context() context.Context {
+	return ts.ctx
+}
+
+// metadataCarrier lets the propagator read gRPC metadata
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if values := metadata.MD(mc).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key string, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTest(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := SetupExporter(exporter, "test")
+	t.Cleanup(func() { shutdown(context.Background()) })
+	return exporter
+}
+
+func TestEnd(t *testing.T) {
+	exporter := setupTest(t)
+
+	search := func(ctx context.Context, fail bool) (err error) {
+		_, span := Start(ctx, "vectordb.Search", Collection("words"), TopK(5))
+		defer End(span, &err)
+		if fail {
+			return stdErrors.New("collection not loaded")
+		}
+		return nil
+	}
+	search(context.Background(), false)
+	search(context.Background(), true)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].Status.Code == codes.Error {
+		t.Errorf("successful span has status %v", spans[0].Status)
+	}
+	if spans[1].Status.Code != codes.Error || spans[1].Status.Description != "collection not loaded" {
+		t.Errorf("failed span has status %v", spans[1].Status)
+	}
+	if len(spans[1].Events) != 1 || spans[1].Events[0].Name != "exception" {
+		t.Errorf("error was not recorded: %v", spans[1].Events)
+	}
+	attrs := map[string]string{}
+	for _, kv := range spans[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["db.collection.name"] != "words" || attrs["vectordb.top_k"] != "5" {
+		t.Errorf("attributes = %v", attrs)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	exporter := setupTest(t)
+
+	handler := HTTPHandler("/v1/neighbors", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "embeddings.Nearest")
+		span.End()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	// The caller's trace is continued through the traceparent header
+	ctx, parent := Start(context.Background(), "client")
+	req := httptest.NewRequest(http.MethodGet, "/v1/neighbors?word=cat", nil)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name != "GET /v1/neighbors" {
+		t.Errorf("server span is named %q", server.Name)
+	}
+	if server.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("server span did not continue the propagated trace")
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("handler span is not a child of the server span")
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("503 response has status %v", server.Status)
+	}
+}
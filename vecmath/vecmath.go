@@ -0,0 +1,100 @@
+// Package vecmath is the vector arithmetic shared by the index, quantization, clustering,
+// projection, alignment and training packages. Vectors passed together must have the same length.
+package vecmath
+
+import "math"
+
+// Dot is the dot product of a and b
+func Dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// SquaredL2 is the squared euclidean distance between a and b
+func SquaredL2(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// Norm is the euclidean length of v, summed in float64
+func Norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// Normalize scales v to unit length in place. A zero vector is left as it is.
+func Normalize(v []float32) {
+	norm := Norm(v)
+	if norm == 0 {
+		return
+	}
+	for i, x := range v {
+		v[i] = float32(float64(x) / norm)
+	}
+}
+
+// Normalized returns a unit length copy of v, or a zero vector if v is zero
+func Normalized(v []float32) []float32 {
+	out := append([]float32(nil), v...)
+	Normalize(out)
+	return out
+}
+
+// Dot64 is Dot for float64 vectors
+func Dot64(a []float64, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// Normalize64 is Normalize for float64 vectors
+func Normalize64(v []float64) {
+	norm := math.Sqrt(Dot64(v, v))
+	if norm == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// Cosine64 is the cosine similarity of float64 vectors, 0 if either is zero
+func Cosine64(a []float64, b []float64) float64 {
+	na, nb := Dot64(a, a), Dot64(b, b)
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return Dot64(a, b) / math.Sqrt(na*nb)
+}
+
+// DotCentroid is the dot product of a float32 vector and a float64 centroid or mean
+func DotCentroid(v []float32, centroid []float64) float64 {
+	var sum float64
+	for i, x := range v {
+		sum += float64(x) * centroid[i]
+	}
+	return sum
+}
+
+// SquaredL2Centroid is the squared euclidean distance between a float32 vector and a float64
+// centroid or mean
+func SquaredL2Centroid(v []float32, centroid []float64) float64 {
+	var sum float64
+	for i, x := range v {
+		d := float64(x) - centroid[i]
+		sum += d * d
+	}
+	return sum
+}
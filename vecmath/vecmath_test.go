@@ -0,0 +1,44 @@
+package vecmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	a, b := []float32{3, 4}, []float32{1, 0}
+	if d := Dot(a, b); d != 3 {
+		t.Errorf("Dot = %g", d)
+	}
+	if d := SquaredL2(a, b); d != 20 {
+		t.Errorf("SquaredL2 = %g", d)
+	}
+	if n := Norm(a); n != 5 {
+		t.Errorf("Norm = %g", n)
+	}
+	if u := Normalized(a); u[0] != 0.6 || u[1] != 0.8 || a[0] != 3 {
+		t.Errorf("Normalized = %v, input now %v", u, a)
+	}
+	zero := []float32{0, 0}
+	if u := Normalized(zero); u[0] != 0 || u[1] != 0 {
+		t.Errorf("Normalized zero vector = %v", u)
+	}
+
+	centroid := []float64{1, 2}
+	if d := DotCentroid(a, centroid); d != 11 {
+		t.Errorf("DotCentroid = %g", d)
+	}
+	if d := SquaredL2Centroid(a, centroid); d != 8 {
+		t.Errorf("SquaredL2Centroid = %g", d)
+	}
+	Normalize64(centroid)
+	if math.Abs(Dot64(centroid, centroid)-1) > 1e-12 {
+		t.Errorf("Normalize64 = %v", centroid)
+	}
+	if c := Cosine64([]float64{1, 1}, []float64{2, 2}); math.Abs(c-1) > 1e-12 {
+		t.Errorf("Cosine64 = %g", c)
+	}
+	if c := Cosine64([]float64{1, 1}, []float64{0, 0}); c != 0 {
+		t.Errorf("Cosine64 with a zero vector = %g", c)
+	}
+}
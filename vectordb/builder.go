@@ -3,7 +3,9 @@ package vectordb
 import (
 	"context"
 	"fmt"
-	"log"
+
+	"milvus/errors"
+	"milvus/metrics"
 
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
@@ -34,6 +36,16 @@ func (cb *CollectionBuilder) WithDescription(desc string) *CollectionBuilder {
 	return cb
 }
 
+func (cb *CollectionBuilder) WithShardNum(shardNum int32) *CollectionBuilder {
+	cb.shardNum = shardNum
+	return cb
+}
+
+func (cb *CollectionBuilder) WithDynamicField(enable bool) *CollectionBuilder {
+	cb.enableDynamic = enable
+	return cb
+}
+
 func (cb *CollectionBuilder) WithFields(fields ...*entity.Field) *CollectionBuilder {
 	cb.fields = append(cb.fields, fields...)
 	return cb
@@ -52,6 +64,16 @@ func NewFieldVarChar(name string, maxLength int, primaryKey bool, autoID bool) *
 	}
 }
 
+// Helper function for creating Int64 fields
+func NewFieldInt64(name string, primaryKey bool, autoID bool) *entity.Field {
+	return &entity.Field{
+		Name:       name,
+		DataType:   entity.FieldTypeInt64,
+		PrimaryKey: primaryKey,
+		AutoID:     autoID,
+	}
+}
+
 // Helper function for creating FloatVector fields
 func NewFieldFloatVector(name string, dim int) *entity.Field {
 	return &entity.Field{
@@ -63,7 +85,8 @@ func NewFieldFloatVector(name string, dim int) *entity.Field {
 	}
 }
 
-func (cb *CollectionBuilder) Create(milvusClient client.Client, ctx context.Context) error {
+func (cb *CollectionBuilder) Create(milvusClient client.Client, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "create_collection")(&err)
 	schema := &entity.Schema{
 		CollectionName:     cb.name,
 		Description:        cb.description,
@@ -73,18 +96,18 @@ func (cb *CollectionBuilder) Create(milvusClient client.Client, ctx context.Cont
 
 	collections, err := milvusClient.ListCollections(ctx)
 	if err != nil {
-		log.Fatal("failed to list collections:", err.Error())
+		return errors.MilvusFailure("list collections", "", err)
 	}
 	for _, collection := range collections {
 		if collection.Name == cb.name {
-			fmt.Printf("Collection %s already exists\n", cb.name)
+			logger.InfoContext(ctx, "collection already exists", "collection", cb.name)
 			return nil
 		}
 	}
 	err = milvusClient.CreateCollection(ctx, schema, cb.shardNum)
 	if err != nil {
-		log.Fatal("failed to create collection:", err.Error())
+		return errors.MilvusFailure("create collection", cb.name, err)
 	}
-	fmt.Printf("Successfully created collection %s\n", cb.name)
+	logger.InfoContext(ctx, "created collection", "collection", cb.name, "fields", len(cb.fields))
 	return nil
 }
@@ -3,16 +3,21 @@ package vectordb
 import (
 	"context"
 	"fmt"
-	"log"
 	"math/rand"
 
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
+
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 
 
-func CreateCollection(milvusClient client.Client, collection string, ctx context.Context) {
+func CreateCollection(milvusClient client.Client, collection string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "create_collection")(&err)
 	schema := &entity.Schema{
 		CollectionName: collection,
 		Description:    "Test book search",
@@ -39,48 +44,169 @@ func CreateCollection(milvusClient client.Client, collection string, ctx context
 		},
 		EnableDynamicField: true,
 	}
-	err := milvusClient.CreateCollection(
+	err = milvusClient.CreateCollection(
 		ctx, // ctx
 		schema,
 		2, // shardNum
 	)
 	if err != nil {
-		log.Fatal("failed to create collection:", err.Error())
+		return errors.MilvusFailure("create collection", collection, err)
 	}
-	fmt.Printf("Successfully created collection %s\n", collection)
+	logger.InfoContext(ctx, "created collection", "collection", collection)
+	return nil
 }
 
-func DeleteCollection(milvusClient client.Client, collection string, ctx context.Context) error {
-	err := milvusClient.DropCollection(
-		context.Background(), // ctx
-		collection,           // CollectionName
+// DeleteCollection drops a collection, refusing collections on the protected allowlist
+func DeleteCollection(milvusClient client.Client, collection string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "drop_collection")(&err)
+	if err := checkProtected(collection); err != nil {
+		return err
+	}
+	err = milvusClient.DropCollection(
+		ctx,        // ctx
+		collection, // CollectionName
 	)
 	if err != nil {
-		log.Fatal("fail to drop collection:", err.Error())
+		return errors.MilvusFailure("drop collection", collection, err)
 	}
-	fmt.Println("Successfully dropped collection:", collection)
+	logger.InfoContext(ctx, "dropped collection", "collection", collection)
 	return nil
 }
 
-func DeleteAllCollections(milvusClient client.Client, ctx context.Context) error {
-	collections, err := milvusClient.ListCollections(ctx)
+// DeletableCollections lists the collections DeleteAllCollections would drop, leaving out protected ones
+func DeletableCollections(milvusClient client.Client, ctx context.Context) ([]string, error) {
+	collections, err := ListCollections(milvusClient, ctx)
 	if err != nil {
-		log.Fatal("failed to list collections:", err.Error())
+		return nil, err
 	}
+	var names []string
 	for _, collection := range collections {
-		err = milvusClient.DropCollection(
-			ctx, // ctx
-			collection.Name, // CollectionName
-		)
-		if err != nil {
-			log.Fatal("fail to drop collection:", err.Error())
+		if !IsProtected(collection.Name) {
+			names = append(names, collection.Name)
 		}
-		fmt.Println("Successfully dropped collection:", collection.Name)
 	}
+	return names, nil
+}
+
+// DeleteAllCollections drops every collection that isn't protected and returns the dropped names
+func DeleteAllCollections(milvusClient client.Client, ctx context.Context) ([]string, error) {
+	names, err := DeletableCollections(milvusClient, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		if err := DeleteCollection(milvusClient, name, ctx); err != nil {
+			return names[:i], err
+		}
+	}
+	return names, nil
+}
+
+// DeleteByExpr deletes the entities matching expr, e.g. "word in ['cat', 'dog']"
+func DeleteByExpr(milvusClient client.Client, collection string, partition string, expr string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "delete")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.Delete", tracing.Milvus(), tracing.Collection(collection), tracing.Expr(expr))
+	defer tracing.End(span, &err)
+	if err := checkProtected(collection); err != nil {
+		return err
+	}
+	if expr == "" {
+		return errors.Invalid("expr", "refusing to delete from %s without an expression", collection)
+	}
+	if err := milvusClient.Delete(ctx, collection, partition, expr); err != nil {
+		return errors.MilvusFailure("delete from", collection, err)
+	}
+	logger.InfoContext(ctx, "deleted entities", "collection", collection, "partition", partition, "expr", expr)
 	return nil
 }
 
-func InsertRawVectorIntoCollection(milvusClient client.Client, collection string, ctx context.Context) {
+func ListCollections(milvusClient client.Client, ctx context.Context) (collections []*entity.Collection, err error) {
+	defer metrics.Track(metrics.VectorDB, "list_collections")(&err)
+	collections, err = milvusClient.ListCollections(ctx)
+	if err != nil {
+		return nil, errors.MilvusFailure("list collections", "", err)
+	}
+	return collections, nil
+}
+
+// CollectionInfo is what DescribeCollection reports about a collection
+type CollectionInfo struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Loaded      bool              `json:"loaded"`
+	ShardNum    int32             `json:"shard_num"`
+	RowCount    string            `json:"row_count"`
+	Fields      []FieldInfo       `json:"fields"`
+	Dynamic     bool              `json:"dynamic_field"` // rows can carry fields outside the schema
+	Indexes     map[string]string `json:"indexes,omitempty"`
+}
+
+type FieldInfo struct {
+	Name       string            `json:"name"`
+	DataType   string            `json:"data_type"`
+	PrimaryKey bool              `json:"primary_key,omitempty"`
+	AutoID     bool              `json:"auto_id,omitempty"`
+	TypeParams map[string]string `json:"type_params,omitempty"`
+}
+
+func DescribeCollection(milvusClient client.Client, collection string, ctx context.Context) (info *CollectionInfo, err error) {
+	defer metrics.Track(metrics.VectorDB, "describe_collection")(&err)
+	coll, err := milvusClient.DescribeCollection(ctx, collection)
+	if err != nil {
+		return nil, errors.MilvusFailure("describe collection", collection, err)
+	}
+	info = &CollectionInfo{
+		Name:     coll.Name,
+		Loaded:   coll.Loaded,
+		ShardNum: coll.ShardNum,
+		Indexes:  map[string]string{},
+	}
+	if coll.Schema != nil {
+		info.Description = coll.Schema.Description
+		info.Dynamic = coll.Schema.EnableDynamicField
+		for _, field := range coll.Schema.Fields {
+			info.Fields = append(info.Fields, FieldInfo{
+				Name:       field.Name,
+				DataType:   field.DataType.String(),
+				PrimaryKey: field.PrimaryKey,
+				AutoID:     field.AutoID,
+				TypeParams: field.TypeParams,
+			})
+			if field.DataType == entity.FieldTypeFloatVector || field.DataType == entity.FieldTypeBinaryVector {
+				if indexes, err := milvusClient.DescribeIndex(ctx, collection, field.Name); err == nil && len(indexes) > 0 {
+					info.Indexes[field.Name] = string(indexes[0].IndexType())
+				}
+			}
+		}
+	}
+	if stats, err := milvusClient.GetCollectionStatistics(ctx, collection); err == nil {
+		info.RowCount = stats["row_count"]
+	}
+	return info, nil
+}
+
+// PrimaryField returns the primary key field of a collection
+func (ci *CollectionInfo) PrimaryField() (FieldInfo, bool) {
+	for _, field := range ci.Fields {
+		if field.PrimaryKey {
+			return field, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// VectorField returns the first float vector field of a collection
+func (ci *CollectionInfo) VectorField() (FieldInfo, bool) {
+	for _, field := range ci.Fields {
+		if field.DataType == entity.FieldTypeFloatVector.String() {
+			return field, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+func InsertRawVectorIntoCollection(milvusClient client.Client, collection string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "insert")(&err)
 	// Prepare Data
 	bookIDs := make([]int64, 0, 2000)
 	wordCounts := make([]int64, 0, 2000)
@@ -98,7 +224,7 @@ func InsertRawVectorIntoCollection(milvusClient client.Client, collection string
 	wordColumn := entity.NewColumnInt64("word_count", wordCounts)
 	introColumn := entity.NewColumnFloatVector("book_intro", 2, bookIntros)
 	// insert
-	_, err := milvusClient.Insert(
+	_, err = milvusClient.Insert(
 		ctx,         // ctx
 		collection,  // CollectionName
 		"",          // partitionName
@@ -107,9 +233,11 @@ func InsertRawVectorIntoCollection(milvusClient client.Client, collection string
 		introColumn, // columnarData
 	)
 	if err != nil {
-		log.Fatal("failed to insert data:", err.Error())
+		return errors.MilvusFailure("insert data into", collection, err)
 	}
-	fmt.Printf("Successfully inserted data into %s\n", collection)
+	recordWrite(collection, "insert", []entity.Column{idColumn, wordColumn, introColumn})
+	logger.InfoContext(ctx, "inserted data", "collection", collection, "rows", len(bookIDs))
+	return nil
 }
 
 func CreateIndex(milvusClient client.Client, collection string, fieldName string, level entity.MetricType, nlist int, ctx context.Context) error {
@@ -118,35 +246,67 @@ func CreateIndex(milvusClient client.Client, collection string, fieldName string
 		nlist, // ConstructParams
 	)
 	if err != nil {
-		log.Fatal("fail to create ivf flat index parameter:", err.Error())
+		return fmt.Errorf("fail to create ivf flat index parameter: %w", err)
 	}
+	return CreateIndexWith(milvusClient, collection, fieldName, idx, ctx)
+}
+
+// CreateIndexWith creates any index built by NewIndex on a field
+func CreateIndexWith(milvusClient client.Client, collection string, fieldName string, idx entity.Index, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "create_index")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.CreateIndex", tracing.Milvus(), tracing.Collection(collection),
+		attribute.String("vectordb.field", fieldName), attribute.String("vectordb.index_type", string(idx.IndexType())))
+	defer tracing.End(span, &err)
 	err = milvusClient.CreateIndex(
-		context.Background(), // ctx
-		collection,           // CollectionName
-		fieldName,            // fieldName
-		idx,                  // entity.Index
-		false,                // async
+		ctx,        // ctx
+		collection, // CollectionName
+		fieldName,  // fieldName
+		idx,        // entity.Index
+		false,      // async
 	)
 	if err != nil {
-		log.Fatal("fail to create index:", err.Error())
+		return errors.MilvusFailure("create index on", collection+"."+fieldName, err)
 	}
+	logger.InfoContext(ctx, "created index", "collection", collection, "field", fieldName, "index_type", idx.IndexType())
 	return nil
 }
 
-func LoadCollection(milvusClient client.Client, collection string, ctx context.Context) error {
-	err := milvusClient.LoadCollection(
-		context.Background(), // ctx
-		collection,           // CollectionName
-		false,                // async
+func DropIndex(milvusClient client.Client, collection string, fieldName string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "drop_index")(&err)
+	if err := milvusClient.DropIndex(ctx, collection, fieldName); err != nil {
+		return errors.MilvusFailure("drop index on", collection+"."+fieldName, err)
+	}
+	logger.InfoContext(ctx, "dropped index", "collection", collection, "field", fieldName)
+	return nil
+}
+
+func LoadCollection(milvusClient client.Client, collection string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "load_collection")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.LoadCollection", tracing.Milvus(), tracing.Collection(collection))
+	defer tracing.End(span, &err)
+	err = milvusClient.LoadCollection(
+		ctx,        // ctx
+		collection, // CollectionName
+		false,      // async
 	)
 	if err != nil {
-		log.Fatal("fail to load collection:", err.Error())
+		return errors.MilvusFailure("load collection", collection, err)
 	}
-	fmt.Println("Successfully loaded collection:", collection)
+	logger.InfoContext(ctx, "loaded collection", "collection", collection)
 	return nil
 }
 
-func ConductSearch(milvusClient client.Client, collection string, outputFields []string, queryVectors []float32, topK int, ctx context.Context) error {
+func ReleaseCollection(milvusClient client.Client, collection string, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "release_collection")(&err)
+	if err := milvusClient.ReleaseCollection(ctx, collection); err != nil {
+		return errors.MilvusFailure("release collection", collection, err)
+	}
+	logger.InfoContext(ctx, "released collection", "collection", collection)
+	return nil
+}
+
+func ConductSearch(milvusClient client.Client, collection string, outputFields []string, queryVectors []float32, topK int, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "search")(&err)
 	sp, _ := entity.NewIndexFlatSearchParam()
 
 	searchResult, err := milvusClient.Search(
@@ -162,14 +322,17 @@ func ConductSearch(milvusClient client.Client, collection string, outputFields [
 		sp,           // sp
 	)
 	if err != nil {
-		log.Fatal("fail to search collection:", err.Error())
+		return errors.MilvusFailure("search collection", collection, err)
 	}
 
-	fmt.Printf("%#v\n", searchResult)
+	metrics.Dimension(metrics.VectorDB, "search", len(queryVectors))
 	for _, sr := range searchResult {
-		fmt.Println(sr.IDs)
-		fmt.Println(sr.Scores)
-		fmt.Printf("%#v\n", sr.Fields)
+		metrics.Results(metrics.VectorDB, "search", sr.ResultCount)
+	}
+	for _, sr := range searchResult {
+		for _, hit := range Hits(sr) {
+			logger.InfoContext(ctx, "search result", "collection", collection, "id", hit.ID, "score", hit.Score, "fields", hit.Fields)
+		}
 	}
 	return nil
 }
@@ -1,101 +1,154 @@
-package vectordb
-
-import (
-	"context"
-	"fmt"
-	"log"
-
-	"github.com/milvus-io/milvus-sdk-go/v2/client"
-	"github.com/milvus-io/milvus-sdk-go/v2/entity"
-)
-
-type CollectionParams struct {
-	CollectionName     string
-	Description        string
-	Fields             []*entity.Field
-	EnableDynamicField bool
-	ShardNum           int32
-}
-
-type InsertParams struct {
-	CollectionName string
-	PartitionName  string
-	Columns        map[string]entity.Column
-}
-
-func InsertData(milvusClient client.Client, params InsertParams, ctx context.Context) {
-	columns := make([]entity.Column, 0, len(params.Columns))
-	for _, column := range params.Columns {
-		columns = append(columns, column)
-	}
-	_, err := milvusClient.Insert(
-		ctx,                   // ctx
-		params.CollectionName, // CollectionName
-		params.PartitionName,  // partitionName
-		columns...,            // Columns for Collection
-	)
-	if err != nil {
-		log.Fatal("failed to insert data:", err.Error())
-	}
-	fmt.Printf("Successfully inserted data into %s\n", params.CollectionName)
-}
-
-func CreateCollectionFromStruct(milvusClient client.Client, params CollectionParams, ctx context.Context) error {
-	schema := &entity.Schema{
-		CollectionName:     params.CollectionName,
-		Description:        params.Description,
-		Fields:             params.Fields,
-		EnableDynamicField: params.EnableDynamicField,
-	}
-	// check if collection exists already
-	collections, err := milvusClient.ListCollections(ctx)
-	if err != nil {
-		log.Fatal("failed to list collections:", err.Error())
-	}
-	for _, collection := range collections {
-		fmt.Println(collection.Name)
-		if collection.Name == params.CollectionName {
-			fmt.Printf("Collection %s already exists\n", params.CollectionName)
-			return nil
-		}
-	}
-	err = milvusClient.CreateCollection(
-		ctx, // ctx
-		schema,
-		params.ShardNum, // shardNum
-	)
-	if err != nil {
-		log.Fatal("failed to create collection:", err.Error())
-	}
-	fmt.Printf("Successfully created collection %s\n", params.CollectionName)
-	return nil
-}
-
-// fields := []*entity.Field{
-// 	{
-// 		Name:     "word",
-// 		DataType: entity.FieldTypeVarChar,
-// 		TypeParams: map[string]string{
-// 			"max_length": "100", // adjust this to the maximum length of your words
-// 		},
-// 		PrimaryKey: true,
-// 		AutoID:     false,
-// 	},
-// 	{
-// 		Name:     "embedding",
-// 		DataType: entity.FieldTypeFloatVector,
-// 		TypeParams: map[string]string{
-// 			"dim": "3", // adjust this to match the dimensionality of your word embeddings
-// 		},
-// 	},
-// }
-
-// params := vectordb.CollectionParams{
-// 	CollectionName:     "words",
-// 	Description:        "Word embeddings",
-// 	Fields:             fields,
-// 	EnableDynamicField: true,
-// 	ShardNum:           2,
-// }
-
-// _ = vectordb.CreateCollectionFromStruct(client, params, ctx)
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+type CollectionParams struct {
+	CollectionName     string
+	Description        string
+	Fields             []*entity.Field
+	EnableDynamicField bool
+	ShardNum           int32
+}
+
+type InsertParams struct {
+	CollectionName string
+	PartitionName  string
+	Columns        map[string]entity.Column
+}
+
+func InsertData(milvusClient client.Client, params InsertParams, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "insert")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.Insert", tracing.Milvus(), tracing.Collection(params.CollectionName))
+	defer tracing.End(span, &err)
+	columns := make([]entity.Column, 0, len(params.Columns))
+	for _, column := range params.Columns {
+		columns = append(columns, column)
+	}
+	_, err = milvusClient.Insert(
+		ctx,                   // ctx
+		params.CollectionName, // CollectionName
+		params.PartitionName,  // partitionName
+		columns...,            // Columns for Collection
+	)
+	if err != nil {
+		return errors.MilvusFailure("insert data into", params.CollectionName, err)
+	}
+	recordWrite(params.CollectionName, "insert", columns)
+	if len(columns) > 0 {
+		span.SetAttributes(tracing.Rows(columns[0].Len()))
+	}
+	logger.InfoContext(ctx, "inserted data", "collection", params.CollectionName, "rows", columnLen(columns))
+	return nil
+}
+
+// InsertVectors bulk inserts vectors in batches, using their row number as the Int64 primary key.
+// Meant for collections built with NewFieldInt64(idField, true, false) and NewFieldFloatVector(vectorField, dim).
+func InsertVectors(milvusClient client.Client, collection string, idField string, vectorField string, vectors [][]float32, batchSize int, ctx context.Context) (err error) {
+	if len(vectors) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 10000
+	}
+	defer metrics.Track(metrics.VectorDB, "insert")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.InsertVectors", tracing.Milvus(), tracing.Collection(collection), tracing.Rows(len(vectors)))
+	defer tracing.End(span, &err)
+	dim := len(vectors[0])
+	metrics.Dimension(metrics.VectorDB, "insert", dim)
+	for start := 0; start < len(vectors); start += batchSize {
+		end := start + batchSize
+		if end > len(vectors) {
+			end = len(vectors)
+		}
+		ids := make([]int64, 0, end-start)
+		for i := start; i < end; i++ {
+			ids = append(ids, int64(i))
+		}
+		_, err := milvusClient.Insert(
+			ctx,        // ctx
+			collection, // CollectionName
+			"",         // partitionName
+			entity.NewColumnInt64(idField, ids),
+			entity.NewColumnFloatVector(vectorField, dim, vectors[start:end]),
+		)
+		if err != nil {
+			return errors.MilvusFailure(fmt.Sprintf("insert rows %d-%d into", start, end), collection, err)
+		}
+		metrics.RowsInserted(collection, end-start)
+	}
+	if err := milvusClient.Flush(ctx, collection, false); err != nil {
+		return errors.MilvusFailure("flush", collection, err)
+	}
+	logger.InfoContext(ctx, "inserted vectors", "collection", collection, "rows", len(vectors), "dim", dim)
+	return nil
+}
+
+func CreateCollectionFromStruct(milvusClient client.Client, params CollectionParams, ctx context.Context) (err error) {
+	defer metrics.Track(metrics.VectorDB, "create_collection")(&err)
+	schema := &entity.Schema{
+		CollectionName:     params.CollectionName,
+		Description:        params.Description,
+		Fields:             params.Fields,
+		EnableDynamicField: params.EnableDynamicField,
+	}
+	// check if collection exists already
+	collections, err := milvusClient.ListCollections(ctx)
+	if err != nil {
+		return errors.MilvusFailure("list collections", "", err)
+	}
+	for _, collection := range collections {
+		if collection.Name == params.CollectionName {
+			logger.InfoContext(ctx, "collection already exists", "collection", params.CollectionName)
+			return nil
+		}
+	}
+	err = milvusClient.CreateCollection(
+		ctx, // ctx
+		schema,
+		params.ShardNum, // shardNum
+	)
+	if err != nil {
+		return errors.MilvusFailure("create collection", params.CollectionName, err)
+	}
+	logger.InfoContext(ctx, "created collection", "collection", params.CollectionName, "fields", len(params.Fields))
+	return nil
+}
+
+// fields := []*entity.Field{
+// 	{
+// 		Name:     "word",
+// 		DataType: entity.FieldTypeVarChar,
+// 		TypeParams: map[string]string{
+// 			"max_length": "100", // adjust this to the maximum length of your words
+// 		},
+// 		PrimaryKey: true,
+// 		AutoID:     false,
+// 	},
+// 	{
+// 		Name:     "embedding",
+// 		DataType: entity.FieldTypeFloatVector,
+// 		TypeParams: map[string]string{
+// 			"dim": "3", // adjust this to match the dimensionality of your word embeddings
+// 		},
+// 	},
+// }
+
+// params := vectordb.CollectionParams{
+// 	CollectionName:     "words",
+// 	Description:        "Word embeddings",
+// 	Fields:             fields,
+// 	EnableDynamicField: true,
+// 	ShardNum:           2,
+// }
+
+// _ = vectordb.CreateCollectionFromStruct(client, params, ctx)
@@ -0,0 +1,74 @@
+package vectordb
+
+import (
+	"strings"
+
+	"milvus/errors"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// NewIndex builds a Milvus index and a matching search param from an index type name and its parameters.
+//
+//	FLAT                                     -
+//	IVF_FLAT, IVF_SQ8   nlist (1024)         nprobe (16)
+//	IVF_PQ              nlist (1024), m (8), nbits (8)   nprobe (16)
+//	HNSW                M (16), efConstruction (200)     ef (64)
+//	AUTOINDEX           -                    level (1)
+func NewIndex(indexType string, metric entity.MetricType, params map[string]int) (entity.Index, entity.SearchParam, error) {
+	param := func(name string, fallback int) int {
+		if v, ok := params[name]; ok {
+			return v
+		}
+		return fallback
+	}
+
+	var (
+		idx entity.Index
+		sp  entity.SearchParam
+		err error
+	)
+	switch strings.ToUpper(indexType) {
+	case "", "FLAT":
+		if idx, err = entity.NewIndexFlat(metric); err == nil {
+			sp, err = entity.NewIndexFlatSearchParam()
+		}
+	case "IVF_FLAT":
+		if idx, err = entity.NewIndexIvfFlat(metric, param("nlist", 1024)); err == nil {
+			sp, err = entity.NewIndexIvfFlatSearchParam(param("nprobe", 16))
+		}
+	case "IVF_SQ8":
+		if idx, err = entity.NewIndexIvfSQ8(metric, param("nlist", 1024)); err == nil {
+			sp, err = entity.NewIndexIvfSQ8SearchParam(param("nprobe", 16))
+		}
+	case "IVF_PQ":
+		if idx, err = entity.NewIndexIvfPQ(metric, param("nlist", 1024), param("m", 8), param("nbits", 8)); err == nil {
+			sp, err = entity.NewIndexIvfPQSearchParam(param("nprobe", 16))
+		}
+	case "HNSW":
+		if idx, err = entity.NewIndexHNSW(metric, param("M", 16), param("efConstruction", 200)); err == nil {
+			sp, err = entity.NewIndexHNSWSearchParam(param("ef", 64))
+		}
+	case "AUTOINDEX":
+		if idx, err = entity.NewIndexAUTOINDEX(metric); err == nil {
+			sp, err = entity.NewIndexAUTOINDEXSearchParam(param("level", 1))
+		}
+	default:
+		return nil, nil, errors.Invalid("index_type", "unsupported index type %q", indexType)
+	}
+	if err != nil {
+		return nil, nil, errors.Invalid("params", "invalid %s parameters: %v", indexType, err)
+	}
+	return idx, sp, nil
+}
+
+// RangeSearchParam turns sp into a range search that only returns hits within threshold: a
+// squared distance below it for L2, a similarity above it for IP and COSINE (Milvus' radius).
+// TopK still caps the hits per query vector.
+func RangeSearchParam(sp entity.SearchParam, threshold float64) entity.SearchParam {
+	if sp == nil {
+		sp, _ = entity.NewIndexFlatSearchParam()
+	}
+	sp.AddRadius(threshold)
+	return sp
+}
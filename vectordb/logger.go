@@ -0,0 +1,14 @@
+package vectordb
+
+import (
+	"log/slog"
+
+	"milvus/logging"
+)
+
+var logger = logging.Discard
+
+// SetLogger makes vectordb log through l, nil silences it again (the default). Call it before using the package.
+func SetLogger(l *slog.Logger) {
+	logger = logging.OrDiscard(l)
+}
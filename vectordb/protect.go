@@ -0,0 +1,57 @@
+package vectordb
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// ErrProtectedCollection is returned when dropping, deleting from or rewriting a protected collection
+var ErrProtectedCollection = stdErrors.New("collection is protected")
+
+var (
+	protectedMu sync.RWMutex
+	protected   []string
+)
+
+// Protect adds collection names, or path.Match patterns such as "prod_*", to the allowlist of
+// collections this package will never drop, delete entities from or rewrite rows of. The allowlist only guards
+// against this process, not other Milvus clients.
+func Protect(names ...string) error {
+	for _, name := range names {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("invalid protected collection pattern %q: %w", name, err)
+		}
+	}
+	protectedMu.Lock()
+	defer protectedMu.Unlock()
+	protected = append(protected, names...)
+	return nil
+}
+
+// ProtectedCollections returns the names and patterns passed to Protect
+func ProtectedCollections() []string {
+	protectedMu.RLock()
+	defer protectedMu.RUnlock()
+	return append([]string(nil), protected...)
+}
+
+// IsProtected reports whether collection matches the protected allowlist
+func IsProtected(collection string) bool {
+	protectedMu.RLock()
+	defer protectedMu.RUnlock()
+	for _, pattern := range protected {
+		if ok, _ := path.Match(pattern, collection); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func checkProtected(collection string) error {
+	if IsProtected(collection) {
+		return fmt.Errorf("%w: %s", ErrProtectedCollection, collection)
+	}
+	return nil
+}
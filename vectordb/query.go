@@ -2,40 +2,94 @@ package vectordb
 
 import (
 	"context"
-	"fmt"
-	"log"
+
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
 
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
 )
 
-func QueryCollection(milvusClient client.Client, collection string, expr string, outputFields []string, ctx context.Context) {
+type QueryParams struct {
+	CollectionName string
+	PartitionNames []string
+	Expr           string
+	OutputFields   []string
+	Offset         int64
+	Limit          int64 // 0 means no limit
+}
+
+// Query runs a filter expression against a loaded collection and returns the matching columns
+func Query(milvusClient client.Client, params QueryParams, ctx context.Context) (columns []entity.Column, err error) {
+	defer metrics.Track(metrics.VectorDB, "query")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.Query", tracing.Milvus(), tracing.Collection(params.CollectionName), tracing.Expr(params.Expr))
+	defer tracing.End(span, &err)
+	var opts []client.SearchQueryOptionFunc
+	if params.Limit > 0 {
+		opts = append(opts, client.WithLimit(params.Limit), client.WithOffset(params.Offset))
+	}
 	queryResult, err := milvusClient.Query(
-		context.Background(), // ctx
-		collection,           // CollectionName
-		[]string{},           // PartitionName
-		expr,                 // expr
-		outputFields,         // OutputFields
+		ctx,                   // ctx
+		params.CollectionName, // CollectionName
+		params.PartitionNames, // PartitionName
+		params.Expr,           // expr
+		params.OutputFields,   // OutputFields
+		opts...,
 	)
 	if err != nil {
-		log.Fatal("fail to query collection:", err.Error())
+		return nil, errors.MilvusFailure("query collection", params.CollectionName, err)
 	}
+	rows := 0
+	if len(queryResult) > 0 {
+		rows = queryResult[0].Len()
+	}
+	metrics.Results(metrics.VectorDB, "query", rows)
+	span.SetAttributes(tracing.Rows(rows))
+	return queryResult, nil
+}
 
-	fmt.Printf("Raw Query Result\n%#v\n\n", queryResult)
-
-	for _, qr := range queryResult {
-
-		fmt.Println("Column Name:", qr.Name())
+func QueryCollection(milvusClient client.Client, collection string, expr string, outputFields []string, ctx context.Context) error {
+	queryResult, err := Query(milvusClient, QueryParams{
+		CollectionName: collection,
+		PartitionNames: []string{},
+		Expr:           expr,
+		OutputFields:   outputFields,
+	}, ctx)
+	if err != nil {
+		return err
+	}
 
-		numResults := qr.Len()
-		fmt.Printf("Query Returned %d results\n", numResults)
+	rows := Rows(queryResult)
+	logger.InfoContext(ctx, "queried collection", "collection", collection, "expr", expr, "rows", len(rows))
+	for i, row := range rows {
+		logger.InfoContext(ctx, "query result", "collection", collection, "index", i, "row", row)
+	}
+	return nil
+}
 
-		for i := 0; i < numResults; i++ {
-			val, _ := qr.GetAsString(i)
-			fmt.Printf("Result %d: %s\n", i, val)
+// Rows turns query columns into one map per entity, keyed by field name
+func Rows(columns []entity.Column) []map[string]interface{} {
+	if len(columns) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, columns[0].Len())
+	for i := range rows {
+		rows[i] = make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			rows[i][column.Name()] = columnValue(column, i)
 		}
+	}
+	return rows
+}
 
-		// fmt.Println("Field Data : ", qr.FieldData())
-		// fields := qr.FieldData().GetScalars().Data
-
+func columnValue(column entity.Column, i int) interface{} {
+	if vectors, ok := column.(*entity.ColumnFloatVector); ok {
+		return vectors.Data()[i]
+	}
+	value, err := column.Get(i)
+	if err != nil {
+		return nil
 	}
+	return value
 }
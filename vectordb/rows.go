@@ -0,0 +1,443 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ColumnsFromRows converts row maps (e.g. decoded JSON) into columns matching the collection schema.
+// Auto ID primary keys are skipped, every other field must be present in every row.
+func ColumnsFromRows(info *CollectionInfo, rows []map[string]interface{}) ([]entity.Column, error) {
+	if len(rows) == 0 {
+		return nil, errors.Invalid("rows", "no rows to insert")
+	}
+	columns := make([]entity.Column, 0, len(info.Fields))
+	for _, field := range info.Fields {
+		if field.PrimaryKey && field.AutoID {
+			continue
+		}
+		column, err := columnFromRows(field, rows)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Write inserts, or with upsert set upserts, columns built by ColumnsFromRows. Upserting rewrites
+// rows, so it is refused for protected collections.
+func Write(milvusClient client.Client, collection string, partition string, upsert bool, columns []entity.Column, ctx context.Context) (err error) {
+	operation := "insert"
+	if upsert {
+		operation = "upsert"
+	}
+	defer metrics.Track(metrics.VectorDB, operation)(&err)
+	if upsert {
+		if err := checkProtected(collection); err != nil {
+			return err
+		}
+	}
+	ctx, span := tracing.Start(ctx, "vectordb.Write", tracing.Milvus(), tracing.Collection(collection), tracing.Rows(columnLen(columns)),
+		attribute.Bool("vectordb.upsert", upsert))
+	defer tracing.End(span, &err)
+	if upsert {
+		_, err = milvusClient.Upsert(ctx, collection, partition, columns...)
+	} else {
+		_, err = milvusClient.Insert(ctx, collection, partition, columns...)
+	}
+	if err != nil {
+		return errors.MilvusFailure(operation+" into", collection, err)
+	}
+	recordWrite(collection, operation, columns)
+	return nil
+}
+
+// recordWrite counts the rows and the vector dimension of a successful write
+func recordWrite(collection string, operation string, columns []entity.Column) {
+	if len(columns) == 0 {
+		return
+	}
+	metrics.RowsInserted(collection, columnLen(columns))
+	for _, column := range columns {
+		if vectors, ok := column.(*entity.ColumnFloatVector); ok {
+			metrics.Dimension(metrics.VectorDB, operation, vectors.Dim())
+		}
+	}
+}
+
+func columnLen(columns []entity.Column) int {
+	if len(columns) == 0 {
+		return 0
+	}
+	return columns[0].Len()
+}
+
+func columnFromRows(field FieldInfo, rows []map[string]interface{}) (entity.Column, error) {
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		value, ok := row[field.Name]
+		if !ok {
+			return nil, errors.Invalid(field.Name, "row %d is missing field %s", i, field.Name)
+		}
+		values[i] = value
+	}
+
+	switch field.DataType {
+	case entity.FieldTypeVarChar.String():
+		data := make([]string, len(values))
+		for i, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return nil, errors.Invalid(field.Name, "row %d: field %s must be a string", i, field.Name)
+			}
+			data[i] = s
+		}
+		return entity.NewColumnVarChar(field.Name, data), nil
+	case entity.FieldTypeInt64.String():
+		data := make([]int64, len(values))
+		for i, v := range values {
+			n, err := toInt64(v)
+			if err != nil {
+				return nil, errors.Invalid(field.Name, "row %d: field %s: %v", i, field.Name, err)
+			}
+			data[i] = n
+		}
+		return entity.NewColumnInt64(field.Name, data), nil
+	case entity.FieldTypeFloat.String():
+		data := make([]float32, len(values))
+		for i, v := range values {
+			n, err := toFloat(v)
+			if err != nil {
+				return nil, errors.Invalid(field.Name, "row %d: field %s: %v", i, field.Name, err)
+			}
+			data[i] = float32(n)
+		}
+		return entity.NewColumnFloat(field.Name, data), nil
+	case entity.FieldTypeDouble.String():
+		data := make([]float64, len(values))
+		for i, v := range values {
+			n, err := toFloat(v)
+			if err != nil {
+				return nil, errors.Invalid(field.Name, "row %d: field %s: %v", i, field.Name, err)
+			}
+			data[i] = n
+		}
+		return entity.NewColumnDouble(field.Name, data), nil
+	case entity.FieldTypeBool.String():
+		data := make([]bool, len(values))
+		for i, v := range values {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, errors.Invalid(field.Name, "row %d: field %s must be a boolean", i, field.Name)
+			}
+			data[i] = b
+		}
+		return entity.NewColumnBool(field.Name, data), nil
+	case entity.FieldTypeFloatVector.String():
+		dim, err := strconv.Atoi(field.TypeParams["dim"])
+		if err != nil {
+			return nil, fmt.Errorf("field %s has no dimension", field.Name)
+		}
+		data := make([][]float32, len(values))
+		for i, v := range values {
+			vector, err := toVector(v)
+			if err != nil {
+				return nil, errors.Invalid(field.Name, "row %d: field %s: %v", i, field.Name, err)
+			}
+			if len(vector) != dim {
+				return nil, errors.DimensionMismatch(fmt.Sprintf("row %d: field %s", i, field.Name), len(vector), dim)
+			}
+			data[i] = vector
+		}
+		return entity.NewColumnFloatVector(field.Name, dim, data), nil
+	}
+	return nil, errors.Invalid(field.Name, "field %s has unsupported type %s", field.Name, field.DataType)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+// toInt64 is toFloat for integer fields: fractions are rejected rather than truncated, and JSON
+// decoded with UseNumber keeps integers above 2^53 exact
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	}
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("expected an integer, got %v", v)
+	}
+	return int64(f), nil
+}
+
+func toVector(v interface{}) ([]float32, error) {
+	switch values := v.(type) {
+	case []float32:
+		return values, nil
+	case []interface{}:
+		vector := make([]float32, len(values))
+		for i, value := range values {
+			n, err := toFloat(value)
+			if err != nil {
+				return nil, err
+			}
+			vector[i] = float32(n)
+		}
+		return vector, nil
+	}
+	return nil, fmt.Errorf("expected a vector, got %T", v)
+}
+
+// InsertWords bulk inserts words and their vectors in batches into a collection keyed by the word
+func InsertWords(milvusClient client.Client, collection string, wordField string, vectorField string, words []string, vectors [][]float32, batchSize int, ctx context.Context) error {
+	if len(words) != len(vectors) {
+		return errors.Invalid("vectors", "got %d words but %d vectors", len(words), len(vectors))
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 10000
+	}
+	dim := len(vectors[0])
+	for start := 0; start < len(words); start += batchSize {
+		end := start + batchSize
+		if end > len(words) {
+			end = len(words)
+		}
+		err := InsertData(milvusClient, InsertParams{
+			CollectionName: collection,
+			Columns: map[string]entity.Column{
+				wordField:   entity.NewColumnVarChar(wordField, words[start:end]),
+				vectorField: entity.NewColumnFloatVector(vectorField, dim, vectors[start:end]),
+			},
+		}, ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if err := milvusClient.Flush(ctx, collection, false); err != nil {
+		return errors.MilvusFailure("flush", collection, err)
+	}
+	return nil
+}
+
+// ExportWords reads every word and vector out of a loaded collection keyed by a VarChar word field
+func ExportWords(milvusClient client.Client, collection string, wordField string, vectorField string, batchSize int, ctx context.Context) ([]string, [][]float32, error) {
+	var (
+		words   []string
+		vectors [][]float32
+	)
+	err := ScanWords(milvusClient, collection, wordField, vectorField, batchSize, ctx, func(page []string, pageVectors [][]float32) error {
+		words = append(words, page...)
+		vectors = append(vectors, pageVectors...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return words, vectors, nil
+}
+
+// ScanWords calls fn with every page of at most batchSize words and vectors of a loaded collection
+// keyed by a VarChar word field, so a collection can be processed without holding all of it.
+// Pages are fetched with "word > last" and a limit, which relies on Milvus returning limited
+// query results ordered by primary key. An error from fn stops the scan and is returned.
+func ScanWords(milvusClient client.Client, collection string, wordField string, vectorField string, batchSize int, ctx context.Context, fn func(words []string, vectors [][]float32) error) error {
+	if batchSize <= 0 {
+		batchSize = 10000
+	}
+	last := ""
+	for {
+		quoted, ok := QuoteString(last)
+		if !ok {
+			return errors.Invalid(wordField, "word %q of %s can't be written in a Milvus expression, the scan can't go past it", last, collection)
+		}
+		columns, err := Query(milvusClient, QueryParams{
+			CollectionName: collection,
+			Expr:           fmt.Sprintf("%s > %s", wordField, quoted),
+			OutputFields:   []string{wordField, vectorField},
+			Limit:          int64(batchSize),
+		}, ctx)
+		if err != nil {
+			return err
+		}
+		rows := Rows(columns)
+		words := make([]string, 0, len(rows))
+		vectors := make([][]float32, 0, len(rows))
+		for _, row := range rows {
+			word, _ := row[wordField].(string)
+			vector, _ := row[vectorField].([]float32)
+			words = append(words, word)
+			vectors = append(vectors, vector)
+			if word > last {
+				last = word
+			}
+		}
+		if len(words) > 0 {
+			if err := fn(words, vectors); err != nil {
+				return err
+			}
+		}
+		if len(rows) < batchSize {
+			return nil
+		}
+	}
+}
+
+// QuoteString quotes s as a string literal of Milvus' expression grammar, escaping backslashes,
+// double quotes and the control characters that have a C escape. ok is false if s holds any other
+// control character or invalid UTF-8, which the grammar has no way to spell.
+func QuoteString(s string) (quoted string, ok bool) {
+	if !utf8.ValidString(s) {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			if unicode.IsControl(r) {
+				return "", false
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), true
+}
+
+// DeleteWords deletes the entities of words from a collection keyed by a VarChar word field, with
+// one "word in [...]" delete per batch. Words QuoteString can't represent are left in place and
+// returned as skipped; they are checked before anything is deleted.
+func DeleteWords(milvusClient client.Client, collection string, wordField string, words []string, batchSize int, ctx context.Context) (skipped []string, err error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if q, ok := QuoteString(word); ok {
+			quoted = append(quoted, q)
+		} else {
+			skipped = append(skipped, word)
+		}
+	}
+	for start := 0; start < len(quoted); start += batchSize {
+		end := min(start+batchSize, len(quoted))
+		expr := fmt.Sprintf("%s in [%s]", wordField, strings.Join(quoted[start:end], ", "))
+		if err := DeleteByExpr(milvusClient, collection, "", expr, ctx); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// UpsertWordField stores an Int64 value per word in field, rewriting each word's row with its
+// vector. field is either an Int64 field of the schema or, if the collection has dynamic fields
+// enabled, a new dynamic field that filter expressions like "cluster == 3" can use. The
+// collection must hold only the word and vector fields (plus field); other dynamic fields of
+// the rewritten rows are dropped.
+func UpsertWordField(milvusClient client.Client, collection string, wordField string, vectorField string, field string, words []string, vectors [][]float32, values []int64, batchSize int, ctx context.Context) error {
+	if len(words) != len(vectors) || len(words) != len(values) {
+		return errors.Invalid(field, "got %d words, %d vectors and %d values", len(words), len(vectors), len(values))
+	}
+	if err := checkProtected(collection); err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	info, err := DescribeCollection(milvusClient, collection, ctx)
+	if err != nil {
+		return err
+	}
+	inSchema := false
+	for _, f := range info.Fields {
+		switch {
+		case f.Name == field && f.DataType != entity.FieldTypeInt64.String():
+			return errors.Invalid(field, "field %s of %s is %s, not Int64", field, collection, f.DataType)
+		case f.Name == field:
+			inSchema = true
+		case f.Name != wordField && f.Name != vectorField:
+			return errors.Invalid(field, "collection %s has field %s besides the words and vectors, upserting would lose it", collection, f.Name)
+		}
+	}
+	if !inSchema && !info.Dynamic {
+		return errors.Invalid(field, "collection %s has no field %s and dynamic fields are disabled", collection, field)
+	}
+
+	if batchSize <= 0 {
+		batchSize = 10000
+	}
+	dim := len(vectors[0])
+	for start := 0; start < len(words); start += batchSize {
+		end := min(start+batchSize, len(words))
+		columns := []entity.Column{
+			entity.NewColumnVarChar(wordField, words[start:end]),
+			entity.NewColumnFloatVector(vectorField, dim, vectors[start:end]),
+			entity.NewColumnInt64(field, values[start:end]),
+		}
+		if err := Write(milvusClient, collection, "", true, columns, ctx); err != nil {
+			return err
+		}
+	}
+	if err := milvusClient.Flush(ctx, collection, false); err != nil {
+		return errors.MilvusFailure("flush", collection, err)
+	}
+	return nil
+}
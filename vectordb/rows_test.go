@@ -0,0 +1,90 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"strings"
+	"testing"
+
+	"milvus/errors"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+func TestColumnsFromRows(t *testing.T) {
+	info := &CollectionInfo{Fields: []FieldInfo{
+		{Name: "id", DataType: entity.FieldTypeInt64.String(), PrimaryKey: true},
+		{Name: "embedding", DataType: entity.FieldTypeFloatVector.String(), TypeParams: map[string]string{"dim": "2"}},
+	}}
+	decode := func(text string) map[string]interface{} {
+		var row map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&row); err != nil {
+			t.Fatal(err)
+		}
+		return row
+	}
+
+	rows := []map[string]interface{}{
+		decode(`{"id": 9007199254740993, "embedding": [0.5, 1]}`),
+		decode(`{"id": 2.0, "embedding": [1e-3, 2]}`),
+		{"id": int64(-3), "embedding": []float32{0, 1}},
+	}
+	columns, err := ColumnsFromRows(info, rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := columns[0].(*entity.ColumnInt64).Data()
+	if ids[0] != 9007199254740993 || ids[1] != 2 || ids[2] != -3 {
+		t.Errorf("ids = %v", ids)
+	}
+	if vectors := columns[1].(*entity.ColumnFloatVector).Data(); vectors[1][0] != 1e-3 {
+		t.Errorf("vectors = %v", vectors)
+	}
+
+	for _, id := range []string{"1.5", "1e30", `"7"`} {
+		rows := []map[string]interface{}{decode(`{"id": ` + id + `, "embedding": [0, 1]}`)}
+		if _, err := ColumnsFromRows(info, rows); !stdErrors.Is(err, errors.ErrValidation) {
+			t.Errorf("id %s: expected a validation error, got %v", id, err)
+		}
+	}
+}
+
+func TestUpsertWordFieldProtected(t *testing.T) {
+	if err := Protect("rows_test_protected"); err != nil {
+		t.Fatal(err)
+	}
+	// refused before the (missing) client is used
+	err := UpsertWordField(nil, "rows_test_protected", "word", "embedding", "cluster",
+		[]string{"cat"}, [][]float32{{0, 1}}, []int64{3}, 0, context.Background())
+	if !stdErrors.Is(err, ErrProtectedCollection) {
+		t.Errorf("expected a protected collection error, got %v", err)
+	}
+	err = Write(nil, "rows_test_protected", "", true, nil, context.Background())
+	if !stdErrors.Is(err, ErrProtectedCollection) {
+		t.Errorf("expected a protected collection error upserting, got %v", err)
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	tests := []struct {
+		s      string
+		quoted string
+		ok     bool
+	}{
+		{"cat", `"cat"`, true},
+		{`say "hi"\now`, `"say \"hi\"\\now"`, true},
+		{"tab\tand\nnewline", `"tab\tand\nnewline"`, true},
+		{"naïve 猫", `"naïve 猫"`, true},
+		{"bell\x07", `"bell\a"`, true},
+		{"escape\x1b", "", false},
+		{"invalid\xff", "", false},
+	}
+	for _, tt := range tests {
+		if quoted, ok := QuoteString(tt.s); quoted != tt.quoted || ok != tt.ok {
+			t.Errorf("QuoteString(%q) = %s, %v, want %s, %v", tt.s, quoted, ok, tt.quoted, tt.ok)
+		}
+	}
+}
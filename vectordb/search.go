@@ -2,8 +2,10 @@ package vectordb
 
 import (
 	"context"
-	"fmt"
-	"log"
+
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
 
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
@@ -15,44 +17,104 @@ type SearchResult struct {
 	Fields []*entity.ColumnVarChar
 }
 
-func SearchIndexFromCollection(milvusClient client.Client, collection string, queryField string, queryVectors []entity.Vector, outputFields []string, topK int, ctx context.Context) error {
-	sp, _ := entity.NewIndexFlatSearchParam()
+type SearchParams struct {
+	CollectionName string
+	PartitionNames []string
+	Expr           string
+	OutputFields   []string
+	VectorField    string
+	Vectors        []entity.Vector
+	Metric         entity.MetricType
+	TopK           int
+	SearchParam    entity.SearchParam // defaults to a flat search param
+}
+
+// Search runs a vector similarity search and returns one result per query vector
+func Search(milvusClient client.Client, params SearchParams, ctx context.Context) (results []client.SearchResult, err error) {
+	defer metrics.Track(metrics.VectorDB, "search")(&err)
+	ctx, span := tracing.Start(ctx, "vectordb.Search", tracing.Milvus(), tracing.Collection(params.CollectionName), tracing.TopK(params.TopK), tracing.Expr(params.Expr))
+	defer tracing.End(span, &err)
+	sp := params.SearchParam
+	if sp == nil {
+		sp, _ = entity.NewIndexFlatSearchParam()
+	}
+	metric := params.Metric
+	if metric == "" {
+		metric = entity.L2
+	}
+	partitions := params.PartitionNames
+	if partitions == nil {
+		partitions = []string{}
+	}
 
 	searchResult, err := milvusClient.Search(
-		context.Background(), // ctx
-		collection,           // CollectionName
-		[]string{},           // partitionNames
-		"",                   // expr
-		outputFields,         // outputFields
-		queryVectors,         // vectors
-		queryField,           // vectorField
-		entity.L2,            // metricType
-		topK,                 // topK
-		sp,                   // sp
+		ctx,                   // ctx
+		params.CollectionName, // CollectionName
+		partitions,            // partitionNames
+		params.Expr,           // expr
+		params.OutputFields,   // outputFields
+		params.Vectors,        // vectors
+		params.VectorField,    // vectorField
+		metric,                // metricType
+		params.TopK,           // topK
+		sp,                    // sp
 	)
 	if err != nil {
-		log.Fatal("fail to search collection:", err.Error())
+		return nil, errors.MilvusFailure("search collection", params.CollectionName, err)
+	}
+	if len(params.Vectors) > 0 {
+		metrics.Dimension(metrics.VectorDB, "search", params.Vectors[0].Dim())
 	}
-
-	fmt.Printf("Raw Search Result\n%#v\n\n", searchResult)
-
 	for _, sr := range searchResult {
-		fmt.Println("IDs ", sr.IDs)
+		metrics.Results(metrics.VectorDB, "search", sr.ResultCount)
+	}
+	if len(searchResult) > 0 {
+		span.SetAttributes(tracing.Rows(searchResult[0].ResultCount))
+	}
+	return searchResult, nil
+}
+
+func SearchIndexFromCollection(milvusClient client.Client, collection string, queryField string, queryVectors []entity.Vector, outputFields []string, topK int, ctx context.Context) error {
+	searchResult, err := Search(milvusClient, SearchParams{
+		CollectionName: collection,
+		OutputFields:   outputFields,
+		VectorField:    queryField,
+		Vectors:        queryVectors,
+		Metric:         entity.L2,
+		TopK:           topK,
+	}, ctx)
+	if err != nil {
+		return err
+	}
 
-		numResults := sr.ResultCount
-		fmt.Println("Number of Results: ", numResults)
-		for i := 0; i < numResults; i++ {
-			ids, _ := sr.IDs.GetAsString(i)
-			fmt.Printf("Result %d: %s Score: %f\n", i, ids, sr.Scores[i])
+	for q, sr := range searchResult {
+		logger.InfoContext(ctx, "searched collection", "collection", collection, "query", q, "results", sr.ResultCount)
+		for i, hit := range Hits(sr) {
+			logger.InfoContext(ctx, "search result", "collection", collection, "query", q, "rank", i+1, "id", hit.ID, "score", hit.Score, "fields", hit.Fields)
 		}
+	}
+	return nil
+}
 
-		// fmt.Println("Scores ", sr.Scores)
-		fmt.Printf("Fields:\n%#v\n", sr.Fields)
+// Hit is one search result row
+type Hit struct {
+	ID     interface{}            `json:"id"`
+	Score  float32                `json:"score"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
 
-		for _, field := range sr.Fields {
-			fmt.Println(field.FieldData().GetScalars().Data)
+// Hits flattens a search result into rows with their output fields
+func Hits(sr client.SearchResult) []Hit {
+	hits := make([]Hit, sr.ResultCount)
+	for i := range hits {
+		id, _ := sr.IDs.Get(i)
+		hits[i] = Hit{ID: id, Score: sr.Scores[i]}
+		if len(sr.Fields) > 0 {
+			hits[i].Fields = make(map[string]interface{}, len(sr.Fields))
+			for _, field := range sr.Fields {
+				hits[i].Fields[field.Name()] = columnValue(field, i)
+			}
 		}
 	}
-
-	return nil
+	return hits
 }
@@ -0,0 +1,120 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// MilvusStore loads vectors into a scratch collection with the given index so it can be benchmarked
+// next to local indexes. It satisfies benchmark.VectorStore.
+type MilvusStore struct {
+	Client     client.Client
+	Collection string
+	IndexType  string
+	Params     map[string]int
+	Metric     entity.MetricType
+	BatchSize  int
+
+	searchParam entity.SearchParam
+	created     bool
+}
+
+func (ms *MilvusStore) Name() string {
+	keys := make([]string, 0, len(ms.Params))
+	for k := range ms.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		params = append(params, fmt.Sprintf("%s=%d", k, ms.Params[k]))
+	}
+	params = append(params, fmt.Sprintf("metric=%s", ms.Metric))
+	return fmt.Sprintf("milvus-%s(%s)", strings.ToLower(ms.IndexType), strings.Join(params, ","))
+}
+
+func (ms *MilvusStore) Build(vectors [][]float32, ctx context.Context) error {
+	if len(vectors) == 0 {
+		return fmt.Errorf("no vectors to insert")
+	}
+	idx, sp, err := NewIndex(ms.IndexType, ms.Metric, ms.Params)
+	if err != nil {
+		return err
+	}
+	ms.searchParam = sp
+
+	// Never reuse someone else's collection, Close drops it afterwards
+	exists, err := ms.Client.HasCollection(ctx, ms.Collection)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("collection %s already exists, pick another name for the benchmark", ms.Collection)
+	}
+
+	dim := len(vectors[0])
+	schema := &entity.Schema{
+		CollectionName: ms.Collection,
+		Description:    "benchmark scratch collection",
+		Fields: []*entity.Field{
+			NewFieldInt64("id", true, false),
+			NewFieldFloatVector("embedding", dim),
+		},
+	}
+	if err := ms.Client.CreateCollection(ctx, schema, 2); err != nil {
+		return err
+	}
+	ms.created = true
+
+	if err := InsertVectors(ms.Client, ms.Collection, "id", "embedding", vectors, ms.BatchSize, ctx); err != nil {
+		return err
+	}
+	if err := ms.Client.CreateIndex(ctx, ms.Collection, "embedding", idx, false); err != nil {
+		return err
+	}
+	return ms.Client.LoadCollection(ctx, ms.Collection, false)
+}
+
+func (ms *MilvusStore) Search(query []float32, k int, ctx context.Context) ([]int64, error) {
+	results, err := ms.Client.Search(
+		ctx,
+		ms.Collection,
+		[]string{},
+		"",
+		[]string{},
+		[]entity.Vector{entity.FloatVector(query)},
+		"embedding",
+		ms.Metric,
+		k,
+		ms.searchParam,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, 0, results[0].ResultCount)
+	for i := 0; i < results[0].ResultCount; i++ {
+		id, err := results[0].IDs.GetAsInt64(i)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Close drops the scratch collection if Build created it
+func (ms *MilvusStore) Close(ctx context.Context) error {
+	if !ms.created {
+		return nil
+	}
+	ms.created = false
+	return ms.Client.DropCollection(ctx, ms.Collection)
+}
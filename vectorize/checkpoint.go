@@ -0,0 +1,81 @@
+package vectorize
+
+import (
+	stdErrors "errors"
+	"os"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/word2vec"
+)
+
+// openModel returns the model TrainWithOptions trains: the run saved at opts.CheckpointPath when
+// resuming (resume is then true), the model in opts.From to continue, or a new one
+func openModel(inputPath string, opts TrainOptions) (model *word2vec.Model, resume bool, err error) {
+	if opts.Resume && opts.CheckpointPath != "" {
+		if _, err := os.Stat(opts.CheckpointPath); err == nil {
+			model, err := LoadCheckpoint(opts.CheckpointPath)
+			if err != nil {
+				return nil, false, errors.TrainingFailed(errors.StageOpen, opts.CheckpointPath, err)
+			}
+			return model, true, nil
+		}
+	}
+
+	modelOpts := opts.Model
+	if modelOpts == (word2vec.Options{}) {
+		modelOpts = word2vec.DefaultOptions()
+	}
+	if opts.From == "" {
+		model, err := word2vec.New(modelOpts)
+		if err != nil {
+			return nil, false, errors.ModelLoadingError(inputPath, err)
+		}
+		return model, false, nil
+	}
+
+	model, err = LoadCheckpoint(opts.From)
+	if stdErrors.Is(err, errors.ErrFileFormat) {
+		// Not a checkpoint, so continue from the saved vectors
+		model, err = fromVectors(opts.From, modelOpts)
+	}
+	if err != nil {
+		return nil, false, errors.TrainingFailed(errors.StageOpen, opts.From, err)
+	}
+	return model, false, nil
+}
+
+// LoadCheckpoint reads a checkpoint saved by TrainWithOptions, e.g. to save its vectors with Save
+// while the run is still going
+func LoadCheckpoint(path string) (*word2vec.Model, error) {
+	input, err := os.Open(path)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(path, err)
+		}
+		return nil, errors.FileLoadingError(path, err)
+	}
+	defer input.Close()
+	model, err := word2vec.ReadCheckpoint(input)
+	if err != nil {
+		var fe *errors.FileError
+		if stdErrors.As(err, &fe) {
+			fe.Path = path
+		}
+		return nil, err
+	}
+	return model, nil
+}
+
+func fromVectors(path string, opts word2vec.Options) (*word2vec.Model, error) {
+	embs, err := embeddings.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]string, len(embs))
+	vectors := make([][]float32, len(embs))
+	for i, e := range embs {
+		words[i], vectors[i] = e.Word, e.Vector
+	}
+	return word2vec.FromVectors(words, vectors, opts)
+}
@@ -0,0 +1,231 @@
+package vectorize
+
+import (
+	"context"
+	stdErrors "errors"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"milvus/embeddings"
+	"milvus/errors"
+	"milvus/metrics"
+	"milvus/tracing"
+	"milvus/word2vec"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CorpusStats describes a corpus as Train tokenizes it
+type CorpusStats struct {
+	Path              string          `json:"path"`
+	Tokens            int64           `json:"tokens"`
+	Vocabulary        int             `json:"vocabulary"`
+	Sentences         int64           `json:"sentences"` // non-empty lines
+	AvgSentenceLength float64         `json:"avg_sentence_length"`
+	MinCounts         []MinCountStats `json:"min_counts"`
+	TopWords          []WordCount     `json:"top_words"`
+	Zipf              []WordCount     `json:"zipf"`          // the word at ranks 1, 2, 5, 10, 20, 50, ...
+	ZipfExponent      float64         `json:"zipf_exponent"` // s in frequency ~ 1/rank^s, fitted over all ranks
+	OOV               *OOVStats       `json:"oov,omitempty"`
+}
+
+// MinCountStats is the vocabulary Train would keep with word2vec.Options.MinCount set to MinCount
+type MinCountStats struct {
+	MinCount   int     `json:"min_count"`
+	Vocabulary int     `json:"vocabulary"`
+	Coverage   float64 `json:"coverage"` // fraction of the tokens that are in that vocabulary
+}
+
+type WordCount struct {
+	Rank      int     `json:"rank"`
+	Word      string  `json:"word"`
+	Count     int64   `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+// OOVStats compares the corpus with the vocabulary of a vector file
+type OOVStats struct {
+	Vectors    string      `json:"vectors"`
+	Types      int         `json:"types"`      // distinct corpus words without a vector
+	TypeRate   float64     `json:"type_rate"`  // Types over the corpus vocabulary
+	Tokens     int64       `json:"tokens"`     // corpus tokens without a vector
+	TokenRate  float64     `json:"token_rate"` // Tokens over all corpus tokens
+	TopMissing []WordCount `json:"top_missing"`
+}
+
+type StatsOptions struct {
+	MinCounts []int  // thresholds to report the vocabulary size at, defaults to 1, 2, 5, 10, 20, 50 and 100
+	Top       int    // most frequent words to list, defaults to 20
+	Vectors   string // vector file to report the out of vocabulary rate against, if any
+}
+
+// AnalyzeCorpus counts the tokens, vocabulary and sentences of the corpus at inputPath with the
+// tokenization Train uses
+func AnalyzeCorpus(inputPath string, opts StatsOptions, ctx context.Context) (stats *CorpusStats, err error) {
+	defer metrics.Track(metrics.Vectorize, "analyze_corpus")(&err)
+	_, span := tracing.Start(ctx, "vectorize.AnalyzeCorpus", attribute.String("vectorize.input", inputPath))
+	defer tracing.End(span, &err)
+
+	if len(opts.MinCounts) == 0 {
+		opts.MinCounts = []int{1, 2, 5, 10, 20, 50, 100}
+	}
+	if opts.Top <= 0 {
+		opts.Top = 20
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		if stdErrors.Is(err, os.ErrNotExist) {
+			return nil, errors.FileNotFound(inputPath, err)
+		}
+		return nil, errors.FileLoadingError(inputPath, err)
+	}
+	defer input.Close()
+
+	stats = &CorpusStats{Path: inputPath}
+	counts := map[string]int64{}
+	// Tokenize can't be stopped from its callback, so the reads under it fail once ctx is cancelled
+	err = word2vec.Tokenize(&contextReader{r: input, ctx: ctx}, func(word string, lineEnd bool) {
+		counts[word]++
+		stats.Tokens++
+		if lineEnd {
+			stats.Sentences++
+		}
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.FileLoadingError(inputPath, err)
+	}
+	if stats.Tokens == 0 {
+		return nil, errors.FileEmpty(inputPath, stdErrors.New("no words in corpus"))
+	}
+
+	ranked := rankWords(counts, stats.Tokens)
+	stats.Vocabulary = len(ranked)
+	stats.AvgSentenceLength = float64(stats.Tokens) / float64(stats.Sentences)
+	stats.TopWords = ranked[:min(opts.Top, len(ranked))]
+	stats.MinCounts = minCountStats(ranked, opts.MinCounts, stats.Tokens)
+	for rank := 1; rank <= len(ranked); rank = nextZipfRank(rank) {
+		stats.Zipf = append(stats.Zipf, ranked[rank-1])
+	}
+	stats.ZipfExponent = zipfExponent(ranked)
+
+	if opts.Vectors != "" {
+		if stats.OOV, err = oovStats(ranked, opts.Vectors, stats.Tokens, opts.Top); err != nil {
+			return nil, err
+		}
+	}
+	logger.Info("analyzed corpus", "input", inputPath, "tokens", stats.Tokens, "vocabulary", stats.Vocabulary)
+	return stats, nil
+}
+
+// contextReader is r until ctx is cancelled, then every read fails with ctx.Err()
+type contextReader struct {
+	r   io.Reader
+	ctx context.Context
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// rankWords sorts words by descending count, ties in alphabetical order
+func rankWords(counts map[string]int64, tokens int64) []WordCount {
+	ranked := make([]WordCount, 0, len(counts))
+	for word, count := range counts {
+		ranked = append(ranked, WordCount{Word: word, Count: count, Frequency: float64(count) / float64(tokens)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Word < ranked[j].Word
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked
+}
+
+func minCountStats(ranked []WordCount, thresholds []int, tokens int64) []MinCountStats {
+	thresholds = append([]int(nil), thresholds...)
+	sort.Ints(thresholds)
+	stats := make([]MinCountStats, len(thresholds))
+	for i, threshold := range thresholds {
+		stats[i].MinCount = threshold
+		var covered int64
+		// ranked is in descending count order, so the kept words are a prefix
+		for _, wc := range ranked {
+			if wc.Count < int64(threshold) {
+				break
+			}
+			stats[i].Vocabulary++
+			covered += wc.Count
+		}
+		stats[i].Coverage = float64(covered) / float64(tokens)
+	}
+	return stats
+}
+
+// nextZipfRank steps through 1, 2, 5, 10, 20, 50, ...
+func nextZipfRank(rank int) int {
+	if strconv.Itoa(rank)[0] == '2' {
+		return rank / 2 * 5
+	}
+	return rank * 2
+}
+
+// zipfExponent fits log(count) = c - s*log(rank) by least squares and returns s
+func zipfExponent(ranked []WordCount) float64 {
+	if len(ranked) < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXX, sumXY float64
+	for _, wc := range ranked {
+		x, y := math.Log(float64(wc.Rank)), math.Log(float64(wc.Count))
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(ranked))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return -(n*sumXY - sumX*sumY) / denominator
+}
+
+func oovStats(ranked []WordCount, vectorsPath string, tokens int64, top int) (*OOVStats, error) {
+	embs, err := embeddings.Load(vectorsPath)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(embs))
+	for _, e := range embs {
+		known[e.Word] = true
+	}
+
+	oov := &OOVStats{Vectors: vectorsPath, TopMissing: []WordCount{}}
+	for _, wc := range ranked {
+		if known[wc.Word] {
+			continue
+		}
+		oov.Types++
+		oov.Tokens += wc.Count
+		if len(oov.TopMissing) < top {
+			oov.TopMissing = append(oov.TopMissing, wc)
+		}
+	}
+	oov.TypeRate = float64(oov.Types) / float64(len(ranked))
+	oov.TokenRate = float64(oov.Tokens) / float64(tokens)
+	return oov, nil
+}
@@ -0,0 +1,66 @@
+package vectorize
+
+import (
+	"context"
+	stdErrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"milvus/errors"
+)
+
+func TestAnalyzeCorpus(t *testing.T) {
+	corpus := filepath.Join(t.TempDir(), "corpus.txt")
+	if err := os.WriteFile(corpus, []byte("cat cat cat dog\n\ncat dog zebra\ncat"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := AnalyzeCorpus(corpus, StatsOptions{MinCounts: []int{2, 1}, Top: 2, Vectors: validModelPath}, context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Tokens != 8 || stats.Vocabulary != 3 || stats.Sentences != 3 {
+		t.Errorf("tokens, vocabulary, sentences = %d, %d, %d, want 8, 3, 3", stats.Tokens, stats.Vocabulary, stats.Sentences)
+	}
+	if len(stats.TopWords) != 2 || stats.TopWords[0].Word != "cat" || stats.TopWords[0].Count != 5 || stats.TopWords[1].Word != "dog" {
+		t.Errorf("top words = %+v", stats.TopWords)
+	}
+	if len(stats.MinCounts) != 2 || stats.MinCounts[0].MinCount != 1 || stats.MinCounts[0].Coverage != 1 ||
+		stats.MinCounts[1].Vocabulary != 2 || stats.MinCounts[1].Coverage != 7.0/8 {
+		t.Errorf("min counts = %+v", stats.MinCounts)
+	}
+	if len(stats.Zipf) != 2 || stats.Zipf[1].Rank != 2 || stats.ZipfExponent <= 0 {
+		t.Errorf("zipf = %+v, exponent %f", stats.Zipf, stats.ZipfExponent)
+	}
+	if stats.OOV == nil || stats.OOV.Types != 1 || stats.OOV.Tokens != 1 || stats.OOV.TopMissing[0].Word != "zebra" {
+		t.Errorf("oov = %+v", stats.OOV)
+	}
+
+	if _, err := AnalyzeCorpus(emptyInputPath, StatsOptions{}, context.Background()); !stdErrors.Is(err, errors.ErrFileEmpty) {
+		t.Errorf("expected an empty file error, got %v", err)
+	}
+	if _, err := AnalyzeCorpus(unknownPath, StatsOptions{}, context.Background()); !stdErrors.Is(err, errors.ErrFileNotFound) {
+		t.Errorf("expected a file not found error, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AnalyzeCorpus(corpus, StatsOptions{}, ctx); !stdErrors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled error, got %v", err)
+	}
+}
+
+func TestNextZipfRank(t *testing.T) {
+	var ranks []int
+	for rank := 1; rank <= 1000; rank = nextZipfRank(rank) {
+		ranks = append(ranks, rank)
+	}
+	want := []int{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+	if len(ranks) != len(want) {
+		t.Fatalf("ranks = %v, want %v", ranks, want)
+	}
+	for i := range want {
+		if ranks[i] != want[i] {
+			t.Fatalf("ranks = %v, want %v", ranks, want)
+		}
+	}
+}
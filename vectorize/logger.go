@@ -0,0 +1,14 @@
+package vectorize
+
+import (
+	"log/slog"
+
+	"milvus/logging"
+)
+
+var logger = logging.Discard
+
+// SetLogger makes vectorize log through l, nil silences it again (the default). Call it before using the package.
+func SetLogger(l *slog.Logger) {
+	logger = logging.OrDiscard(l)
+}
@@ -0,0 +1,66 @@
+package vectorize
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"milvus/errors"
+	"milvus/registry"
+)
+
+// TrainModel trains like TrainWithOptions and registers the vectors as the next version of name in
+// reg, with a manifest of the hyperparameters, corpus hash, vocabulary size, dimension and training time
+func TrainModel(inputPath string, reg *registry.Registry, name string, opts TrainOptions, ctx context.Context) (registry.Manifest, error) {
+	if _, _, err := registry.ParseRef(name); err != nil {
+		return registry.Manifest{}, err
+	}
+	tmp, err := os.CreateTemp(reg.Root(), ".train-*.txt")
+	if err != nil {
+		return registry.Manifest{}, errors.FileCreationErr(reg.Root(), err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	start := time.Now()
+	result, err := train(inputPath, tmp.Name(), opts, ctx)
+	if err != nil {
+		return registry.Manifest{}, err
+	}
+	elapsed := time.Since(start)
+
+	hash, err := registry.HashFile(inputPath)
+	if err != nil {
+		return registry.Manifest{}, err
+	}
+	corpus, err := filepath.Abs(inputPath)
+	if err != nil {
+		corpus = inputPath
+	}
+	manifest, err := reg.Register(registry.Manifest{
+		Name:            name,
+		Algorithm:       "word2vec",
+		Hyperparameters: result.params,
+		Corpus:          corpus,
+		CorpusSHA256:    hash,
+		Parent:          opts.From,
+		VocabSize:       result.words,
+		Dimension:       result.dim,
+		TrainingSeconds: elapsed.Seconds(),
+	}, tmp.Name())
+	if err != nil {
+		return registry.Manifest{}, errors.TrainingFailed(errors.StageSave, reg.Root(), err)
+	}
+	logger.Info("registered model", "model", manifest.Ref(), "words", manifest.VocabSize, "dim", manifest.Dimension)
+	return manifest, nil
+}
+
+// QueryModel is QueryVector against a registered model, e.g. "words" or "words@2"
+func QueryModel(word string, reg *registry.Registry, ref string) error {
+	path, err := reg.Path(ref)
+	if err != nil {
+		return err
+	}
+	return QueryVector(word, path)
+}
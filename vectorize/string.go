@@ -1,92 +1,235 @@
 package vectorize
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"os"
+	"time"
 
 	stdErrors "errors"
+	"milvus/atomicfile"
+	"milvus/embeddings"
 	"milvus/errors"
-
-	"github.com/ynqa/wego/pkg/model/modelutil/vector"
-	"github.com/ynqa/wego/pkg/model/word2vec"
+	"milvus/hnsw"
+	"milvus/metrics"
+	"milvus/tracing"
+	"milvus/word2vec"
 
 	"github.com/ynqa/wego/pkg/embedding"
 	"github.com/ynqa/wego/pkg/search"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Progress is a snapshot of a training run, passed to the progress func of TrainWithProgress
+type Progress = word2vec.Progress
+
+// Train trains word2vec vectors on the corpus at inputPath and writes them to outputPath. The
+// output is replaced atomically: if opening the corpus, training or saving fails, Train returns a
+// *errors.TrainingError naming that stage and outputPath keeps its previous contents.
 func Train(inputPath string, outputPath string) error {
-	fmt.Printf("Training data from : %s\n", inputPath)
+	return TrainWithProgress(inputPath, outputPath, nil, context.Background())
+}
+
+// TrainWithProgress is Train that stops when ctx is cancelled, returning a train stage error that
+// wraps ctx.Err(), and calls progress, if not nil, as training goes and at the end of every epoch
+func TrainWithProgress(inputPath string, outputPath string, progress func(Progress), ctx context.Context) error {
+	return TrainWithOptions(inputPath, outputPath, TrainOptions{Progress: progress}, ctx)
+}
+
+type TrainOptions struct {
+	Model    word2vec.Options // zero value uses wego's defaults, or word2vec.DefaultOptions() with checkpoints
+	Progress func(Progress)
+
+	// CheckpointPath, if set, is where the training state is saved at the end of every epoch and
+	// every CheckpointInterval in between, so a run that dies can be resumed. wego can't save or
+	// restore its state, so checkpointed and continued runs are trained by the word2vec package
+	// instead, on a single goroutine.
+	CheckpointPath     string
+	CheckpointInterval time.Duration
+	// Resume picks the run up from CheckpointPath if it exists instead of starting over
+	Resume bool
+	// From is a checkpoint or vector file to continue training on the corpus, adding its new words
+	// to the vocabulary, instead of training from scratch
+	From string
+}
+
+// TrainWithOptions is TrainWithProgress with checkpoints, resuming and continued training
+func TrainWithOptions(inputPath string, outputPath string, opts TrainOptions, ctx context.Context) error {
+	_, err := train(inputPath, outputPath, opts, ctx)
+	return err
+}
+
+// trained describes the model a run trained, for a registry manifest
+type trained struct {
+	params map[string]string
+	words  int
+	dim    int
+}
+
+func train(inputPath string, outputPath string, opts TrainOptions, ctx context.Context) (result trained, err error) {
+	defer metrics.Track(metrics.Vectorize, "train")(&err)
+	ctx, span := tracing.Start(ctx, "vectorize.Train",
+		attribute.String("vectorize.input", inputPath), attribute.String("vectorize.output", outputPath))
+	defer tracing.End(span, &err)
+	logger.Info("training word vectors", "input", inputPath)
 
 	fileInfo, err := os.Stat(inputPath)
 	if err != nil {
 		if stdErrors.Is(err, os.ErrNotExist) {
-			return errors.FileNotFound(inputPath, err) // here 'errors' refers to your custom package
+			return trained{}, errors.TrainingFailed(errors.StageOpen, inputPath, errors.FileNotFound(inputPath, err)) // here 'errors' refers to your custom package
 		}
-		return errors.FileLoadingError(inputPath, err)
+		return trained{}, errors.TrainingFailed(errors.StageOpen, inputPath, errors.FileLoadingError(inputPath, err))
 	}
 
 	// Check if the input file is empty.
 	if fileInfo.Size() == 0 {
-		return errors.FileEmpty(inputPath, stdErrors.New("Empty File"))
+		return trained{}, errors.TrainingFailed(errors.StageOpen, inputPath, errors.FileEmpty(inputPath, stdErrors.New("Empty File")))
 	}
 
-	model, err := word2vec.New(
-		word2vec.Window(5),
-		word2vec.Model(word2vec.Cbow),
-		word2vec.Optimizer(word2vec.NegativeSampling),
-		word2vec.NegativeSampleSize(5),
-	)
-	if err != nil {
-		fmt.Printf("Failed to Load model: %s\n", err)
-		return errors.ModelLoadingError(inputPath, err)
+	if opts.CheckpointPath == "" && opts.From == "" {
+		if result, err = trainWego(inputPath, outputPath, opts, ctx); err != nil {
+			return trained{}, err
+		}
+		logger.Info("trained and saved model", "input", inputPath, "output", outputPath)
+		return result, nil
+	}
 
+	model, resume, err := openModel(inputPath, opts)
+	if err != nil {
+		return trained{}, err
+	}
+	if opts.CheckpointPath != "" {
+		model.CheckpointEvery(opts.CheckpointInterval, func(m *word2vec.Model) error {
+			if err := atomicfile.Write(opts.CheckpointPath, m.WriteCheckpoint); err != nil {
+				return errors.TrainingFailed(errors.StageSave, opts.CheckpointPath, err)
+			}
+			logger.Debug("saved checkpoint", "checkpoint", opts.CheckpointPath, "epoch", m.Epoch())
+			return nil
+		})
 	}
 
-	input, _ := os.Open(inputPath)
+	input, err := os.Open(inputPath)
 	if err != nil {
-		return errors.FileLoadingError(inputPath, err)
+		return trained{}, errors.TrainingFailed(errors.StageOpen, inputPath, errors.FileLoadingError(inputPath, err))
 	}
 	defer input.Close()
-	if err = model.Train(input); err != nil {
-		// failed to train.
-		fmt.Printf("Error training model: %s\n", err)
+	switch {
+	case resume:
+		logger.Info("resuming training", "checkpoint", opts.CheckpointPath, "epoch", model.Epoch())
+		err = model.Resume(input, opts.Progress, ctx)
+	case opts.From != "":
+		logger.Info("continuing training", "from", opts.From, "words", len(model.Words()))
+		err = model.Continue(input, opts.Progress, ctx)
+	default:
+		err = model.Train(input, opts.Progress, ctx)
 	}
-
-	// write word vector to a file
-	output, err := os.Create(outputPath)
 	if err != nil {
-		return errors.FileCreationErr(inputPath, err)
+		var te *errors.TrainingError
+		if stdErrors.As(err, &te) {
+			return trained{}, err // a checkpoint could not be saved
+		}
+		return trained{}, errors.TrainingFailed(errors.StageTrain, inputPath, err)
+	}
+
+	// Save the trained model next to outputPath and only move it into place once it is complete,
+	// so a failed run never leaves a truncated vector file behind
+	if err = atomicfile.Write(outputPath, func(w io.Writer) error {
+		return model.Save(w)
+	}); err != nil {
+		return trained{}, errors.TrainingFailed(errors.StageSave, outputPath, err)
 	}
-	defer output.Close()
 
-	// Save Trained Model to Disk
-	model.Save(output, vector.Agg)
+	logger.Info("trained and saved model", "input", inputPath, "output", outputPath)
 
-	fmt.Printf("Successfully trained and saved model to %s\n", outputPath)
+	return trained{params: model.Options().Params(), words: len(model.Words()), dim: model.Options().Dim}, nil
+}
 
+func QueryVector(word string, inputPath string) (err error) {
+	defer metrics.Track(metrics.Vectorize, "query_vector")(&err)
+	_, span := tracing.Start(context.Background(), "vectorize.QueryVector", attribute.String("vectorize.word", word))
+	defer tracing.End(span, &err)
+	searcher, err := loadSearcher(inputPath)
+	if err != nil {
+		return err
+	}
+	neighbors, err := searcher.SearchInternal(word, 10)
+	if err != nil {
+		return errors.ModelSearchError(inputPath, err)
+	}
+	metrics.Results(metrics.Vectorize, "query_vector", len(neighbors))
+	for i, n := range neighbors {
+		logger.Info("similar word", "word", word, "rank", i+1, "neighbor", n.Word, "similarity", n.Similarity)
+	}
 	return nil
 }
 
-func QueryVector(word string, inputPath string) error {
-	fmt.Printf("Querying similarity for word: %s\n", word)
+type Neighbor = embeddings.Neighbor
 
-	input, err := os.Open(inputPath)
+// SimilarWords returns the k words closest to word in the vector file, by cosine similarity
+func SimilarWords(word string, inputPath string, k int) (results []Neighbor, err error) {
+	defer metrics.Track(metrics.Vectorize, "similar_words")(&err)
+	_, span := tracing.Start(context.Background(), "vectorize.SimilarWords", attribute.String("vectorize.word", word), tracing.TopK(k))
+	defer tracing.End(span, &err)
+	searcher, err := loadSearcher(inputPath)
 	if err != nil {
-		return errors.FileCreationErr(inputPath, err)
+		return nil, err
 	}
-	defer input.Close()
-	embs, err := embedding.Load(input)
+	neighbors, err := searcher.SearchInternal(word, k)
 	if err != nil {
-		return errors.FileLoadingError(inputPath, err)
+		return nil, errors.ModelSearchError(inputPath, err)
 	}
-	searcher, err := search.New(embs...)
+	results = make([]Neighbor, len(neighbors))
+	for i, n := range neighbors {
+		results[i] = Neighbor{Word: n.Word, Similarity: n.Similarity}
+	}
+	metrics.Results(metrics.Vectorize, "similar_words", len(results))
+	return results, nil
+}
+
+func loadSearcher(inputPath string) (*search.Searcher, error) {
+	// Any supported format works here - wego text, GloVe, fastText, word2vec binary, npy or float16
+	embs, err := embeddings.Load(inputPath)
 	if err != nil {
-		return errors.ModelSearchError(inputPath, err)
+		return nil, err
 	}
-	neighbors, err := searcher.SearchInternal(word, 10)
+	if len(embs) > 0 {
+		metrics.Dimension(metrics.Vectorize, "load", len(embs[0].Vector))
+	}
+	searcher, err := search.New(toWego(embs)...)
 	if err != nil {
-		return errors.ModelSearchError(inputPath, err)
+		return nil, errors.ModelSearchError(inputPath, err)
+	}
+	return searcher, nil
+}
+
+// QueryIndex is QueryVector against a persisted HNSW index instead of a brute force search over the vector file
+func QueryIndex(word string, indexPath string) (err error) {
+	defer metrics.Track(metrics.Vectorize, "query_index")(&err)
+	_, span := tracing.Start(context.Background(), "vectorize.QueryIndex", attribute.String("vectorize.word", word))
+	defer tracing.End(span, &err)
+	idx, err := hnsw.Load(indexPath)
+	if err != nil {
+		return err
+	}
+	neighbors, err := idx.SearchWord(word, 10)
+	if err != nil {
+		return errors.ModelSearchError(indexPath, err)
+	}
+	metrics.Results(metrics.Vectorize, "query_index", len(neighbors))
+	for i, n := range neighbors {
+		logger.Info("similar word", "word", word, "rank", i+1, "neighbor", n.Word, "score", n.Score)
 	}
-	neighbors.Describe()
 	return nil
 }
+
+func toWego(embs []embeddings.Embedding) embedding.Embeddings {
+	converted := make(embedding.Embeddings, len(embs))
+	for i, e := range embs {
+		vector := make([]float64, len(e.Vector))
+		for j, v := range e.Vector {
+			vector[j] = float64(v)
+		}
+		converted[i] = embedding.Embedding{Word: e.Word, Dim: len(vector), Vector: vector}
+	}
+	return converted
+}
@@ -1,9 +1,16 @@
 package vectorize
 
 import (
+	"context"
+	stdErrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"milvus/embeddings"
 	"milvus/errors"
+	"milvus/registry"
 )
 
 const (
@@ -44,6 +51,22 @@ func TestTrain(t *testing.T) {
 				return errors.IsFileError(err, "FileEmpty")
 			},
 		},
+		{
+			name:       "Invalid Input - Passing a directory",
+			inputPath:  "../tests/mockdata",
+			outputPath: filepath.Join(os.TempDir(), "vectorize-train-test.txt"),
+			expectedErrFn: func(err error) bool {
+				return isTrainingError(err, errors.StageTrain)
+			},
+		},
+		{
+			name:       "Invalid Output - Missing directory",
+			inputPath:  validInputPath,
+			outputPath: unknownPath,
+			expectedErrFn: func(err error) bool {
+				return isTrainingError(err, errors.StageSave) && errors.IsFileError(err, "FileCreationError")
+			},
+		},
 		// Add more test cases as needed
 	}
 
@@ -57,6 +80,149 @@ func TestTrain(t *testing.T) {
 	}
 }
 
+func isTrainingError(err error, stage string) bool {
+	var te *errors.TrainingError
+	return stdErrors.As(err, &te) && te.Stage == stage
+}
+
+func TestTrainKeepsOutputOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "word_vector.txt")
+	if err := os.WriteFile(outputPath, []byte("previous vectors\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Train("../tests/mockdata", outputPath); !stdErrors.Is(err, errors.ErrTraining) {
+		t.Fatalf("expected a training error, got %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil || string(data) != "previous vectors\n" {
+		t.Errorf("output was modified by a failed run: %q, %v", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files were left behind: %v", entries)
+	}
+
+	if err := Train(validInputPath, outputPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(outputPath); string(data) == "previous vectors\n" || len(data) == 0 {
+		t.Error("output was not replaced by a successful run")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files were left behind: %v", entries)
+	}
+}
+
+func TestTrainWithProgress(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "vectors.txt")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := TrainWithProgress(validInputPath, outputPath, nil, ctx); !isTrainingError(err, errors.StageTrain) || !stdErrors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled training error, got %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("a cancelled run wrote its output: %v", err)
+	}
+
+	var last Progress
+	if err := TrainWithProgress(validInputPath, outputPath, func(p Progress) { last = p }, context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last.Epoch != last.Epochs || last.TotalWords == 0 || last.Words != last.TotalWords {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func TestTrainWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	corpusPath := filepath.Join(dir, "corpus.txt")
+	corpus := strings.Repeat("english hindi dog cat sanskrit memes lithuania darwin german italian ", 300)
+	if err := os.WriteFile(corpusPath, []byte(corpus), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "vectors.txt")
+	checkpointPath := filepath.Join(dir, "train.ckpt")
+
+	// Stop once the second epoch is under way, so at least the first one was checkpointed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := TrainOptions{
+		CheckpointPath: checkpointPath,
+		Progress: func(p Progress) {
+			if p.Epoch == 2 {
+				cancel()
+			}
+		},
+	}
+	if err := TrainWithOptions(corpusPath, outputPath, opts, ctx); !isTrainingError(err, errors.StageTrain) || !stdErrors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled training error, got %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("a cancelled run wrote its output: %v", err)
+	}
+	model, err := LoadCheckpoint(checkpointPath)
+	if err != nil || model.Epoch() < 2 || model.Done() {
+		t.Fatalf("checkpoint is at epoch %d, %v", model.Epoch(), err)
+	}
+
+	var epochs []int
+	opts.Resume = true
+	opts.Progress = func(p Progress) { epochs = append(epochs, p.Epoch) }
+	if err := TrainWithOptions(corpusPath, outputPath, opts, context.Background()); err != nil {
+		t.Fatalf("unexpected error resuming: %v", err)
+	}
+	if epochs[0] != model.Epoch() || epochs[len(epochs)-1] != model.Options().Iter {
+		t.Errorf("resumed run went through epochs %v", epochs)
+	}
+	if model, err := LoadCheckpoint(checkpointPath); err != nil || !model.Done() {
+		t.Errorf("final checkpoint is not done: %v", err)
+	}
+
+	// Continue the fixture's vectors on a corpus with two new words
+	if err := os.WriteFile(corpusPath, []byte(strings.Repeat("zebra cat dog giraffe ", 50)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	continued := filepath.Join(dir, "continued.txt")
+	if err := TrainWithOptions(corpusPath, continued, TrainOptions{From: validModelPath}, context.Background()); err != nil {
+		t.Fatalf("unexpected error continuing: %v", err)
+	}
+	embs, err := embeddings.Load(continued)
+	if err != nil || len(embs) != 22 || embs[20].Word != "zebra" || embs[21].Word != "giraffe" {
+		t.Errorf("continued model has %d words, %v", len(embs), err)
+	}
+
+	if err := TrainWithOptions(corpusPath, continued, TrainOptions{From: emptyInputPath}, context.Background()); !isTrainingError(err, errors.StageOpen) {
+		t.Errorf("expected an open stage error for an empty model, got %v", err)
+	}
+}
+
+func TestTrainModel(t *testing.T) {
+	reg, err := registry.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for version := 1; version <= 2; version++ {
+		manifest, err := TrainModel(validInputPath, reg, "animals", TrainOptions{}, context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if manifest.Version != version || manifest.VocabSize != 20 || manifest.Dimension != 10 ||
+			manifest.Algorithm != "word2vec" || manifest.Hyperparameters["window"] != "5" || len(manifest.CorpusSHA256) != 64 {
+			t.Errorf("manifest = %+v", manifest)
+		}
+	}
+	if err := QueryModel("cat", reg, "animals@2"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := QueryModel("cat", reg, "animals@3"); !stdErrors.Is(err, errors.ErrModelLoading) {
+		t.Errorf("expected a model loading error, got %v", err)
+	}
+	if _, err := TrainModel(validInputPath, reg, "../animals", TrainOptions{}, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for a bad name, got %v", err)
+	}
+}
+
 func TestQueryVector(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -0,0 +1,183 @@
+package vectorize
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"milvus/atomicfile"
+	"milvus/errors"
+	"milvus/word2vec"
+
+	"github.com/ynqa/wego/pkg/model/modelutil/vector"
+	wego "github.com/ynqa/wego/pkg/model/word2vec"
+)
+
+// wegoDefaults are the options wego trains with unless TrainOptions.Model says otherwise
+var wegoDefaults = word2vec.Options{Dim: 10, Window: 5, Model: word2vec.Cbow, Negative: 5, Iter: 15, MinCount: 5, InitLR: 0.025, Subsample: 1e-3}
+
+// trainWego trains a run without checkpoints on wego's multi-threaded trainer. wego takes no
+// context and reports no progress, so both go through the corpus reader it is given.
+func trainWego(inputPath string, outputPath string, opts TrainOptions, ctx context.Context) (trained, error) {
+	modelOpts := opts.Model
+	if modelOpts == (word2vec.Options{}) {
+		modelOpts = wegoDefaults
+	}
+	if err := modelOpts.Validate(); err != nil {
+		return trained{}, errors.ModelLoadingError(inputPath, err)
+	}
+	modelType := wego.Cbow
+	if modelOpts.Model == word2vec.SkipGram {
+		modelType = wego.SkipGram
+	}
+	model, err := wego.New(
+		wego.Dim(modelOpts.Dim),
+		wego.Window(modelOpts.Window),
+		wego.Model(modelType),
+		wego.Optimizer(wego.NegativeSampling),
+		wego.NegativeSampleSize(modelOpts.Negative),
+		wego.Iter(modelOpts.Iter),
+		wego.MinCount(modelOpts.MinCount),
+		wego.Initlr(modelOpts.InitLR),
+		wego.SubsampleThreshold(modelOpts.Subsample),
+	)
+	if err != nil {
+		return trained{}, errors.ModelLoadingError(inputPath, err)
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return trained{}, errors.TrainingFailed(errors.StageOpen, inputPath, errors.FileLoadingError(inputPath, err))
+	}
+	defer input.Close()
+	corpus := newCorpusReader(input, modelOpts, opts.Progress, ctx)
+	err = model.Train(corpus)
+	if ctx.Err() != nil {
+		// wego may stop on the failed read without passing its error on
+		err = ctx.Err()
+	}
+	if err != nil {
+		return trained{}, errors.TrainingFailed(errors.StageTrain, inputPath, err)
+	}
+	corpus.finish()
+
+	words := 0
+	if err = atomicfile.Write(outputPath, func(w io.Writer) error {
+		lines := &lineCounter{w: w}
+		err := model.Save(lines, vector.Agg)
+		words = lines.n
+		return err
+	}); err != nil {
+		return trained{}, errors.TrainingFailed(errors.StageSave, outputPath, err)
+	}
+
+	params := modelOpts.Params()
+	delete(params, "seed") // wego doesn't take one
+	return trained{params: params, words: words, dim: modelOpts.Dim}, nil
+}
+
+// corpusReader is the corpus as wego reads it: once to build the vocabulary, then again from the
+// start for every epoch. Reads fail once ctx is cancelled, which stops training, and the words read
+// in the epochs are reported to progress every word2vec.ReportInterval and at the end of each.
+type corpusReader struct {
+	io.ReadSeeker
+	ctx      context.Context
+	opts     word2vec.Options
+	progress func(Progress)
+
+	pass       int   // 0 while wego builds the vocabulary, then the epoch
+	passWords  int64 // words read in this pass
+	epochWords int64 // words of the corpus, known once the vocabulary pass is done
+	inWord     bool
+	start      time.Time
+	lastReport time.Time
+}
+
+func newCorpusReader(r io.ReadSeeker, opts word2vec.Options, progress func(Progress), ctx context.Context) *corpusReader {
+	now := time.Now()
+	return &corpusReader{ReadSeeker: r, ctx: ctx, opts: opts, progress: progress, start: now, lastReport: now}
+}
+
+func (c *corpusReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.ReadSeeker.Read(p)
+	for _, b := range p[:n] {
+		space := b == ' ' || b == '\n' || b == '\t' || b == '\r' || b == '\v' || b == '\f'
+		if !space && !c.inWord {
+			c.passWords++
+		}
+		c.inWord = !space
+	}
+	if c.pass > 0 && time.Since(c.lastReport) >= word2vec.ReportInterval {
+		c.report()
+	}
+	return n, err
+}
+
+// Seek starts the next pass when wego goes back to the start of the corpus
+func (c *corpusReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := c.ReadSeeker.Seek(offset, whence)
+	if err != nil || pos != 0 || c.passWords == 0 {
+		return pos, err
+	}
+	if c.pass == 0 {
+		c.epochWords = c.passWords
+		c.start = time.Now()
+	} else {
+		c.report()
+	}
+	c.pass++
+	c.passWords, c.inWord = 0, false
+	return pos, nil
+}
+
+// finish reports the end of the run
+func (c *corpusReader) finish() {
+	if c.epochWords == 0 {
+		// wego kept the corpus in memory after the vocabulary pass
+		c.epochWords = c.passWords
+	}
+	c.pass, c.passWords = c.opts.Iter, c.epochWords
+	c.report()
+}
+
+func (c *corpusReader) report() {
+	if c.progress == nil {
+		return
+	}
+	c.lastReport = time.Now()
+	epoch := min(max(c.pass, 1), c.opts.Iter)
+	p := Progress{
+		Epoch:      epoch,
+		Epochs:     c.opts.Iter,
+		Words:      int64(epoch-1)*c.epochWords + min(c.passWords, c.epochWords),
+		TotalWords: c.epochWords * int64(c.opts.Iter),
+		Elapsed:    c.lastReport.Sub(c.start),
+	}
+	// wego decays the learning rate linearly over the run, down to 1e-4 of where it started
+	p.LearningRate = c.opts.InitLR * max(1-p.Fraction(), 1e-4)
+	if seconds := p.Elapsed.Seconds(); seconds > 0 && p.Words > 0 {
+		p.WordsPerSec = float64(p.Words) / seconds
+		p.ETA = time.Duration(float64(p.TotalWords-p.Words) / p.WordsPerSec * float64(time.Second))
+	}
+	c.progress(p)
+}
+
+// lineCounter counts the lines written through it, one per word for wego's vector files
+type lineCounter struct {
+	w io.Writer
+	n int
+}
+
+func (l *lineCounter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	for _, b := range p[:n] {
+		if b == '\n' {
+			l.n++
+		}
+	}
+	return n, err
+}
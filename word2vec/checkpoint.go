@@ -0,0 +1,85 @@
+package word2vec
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"milvus/errors"
+)
+
+// checkpointMagic starts every checkpoint, so vector files and other data are told apart
+const checkpointMagic = "word2vec-checkpoint\n"
+
+const checkpointVersion = 1
+
+// checkpoint is everything needed to resume training: options, vocabulary with counts, both sets
+// of vectors and how far the run got
+type checkpoint struct {
+	Version int
+	Options Options
+	Vocab   []string
+	Counts  []int64
+	In      []float32
+	Out     []float32
+	State   trainState
+}
+
+// WriteCheckpoint saves the model and its training position for ReadCheckpoint
+func (m *Model) WriteCheckpoint(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(checkpointMagic); err != nil {
+		return err
+	}
+	err := gob.NewEncoder(bw).Encode(checkpoint{
+		Version: checkpointVersion,
+		Options: m.opts,
+		Vocab:   m.vocab,
+		Counts:  m.counts,
+		In:      m.in,
+		Out:     m.out,
+		State:   m.state,
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadCheckpoint restores a model written by WriteCheckpoint, ready to Resume, Continue or Save.
+// Data that isn't a checkpoint is reported as a FileFormatError.
+func ReadCheckpoint(r io.Reader) (*Model, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != checkpointMagic {
+		return nil, errors.FileFormatError("checkpoint", fmt.Errorf("not a word2vec checkpoint"))
+	}
+	var ckpt checkpoint
+	if err := gob.NewDecoder(br).Decode(&ckpt); err != nil {
+		return nil, errors.FileFormatError("checkpoint", err)
+	}
+	if ckpt.Version != checkpointVersion {
+		return nil, errors.FileFormatError("checkpoint", fmt.Errorf("unsupported checkpoint version %d", ckpt.Version))
+	}
+
+	m, err := New(ckpt.Options)
+	if err != nil {
+		return nil, err
+	}
+	size := len(ckpt.Vocab) * ckpt.Options.Dim
+	if len(ckpt.Counts) != len(ckpt.Vocab) || len(ckpt.In) != size || len(ckpt.Out) != size {
+		return nil, errors.FileFormatError("checkpoint", fmt.Errorf("checkpoint has %d words but %d counts and %d/%d vector values", len(ckpt.Vocab), len(ckpt.Counts), len(ckpt.In), len(ckpt.Out)))
+	}
+	m.vocab, m.counts, m.in, m.out, m.state = ckpt.Vocab, ckpt.Counts, ckpt.In, ckpt.Out, ckpt.State
+	for i, word := range m.vocab {
+		m.index[word] = i
+		m.total += m.counts[i]
+	}
+	if len(m.vocab) > 0 {
+		m.buildSampler()
+	}
+	m.rng = rand.New(rand.NewSource(m.opts.Seed + m.state.Words))
+	return m, nil
+}
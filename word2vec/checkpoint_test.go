@@ -0,0 +1,162 @@
+package word2vec
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"milvus/errors"
+)
+
+func TestResume(t *testing.T) {
+	defer func(interval time.Duration) { ReportInterval = interval }(ReportInterval)
+	ReportInterval = 0
+
+	opts := DefaultOptions()
+	opts.Iter = 3
+	opts.Subsample = 0
+	model, _ := New(opts)
+
+	// Checkpoint after every sentence and stop a few sentences into the second epoch
+	var checkpoints []*bytes.Buffer
+	model.CheckpointEvery(time.Nanosecond, func(m *Model) error {
+		var buf bytes.Buffer
+		checkpoints = append(checkpoints, &buf)
+		return m.WriteCheckpoint(&buf)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := model.Train(corpus(1000), func(p Progress) {
+		if p.Epoch == 2 && p.Words > p.TotalWords/3+3000 {
+			cancel()
+		}
+	}, ctx)
+	if !stdErrors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	resumed, err := ReadCheckpoint(checkpoints[len(checkpoints)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Epoch() != 2 || resumed.state.Offset == 0 || resumed.Done() {
+		t.Fatalf("checkpoint is at epoch %d, offset %d", resumed.Epoch(), resumed.state.Offset)
+	}
+	startWords := resumed.state.Words
+
+	var reports []Progress
+	if err := resumed.Resume(corpus(1000), func(p Progress) { reports = append(reports, p) }, context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resumed.Done() {
+		t.Error("resumed run is not done")
+	}
+	if first := reports[0]; first.Epoch != 2 || first.Words <= startWords {
+		t.Errorf("resumed run started at %+v, want epoch 2 after %d words", first, startWords)
+	}
+	// Every corpus word is trained exactly once per epoch, before or after resuming
+	if last := reports[len(reports)-1]; last.Words != last.TotalWords || last.TotalWords != 3*8000 {
+		t.Errorf("resumed run ended at %+v", last)
+	}
+	if vector, ok := resumed.Vector("cat"); !ok || len(vector) != opts.Dim {
+		t.Errorf("Vector(cat) = %v, %v", vector, ok)
+	}
+
+	// A finished run has nothing left to resume
+	if err := resumed.Resume(strings.NewReader("different corpus"), nil, context.Background()); err != nil {
+		t.Errorf("resuming a finished run: %v", err)
+	}
+}
+
+func TestResumeOtherCorpus(t *testing.T) {
+	model, _ := New(DefaultOptions())
+	var buf bytes.Buffer
+	model.CheckpointEvery(0, func(m *Model) error {
+		if m.Epoch() == 2 {
+			buf.Reset()
+			return m.WriteCheckpoint(&buf)
+		}
+		return nil
+	})
+	if err := model.Train(corpus(100), nil, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := ReadCheckpoint(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.Resume(corpus(50), nil, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+func TestContinue(t *testing.T) {
+	model, _ := New(DefaultOptions())
+	if err := model.Train(corpus(200), nil, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	before := append([]string(nil), model.Words()...)
+	cat, _ := model.Vector("cat")
+
+	if err := model.Continue(strings.NewReader("zebra cat giraffe dog zebra lion"), nil, context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	words := model.Words()
+	if len(words) != len(before)+2 || words[len(words)-2] != "zebra" || words[len(words)-1] != "giraffe" {
+		t.Fatalf("vocabulary after continuing = %v", words)
+	}
+	for i, word := range before {
+		if words[i] != word {
+			t.Errorf("word %d changed from %q to %q", i, word, words[i])
+		}
+	}
+	if after, _ := model.Vector("cat"); cosine(cat, after) < 0.5 {
+		t.Errorf("continued training replaced the vector of cat: %v -> %v", cat, after)
+	}
+	if _, ok := model.Vector("zebra"); !ok {
+		t.Error("no vector for the new word zebra")
+	}
+}
+
+func TestFromVectors(t *testing.T) {
+	model, err := FromVectors([]string{"cat", "dog"}, [][]float32{{1, 0, 0}, {0, 1, 0}}, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Options().Dim != 3 || !model.Done() {
+		t.Fatalf("got dimension %d, done %v", model.Options().Dim, model.Done())
+	}
+	if err := model.Continue(strings.NewReader("cat dog puppy cat dog puppy"), nil, context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if words := model.Words(); len(words) != 3 || words[2] != "puppy" {
+		t.Errorf("vocabulary = %v", words)
+	}
+
+	if _, err := FromVectors([]string{"cat", "dog"}, [][]float32{{1, 0}, {0, 1, 0}}, DefaultOptions()); !stdErrors.Is(err, errors.ErrDimensionMismatch) {
+		t.Errorf("expected a dimension mismatch, got %v", err)
+	}
+}
+
+func TestReadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "Vector file", data: "cat 0.1 0.2\ndog 0.3 0.4\n"},
+		{name: "Empty", data: ""},
+		{name: "Truncated", data: checkpointMagic + "\x01\x02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadCheckpoint(strings.NewReader(tt.data)); !errors.IsFileError(err, "FileFormatError") {
+				t.Errorf("expected a FileFormatError, got %v", err)
+			}
+		})
+	}
+}
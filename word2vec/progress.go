@@ -0,0 +1,66 @@
+package word2vec
+
+import "time"
+
+// ReportInterval is how often training calls its progress func in the middle of an epoch
+var ReportInterval = 200 * time.Millisecond
+
+// Progress is a snapshot of a training run
+type Progress struct {
+	Epoch        int // current epoch, from 1
+	Epochs       int
+	Words        int64 // corpus words processed so far, over all epochs
+	TotalWords   int64 // corpus words the whole run processes
+	WordsPerSec  float64
+	LearningRate float64
+	Elapsed      time.Duration // since training started or was resumed
+	ETA          time.Duration // estimated time left, 0 until there is a rate to go by
+}
+
+// Fraction is how much of the run is done, from 0 to 1
+func (p Progress) Fraction() float64 {
+	if p.TotalWords == 0 {
+		return 0
+	}
+	return float64(p.Words) / float64(p.TotalWords)
+}
+
+// tracker hands out Progress, timing the words trained since it was created
+type tracker struct {
+	progress   func(Progress)
+	startWords int64
+	start      time.Time
+	lastReport time.Time
+}
+
+func newTracker(progress func(Progress), startWords int64) *tracker {
+	now := time.Now()
+	return &tracker{progress: progress, startWords: startWords, start: now, lastReport: now}
+}
+
+// tick reports if ReportInterval has passed since the last report
+func (t *tracker) tick(m *Model) {
+	if t.progress != nil && time.Since(t.lastReport) >= ReportInterval {
+		t.report(m)
+	}
+}
+
+func (t *tracker) report(m *Model) {
+	if t.progress == nil {
+		return
+	}
+	t.lastReport = time.Now()
+	p := Progress{
+		Epoch:        m.state.Epoch,
+		Epochs:       m.opts.Iter,
+		Words:        m.state.Words,
+		TotalWords:   m.state.EpochWords * int64(m.opts.Iter),
+		LearningRate: m.learningRate(),
+		Elapsed:      t.lastReport.Sub(t.start),
+	}
+	if seconds := p.Elapsed.Seconds(); seconds > 0 && p.Words > t.startWords {
+		p.WordsPerSec = float64(p.Words-t.startWords) / seconds
+		p.ETA = time.Duration(float64(p.TotalWords-p.Words) / p.WordsPerSec * float64(time.Second))
+	}
+	t.progress(p)
+}
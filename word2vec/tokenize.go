@@ -0,0 +1,72 @@
+package word2vec
+
+import (
+	"bufio"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineBreak is the token scanTokens returns for a newline; it can't be a word since words never
+// contain whitespace
+const lineBreak = "\n"
+
+// Tokenize calls fn with every word of r, split exactly the way training splits the corpus, and
+// with lineEnd set once after the last word of each line
+func Tokenize(r io.Reader, fn func(word string, lineEnd bool)) error {
+	scanner := newScanner(r)
+	pending := ""
+	for scanner.Scan() {
+		token := scanner.Text()
+		if token == lineBreak {
+			if pending != "" {
+				fn(pending, true)
+				pending = ""
+			}
+			continue
+		}
+		if pending != "" {
+			fn(pending, false)
+		}
+		pending = token
+	}
+	if pending != "" {
+		fn(pending, true)
+	}
+	return scanner.Err()
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// text8 style corpora are a single huge line, so split on words rather than lines
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(scanTokens)
+	return scanner
+}
+
+// scanTokens is bufio.ScanWords that also returns a lineBreak token for every newline
+func scanTokens(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) {
+		r, width := utf8.DecodeRune(data[start:])
+		if r == '\n' {
+			return start + width, []byte(lineBreak), nil
+		}
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += width
+	}
+	for i := start; i < len(data); {
+		r, width := utf8.DecodeRune(data[i:])
+		if unicode.IsSpace(r) {
+			// Leave the space for the next call, in case it is a newline
+			return i, data[start:i], nil
+		}
+		i += width
+	}
+	if atEOF && len(data) > start {
+		return len(data), data[start:], nil
+	}
+	return start, nil, nil
+}
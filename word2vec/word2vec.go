@@ -0,0 +1,535 @@
+// Package word2vec trains word vectors with word2vec, CBOW or skip-gram with negative sampling,
+// in pure Go. The corpus is streamed from an io.ReadSeeker once to build the vocabulary and once
+// per epoch to train, so it never has to fit in memory. Training reports its progress through a
+// callback and stops as soon as its context is cancelled.
+package word2vec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+type ModelType int
+
+const (
+	Cbow ModelType = iota
+	SkipGram
+)
+
+func (mt ModelType) String() string {
+	if mt == SkipGram {
+		return "skipgram"
+	}
+	return "cbow"
+}
+
+type Options struct {
+	Dim       int // vector dimension
+	Window    int // context words on each side of the target
+	Model     ModelType
+	Negative  int     // negative samples per target word
+	Iter      int     // epochs over the corpus
+	MinCount  int     // words seen fewer times are left out of the vocabulary
+	InitLR    float64 // learning rate, decayed linearly to InitLR * 1e-4 over the run
+	Subsample float64 // threshold for randomly skipping frequent words, 0 keeps every word
+	Seed      int64
+}
+
+// DefaultOptions match what vectorize.Train has always used: 10 dimensions, CBOW, a window of 5
+// and 5 negative samples, keeping every word of the corpus
+func DefaultOptions() Options {
+	return Options{Dim: 10, Window: 5, Model: Cbow, Negative: 5, Iter: 15, MinCount: 1, InitLR: 0.025, Subsample: 1e-3, Seed: 1}
+}
+
+// Params lists the options as strings, e.g. for a model registry manifest
+func (o Options) Params() map[string]string {
+	return map[string]string{
+		"dim":       strconv.Itoa(o.Dim),
+		"window":    strconv.Itoa(o.Window),
+		"model":     o.Model.String(),
+		"negative":  strconv.Itoa(o.Negative),
+		"iter":      strconv.Itoa(o.Iter),
+		"min_count": strconv.Itoa(o.MinCount),
+		"init_lr":   strconv.FormatFloat(o.InitLR, 'g', -1, 64),
+		"subsample": strconv.FormatFloat(o.Subsample, 'g', -1, 64),
+		"seed":      strconv.FormatInt(o.Seed, 10),
+	}
+}
+
+// Validate returns an errors.ErrValidation error for the first option that can't be trained with
+func (o Options) Validate() error {
+	switch {
+	case o.Dim <= 0:
+		return errors.Invalid("dim", "dimension must be positive, got %d", o.Dim)
+	case o.Window <= 0:
+		return errors.Invalid("window", "window must be positive, got %d", o.Window)
+	case o.Model != Cbow && o.Model != SkipGram:
+		return errors.Invalid("model", "unknown model type %d", o.Model)
+	case o.Negative <= 0:
+		return errors.Invalid("negative", "negative samples must be positive, got %d", o.Negative)
+	case o.Iter <= 0:
+		return errors.Invalid("iter", "epochs must be positive, got %d", o.Iter)
+	case o.InitLR <= 0:
+		return errors.Invalid("initlr", "learning rate must be positive, got %g", o.InitLR)
+	case o.Subsample < 0:
+		return errors.Invalid("subsample", "subsample threshold can't be negative, got %g", o.Subsample)
+	}
+	return nil
+}
+
+// maxSentence is how many words are trained together; context windows don't cross chunks
+const maxSentence = 1000
+
+// checkEvery is how many corpus words pass between cancellation and progress checks
+const checkEvery = 1000
+
+type Model struct {
+	opts Options
+
+	vocab  []string // in order of first appearance in the corpora
+	index  map[string]int
+	counts []int64
+	total  int64 // sum of counts, for subsampling
+
+	in  []float32 // word vectors, len(vocab) rows of opts.Dim
+	out []float32 // context vectors for negative sampling
+
+	cdf []float64 // cumulative unigram^0.75 distribution to draw negative samples from
+	rng *rand.Rand
+
+	state trainState
+
+	checkpointEvery time.Duration
+	checkpoint      func(*Model) error
+	lastCheckpoint  time.Time
+}
+
+// trainState is how far a run over a corpus has got, saved in checkpoints to resume from
+type trainState struct {
+	Epoch      int   // epoch being trained, from 1; opts.Iter+1 once the run is done
+	Offset     int64 // corpus words of Epoch already trained
+	Words      int64 // corpus words trained in the whole run
+	EpochWords int64 // corpus words per epoch that are in the vocabulary
+	CorpusSize int64 // bytes, to tell a checkpoint is resumed on the same corpus
+}
+
+// New returns an untrained model, or a validation error if opts are out of range
+func New(opts Options) (*Model, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.MinCount < 1 {
+		opts.MinCount = 1
+	}
+	return &Model{opts: opts, index: map[string]int{}, rng: rand.New(rand.NewSource(opts.Seed))}, nil
+}
+
+// FromVectors returns a model whose word vectors are vectors, to continue training word vectors
+// that were saved without a checkpoint. opts.Dim is taken from the vectors.
+func FromVectors(words []string, vectors [][]float32, opts Options) (*Model, error) {
+	if len(words) != len(vectors) {
+		return nil, errors.Invalid("vectors", "got %d words for %d vectors", len(words), len(vectors))
+	}
+	if len(vectors) > 0 {
+		opts.Dim = len(vectors[0])
+	}
+	m, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, word := range words {
+		if len(vectors[i]) != opts.Dim {
+			return nil, errors.DimensionMismatch(fmt.Sprintf("word %q", word), len(vectors[i]), opts.Dim)
+		}
+		if _, ok := m.index[word]; ok {
+			continue
+		}
+		// Saved vectors don't say how often a word was seen, so each counts as seen once
+		m.index[word] = len(m.vocab)
+		m.vocab = append(m.vocab, word)
+		m.counts = append(m.counts, 1)
+		m.total++
+		m.in = append(m.in, vectors[i]...)
+	}
+	m.out = make([]float32, len(m.in))
+	m.state.Epoch = opts.Iter + 1
+	return m, nil
+}
+
+func (m *Model) Options() Options {
+	return m.opts
+}
+
+// Words returns the vocabulary in the order the vectors are saved
+func (m *Model) Words() []string {
+	return m.vocab
+}
+
+// Epoch is the epoch training is in, from 1, and Done reports whether the run has finished
+func (m *Model) Epoch() int {
+	return m.state.Epoch
+}
+
+func (m *Model) Done() bool {
+	return m.state.Epoch > m.opts.Iter
+}
+
+// CheckpointEvery makes training call save with the model at the end of every epoch, every
+// interval in between (0 only checkpoints between epochs) and once the run is done, e.g. to
+// WriteCheckpoint it to disk. An error from save stops training.
+func (m *Model) CheckpointEvery(interval time.Duration, save func(*Model) error) {
+	m.checkpointEvery = interval
+	m.checkpoint = save
+}
+
+// Train builds the vocabulary from corpus and trains opts.Iter epochs over it. progress, if not
+// nil, is called from the training goroutine every ReportInterval and at the end of every epoch.
+// If ctx is cancelled Train returns ctx.Err() and the model is left half trained.
+func (m *Model) Train(corpus io.ReadSeeker, progress func(Progress), ctx context.Context) error {
+	m.vocab, m.index, m.counts, m.total = nil, map[string]int{}, nil, 0
+	m.in, m.out = nil, nil
+	return m.start(corpus, progress, ctx)
+}
+
+// Continue trains opts.Iter more epochs over corpus, adding the words it introduces (seen at least
+// opts.MinCount times) to the vocabulary with fresh vectors and keeping the vectors of the rest
+func (m *Model) Continue(corpus io.ReadSeeker, progress func(Progress), ctx context.Context) error {
+	return m.start(corpus, progress, ctx)
+}
+
+// Resume picks up a run restored with ReadCheckpoint where it stopped. corpus must be the one the
+// run was started on. Resuming a run that is done returns straight away.
+func (m *Model) Resume(corpus io.ReadSeeker, progress func(Progress), ctx context.Context) error {
+	if m.Done() {
+		return nil
+	}
+	size, err := corpus.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size != m.state.CorpusSize {
+		return errors.Invalid("corpus", "corpus is %d bytes but the checkpoint was trained on %d bytes", size, m.state.CorpusSize)
+	}
+	m.rng = rand.New(rand.NewSource(m.opts.Seed + m.state.Words))
+	return m.run(corpus, progress, ctx)
+}
+
+func (m *Model) start(corpus io.ReadSeeker, progress func(Progress), ctx context.Context) error {
+	size, err := corpus.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := corpus.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	epochWords, err := m.addVocab(corpus)
+	if err != nil {
+		return err
+	}
+	if epochWords == 0 {
+		return errors.Invalid("corpus", "corpus has no words seen at least %d times", m.opts.MinCount)
+	}
+	m.buildSampler()
+	m.state = trainState{Epoch: 1, EpochWords: epochWords, CorpusSize: size}
+	return m.run(corpus, progress, ctx)
+}
+
+func (m *Model) run(corpus io.ReadSeeker, progress func(Progress), ctx context.Context) error {
+	tracker := newTracker(progress, m.state.Words)
+	m.lastCheckpoint = time.Now()
+	for ; m.state.Epoch <= m.opts.Iter; m.state.Epoch++ {
+		if _, err := corpus.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := m.trainEpoch(corpus, tracker, ctx); err != nil {
+			return err
+		}
+		tracker.report(m)
+		if err := m.saveCheckpoint(m.state.Epoch+1, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addVocab counts the words of corpus, adds the ones seen at least opts.MinCount times to the
+// vocabulary and returns how many of the corpus words are in it
+func (m *Model) addVocab(corpus io.Reader) (int64, error) {
+	var order []string
+	counts := map[string]int64{}
+	scanner := newScanner(corpus)
+	for scanner.Scan() {
+		word := scanner.Text()
+		if word == lineBreak {
+			continue
+		}
+		if counts[word] == 0 {
+			order = append(order, word)
+		}
+		counts[word]++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	var epochWords int64
+	for _, word := range order {
+		i, ok := m.index[word]
+		if !ok {
+			if counts[word] < int64(m.opts.MinCount) {
+				continue
+			}
+			i = len(m.vocab)
+			m.index[word] = i
+			m.vocab = append(m.vocab, word)
+			m.counts = append(m.counts, 0)
+		}
+		m.counts[i] += counts[word]
+		m.total += counts[word]
+		epochWords += counts[word]
+	}
+	m.growVectors()
+	return epochWords, nil
+}
+
+// growVectors gives the words added to the vocabulary random word vectors and zero context vectors
+func (m *Model) growVectors() {
+	dim := m.opts.Dim
+	for len(m.in) < len(m.vocab)*dim {
+		m.in = append(m.in, (m.rng.Float32()-0.5)/float32(dim))
+	}
+	for len(m.out) < len(m.vocab)*dim {
+		m.out = append(m.out, 0)
+	}
+}
+
+func (m *Model) buildSampler() {
+	m.cdf = make([]float64, len(m.counts))
+	sum := 0.0
+	for i, c := range m.counts {
+		sum += math.Pow(float64(c), 0.75)
+		m.cdf[i] = sum
+	}
+	for i := range m.cdf {
+		m.cdf[i] /= sum
+	}
+}
+
+func (m *Model) sample() int {
+	i := sort.SearchFloat64s(m.cdf, m.rng.Float64())
+	if i >= len(m.cdf) {
+		i = len(m.cdf) - 1
+	}
+	return i
+}
+
+// keep decides whether an occurrence of word survives subsampling
+func (m *Model) keep(word int) bool {
+	if m.opts.Subsample == 0 {
+		return true
+	}
+	f := float64(m.counts[word]) / float64(m.total)
+	t := m.opts.Subsample
+	return m.rng.Float64() < (math.Sqrt(f/t)+1)*t/f
+}
+
+// learningRate decays linearly over the run
+func (m *Model) learningRate() float64 {
+	totalWords := m.state.EpochWords * int64(m.opts.Iter)
+	lr := m.opts.InitLR * (1 - float64(m.state.Words)/float64(totalWords+1))
+	if min := m.opts.InitLR * 1e-4; lr < min {
+		return min
+	}
+	return lr
+}
+
+// trainEpoch trains the words of corpus after the first state.Offset, which an earlier session
+// already trained
+func (m *Model) trainEpoch(corpus io.Reader, tracker *tracker, ctx context.Context) error {
+	skip := m.state.Offset
+	var offset int64
+	sentence := make([]int, 0, maxSentence)
+	sinceCheck := 0
+	scanner := newScanner(corpus)
+	for scanner.Scan() {
+		word, ok := m.index[scanner.Text()]
+		if !ok {
+			continue
+		}
+		if offset++; offset <= skip {
+			continue
+		}
+		m.state.Words++
+		if m.keep(word) {
+			sentence = append(sentence, word)
+		}
+		if len(sentence) == maxSentence {
+			m.trainSentence(sentence, m.learningRate())
+			sentence = sentence[:0]
+			// Every word so far is trained, so this is a position training can resume from
+			m.state.Offset = offset
+			if m.checkpointEvery > 0 && time.Since(m.lastCheckpoint) >= m.checkpointEvery {
+				if err := m.saveCheckpoint(m.state.Epoch, offset); err != nil {
+					return err
+				}
+			}
+		}
+		if sinceCheck++; sinceCheck == checkEvery {
+			sinceCheck = 0
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tracker.tick(m)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.trainSentence(sentence, m.learningRate())
+	m.state.Offset = 0
+	return ctx.Err()
+}
+
+// saveCheckpoint hands the model to the checkpoint func as if it were at epoch and offset
+func (m *Model) saveCheckpoint(epoch int, offset int64) error {
+	if m.checkpoint == nil {
+		return nil
+	}
+	current := m.state
+	m.state.Epoch, m.state.Offset = epoch, offset
+	err := m.checkpoint(m)
+	m.state = current
+	m.lastCheckpoint = time.Now()
+	return err
+}
+
+func (m *Model) trainSentence(sentence []int, lr float64) {
+	dim := m.opts.Dim
+	hidden := make([]float32, dim)
+	grad := make([]float32, dim)
+	for pos, word := range sentence {
+		// Like the original word2vec, sample the effective window size so nearer words weigh more
+		window := m.opts.Window - m.rng.Intn(m.opts.Window)
+		start, end := pos-window, pos+window
+		if start < 0 {
+			start = 0
+		}
+		if end >= len(sentence) {
+			end = len(sentence) - 1
+		}
+
+		if m.opts.Model == Cbow {
+			clear(hidden)
+			n := 0
+			for c := start; c <= end; c++ {
+				if c != pos {
+					add(hidden, m.row(m.in, sentence[c]), 1)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			scale(hidden, 1/float32(n))
+			clear(grad)
+			m.negativeSampling(word, hidden, grad, lr)
+			for c := start; c <= end; c++ {
+				if c != pos {
+					add(m.row(m.in, sentence[c]), grad, 1)
+				}
+			}
+			continue
+		}
+
+		for c := start; c <= end; c++ {
+			if c == pos {
+				continue
+			}
+			contextVector := m.row(m.in, sentence[c])
+			clear(grad)
+			m.negativeSampling(word, contextVector, grad, lr)
+			add(contextVector, grad, 1)
+		}
+	}
+}
+
+// negativeSampling trains the context vectors to tell target apart from opts.Negative random
+// words given hidden, and accumulates the gradient for hidden in grad
+func (m *Model) negativeSampling(target int, hidden []float32, grad []float32, lr float64) {
+	for d := 0; d <= m.opts.Negative; d++ {
+		word, label := target, 1.0
+		if d > 0 {
+			if word = m.sample(); word == target {
+				continue
+			}
+			label = 0
+		}
+		out := m.row(m.out, word)
+		g := float32((label - sigmoid(float64(vecmath.Dot(hidden, out)))) * lr)
+		add(grad, out, g)
+		add(out, hidden, g)
+	}
+}
+
+func (m *Model) row(vectors []float32, word int) []float32 {
+	return vectors[word*m.opts.Dim : (word+1)*m.opts.Dim]
+}
+
+// Vector returns the saved vector of word: the sum of its word and context vectors
+func (m *Model) Vector(word string) ([]float32, bool) {
+	i, ok := m.index[word]
+	if !ok || m.in == nil {
+		return nil, false
+	}
+	vector := make([]float32, m.opts.Dim)
+	add(vector, m.row(m.in, i), 1)
+	add(vector, m.row(m.out, i), 1)
+	return vector, true
+}
+
+// Save writes one line per word, the word followed by its vector, in the text format wego wrote
+func (m *Model) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, word := range m.vocab {
+		vector, _ := m.Vector(word)
+		bw.WriteString(word)
+		for _, v := range vector {
+			fmt.Fprintf(bw, " %f", v)
+		}
+		bw.WriteString(" \n")
+	}
+	return bw.Flush()
+}
+
+func sigmoid(x float64) float64 {
+	switch {
+	case x > 6:
+		return 1
+	case x < -6:
+		return 0
+	}
+	return 1 / (1 + math.Exp(-x))
+}
+
+// add sets dst += s * src
+func add(dst, src []float32, s float32) {
+	for i := range dst {
+		dst[i] += s * src[i]
+	}
+}
+
+func scale(v []float32, s float32) {
+	for i := range v {
+		v[i] *= s
+	}
+}
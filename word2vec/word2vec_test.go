@@ -0,0 +1,196 @@
+package word2vec
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"milvus/errors"
+	"milvus/vecmath"
+)
+
+// corpus returns sentences that each mix words from only one of two groups
+func corpus(sentences int) *strings.Reader {
+	groups := [][]string{
+		{"cat", "dog", "puppy", "kitten", "mouse"},
+		{"tiger", "lion", "leopard", "lizard", "alligator"},
+	}
+	rng := rand.New(rand.NewSource(7))
+	var sb strings.Builder
+	for i := 0; i < sentences; i++ {
+		group := groups[i%2]
+		for j := 0; j < 8; j++ {
+			sb.WriteString(group[rng.Intn(len(group))])
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte('\n')
+	}
+	return strings.NewReader(sb.String())
+}
+
+func cosine(a, b []float32) float64 {
+	return float64(vecmath.Dot(a, b)) / (vecmath.Norm(a) * vecmath.Norm(b))
+}
+
+func TestTrain(t *testing.T) {
+	tests := []struct {
+		name  string
+		model ModelType
+	}{
+		{name: "CBOW", model: Cbow},
+		{name: "Skip-gram", model: SkipGram},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Model = tt.model
+			opts.Dim = 16
+			opts.Subsample = 0
+			model, err := New(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := model.Train(corpus(600), nil, context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cat, _ := model.Vector("cat")
+			dog, _ := model.Vector("dog")
+			lion, _ := model.Vector("lion")
+			if same, other := cosine(cat, dog), cosine(cat, lion); same <= other {
+				t.Errorf("cos(cat, dog) = %.3f is not above cos(cat, lion) = %.3f", same, other)
+			}
+
+			var buf bytes.Buffer
+			if err := model.Save(&buf); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != len(model.Words()) || len(lines) != 10 {
+				t.Fatalf("saved %d lines for %d words", len(lines), len(model.Words()))
+			}
+			if fields := strings.Fields(lines[0]); len(fields) != 17 {
+				t.Errorf("saved %d fields per line, want 17", len(fields))
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *Options)
+	}{
+		{name: "Zero dimension", modify: func(o *Options) { o.Dim = 0 }},
+		{name: "Zero window", modify: func(o *Options) { o.Window = 0 }},
+		{name: "Unknown model", modify: func(o *Options) { o.Model = 5 }},
+		{name: "No epochs", modify: func(o *Options) { o.Iter = 0 }},
+		{name: "Negative learning rate", modify: func(o *Options) { o.InitLR = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if _, err := New(opts); !stdErrors.Is(err, errors.ErrValidation) {
+				t.Errorf("expected a validation error, got %v", err)
+			}
+		})
+	}
+
+	model, _ := New(DefaultOptions())
+	if err := model.Train(strings.NewReader(""), nil, context.Background()); !stdErrors.Is(err, errors.ErrValidation) {
+		t.Errorf("expected a validation error for an empty corpus, got %v", err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	defer func(interval time.Duration) { ReportInterval = interval }(ReportInterval)
+	ReportInterval = 0
+
+	opts := DefaultOptions()
+	opts.Iter = 3
+	model, _ := New(opts)
+	var reports []Progress
+	if err := model.Train(corpus(400), func(p Progress) { reports = append(reports, p) }, context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reports) < 3 {
+		t.Fatalf("got %d progress reports, want at least one per epoch", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		prev, cur := reports[i-1], reports[i]
+		if cur.Words < prev.Words || cur.Epoch < prev.Epoch || cur.LearningRate > prev.LearningRate {
+			t.Errorf("progress went backwards: %+v after %+v", cur, prev)
+		}
+	}
+	last := reports[len(reports)-1]
+	if last.Epoch != 3 || last.Epochs != 3 || last.Words != last.TotalWords || last.Fraction() != 1 {
+		t.Errorf("last report = %+v", last)
+	}
+	if last.TotalWords != 3*400*8 {
+		t.Errorf("TotalWords = %d, want %d", last.TotalWords, 3*400*8)
+	}
+	if last.LearningRate >= opts.InitLR || last.WordsPerSec <= 0 {
+		t.Errorf("last report = %+v", last)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	model, _ := New(DefaultOptions())
+	epochs := 0
+	err := model.Train(corpus(400), func(p Progress) {
+		if p.Epoch == 2 {
+			cancel()
+		}
+		epochs = p.Epoch
+	}, ctx)
+	if !stdErrors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if epochs != 2 {
+		t.Errorf("training went on to epoch %d after being cancelled in epoch 2", epochs)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	type token struct {
+		word    string
+		lineEnd bool
+	}
+	tests := []struct {
+		name     string
+		text     string
+		expected []token
+	}{
+		{name: "Single line", text: "the cat sat", expected: []token{{"the", false}, {"cat", false}, {"sat", true}}},
+		{
+			name:     "Lines, blank lines and CRLF",
+			text:     "  the cat\r\n\n\tsat  on the mat \n",
+			expected: []token{{"the", false}, {"cat", true}, {"sat", false}, {"on", false}, {"the", false}, {"mat", true}},
+		},
+		{name: "Whitespace only", text: " \n\t\n ", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []token
+			err := Tokenize(strings.NewReader(tt.text), func(word string, lineEnd bool) {
+				got = append(got, token{word, lineEnd})
+			})
+			if err != nil || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Tokenize(%q) = %v, %v, want %v", tt.text, got, err, tt.expected)
+			}
+		})
+	}
+}